@@ -0,0 +1,274 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// KMeans clusters the documents (columns) of a matrix into K clusters using spherical
+// k-means i.e. k-means using cosine distance rather than Euclidean distance.  Each document
+// vector is normalised to unit length and assigned to the cluster whose centroid it is most
+// similar to (has the largest cosine similarity with).  Each centroid is then recomputed as
+// the normalised mean of the documents assigned to it and the process repeated until the
+// assignments no longer change or MaxIter iterations have been performed.  Cosine distance
+// is well suited to clustering text as it is insensitive to the lengths of the documents.
+// Initial centroids are chosen from amongst the documents using k-means++ seeding.
+type KMeans struct {
+	centroids  *mat64.Dense
+	labels     []int
+	iterations int
+
+	// K is the number of clusters
+	K int
+
+	// MaxIter is the maximum number of iterations (assignment and update steps) performed
+	// by Fit().
+	MaxIter int
+
+	// Tol is the convergence tolerance for Fit().  If greater than 0, fitting stops early
+	// once the relative change in the objective (the sum of the cosine similarities of the
+	// documents to the centroids of their assigned clusters) between successive iterations
+	// falls below Tol.  Fitting always stops once the assignments no longer change.  A value
+	// of 0 (the default) disables the tolerance.
+	Tol float64
+
+	// Progress, if set, is called following the assignment step of each iteration of Fit()
+	// with the objective (the sum of the cosine similarities of the documents to the
+	// centroids of their assigned clusters).
+	Progress ProgressFunc
+
+	// Seed is used to seed the random number generator used to choose the initial
+	// centroids so that results are reproducible.
+	Seed int64
+
+	// Source, if set, is used as the source of random numbers in place of a source seeded
+	// with Seed in the same way as RandomProjection.
+	Source rand.Source
+}
+
+// NewKMeans creates a new KMeans clusterer with k clusters, using the specified seed for
+// the random number generator.  MaxIter is initialised to 100.
+func NewKMeans(k int, seed int64) *KMeans {
+	return &KMeans{K: k, MaxIter: 100, Seed: seed}
+}
+
+// Fit clusters the columns (documents) of the supplied matrix into K clusters.  The cluster
+// assigned to each document and the centroid of each cluster are available through Labels()
+// and Centroids() respectively.  If a cluster becomes empty during fitting, its centroid is
+// reseeded with the document least similar to the centroid of its own cluster.  An error is
+// returned if K is less than 1 or greater than the number of documents.
+func (k *KMeans) Fit(mat mat64.Matrix) error {
+	m, n := mat.Dims()
+	if k.K < 1 || k.K > n {
+		return fmt.Errorf("%w: K (%d) must be between 1 and the number of documents (%d)", ErrInvalidArgument, k.K, n)
+	}
+
+	docs := mat64.DenseCopyOf(mat)
+	normaliseColumns(docs, L2Norm)
+	rnd := newRand(k.Source, k.Seed)
+	centroids := k.seedCentroids(docs, rnd)
+
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = -1
+	}
+	similarities := make([]float64, n)
+	counts := make([]int, k.K)
+	col := make([]float64, m)
+
+	var sims mat64.Dense
+	var objective float64
+	k.iterations = 0
+	for k.iterations < k.MaxIter {
+		k.iterations++
+
+		// assign each document to the most similar centroid
+		sims.Mul(centroids.T(), docs)
+		changed := false
+		previous := objective
+		objective = 0
+		for j := 0; j < n; j++ {
+			best := 0
+			for c := 1; c < k.K; c++ {
+				if sims.At(c, j) > sims.At(best, j) {
+					best = c
+				}
+			}
+			if labels[j] != best {
+				labels[j] = best
+				changed = true
+			}
+			similarities[j] = sims.At(best, j)
+			objective += similarities[j]
+		}
+		if k.Progress != nil {
+			k.Progress(k.iterations, objective)
+		}
+		if !changed || (k.Tol > 0 && k.iterations > 1 && math.Abs(objective-previous) < k.Tol*math.Abs(previous)) {
+			break
+		}
+
+		// recompute each centroid as the normalised mean of its documents
+		for c := range counts {
+			counts[c] = 0
+		}
+		next := mat64.NewDense(m, k.K, nil)
+		for j, c := range labels {
+			counts[c]++
+			mat64.Col(col, j, docs)
+			for i, v := range col {
+				next.Set(i, c, next.At(i, c)+v)
+			}
+		}
+		normaliseColumns(next, L2Norm)
+		for c, count := range counts {
+			if count == 0 {
+				k.reseed(next, c, docs, labels, similarities)
+			}
+		}
+		centroids = next
+	}
+
+	k.centroids = centroids
+	k.labels = labels
+	return nil
+}
+
+// Iterations returns the number of iterations performed during the last call to Fit().
+// This will be less than MaxIter if fitting converged early.
+func (k *KMeans) Iterations() int {
+	return k.iterations
+}
+
+// Labels returns the index of the cluster assigned to each document (column) of the matrix
+// supplied to Fit().  If the clusterer has not been fitted then nil is returned.
+func (k *KMeans) Labels() []int {
+	if k.labels == nil {
+		return nil
+	}
+	labels := make([]int, len(k.labels))
+	copy(labels, k.labels)
+	return labels
+}
+
+// Centroids returns an m x K matrix where each column is the (unit length) centroid of the
+// corresponding cluster and m is the number of rows of the matrix supplied to Fit().  If
+// the clusterer has not been fitted then nil is returned.
+func (k *KMeans) Centroids() *mat64.Dense {
+	if k.centroids == nil {
+		return nil
+	}
+	return mat64.DenseCopyOf(k.centroids)
+}
+
+// ClusterRepresentatives returns a representative document for each cluster, the index of
+// the document (column) of mat with the highest cosine similarity to the centroid of its
+// cluster, where labels[j] is the cluster of document j (e.g. as returned by
+// KMeans.Labels()) and each column of centroids is the centroid of the corresponding
+// cluster (e.g. as returned by KMeans.Centroids()).  Unlike the centroids themselves, the
+// representatives are actual documents and so may be presented to summarise the contents of
+// each cluster.  Only the documents assigned to a cluster are considered as its
+// representative with ties resolved in favour of the lowest index.  The representative of a
+// cluster with no documents is -1.  If the length of labels differs from the number of
+// documents, the number of rows of centroids differs from the number of rows of mat or any
+// label is not the index of a column of centroids then nil is returned.
+func ClusterRepresentatives(mat mat64.Matrix, labels []int, centroids *mat64.Dense) []int {
+	m, n := mat.Dims()
+	r, k := centroids.Dims()
+	if len(labels) != n || r != m {
+		return nil
+	}
+	for _, c := range labels {
+		if c < 0 || c >= k {
+			return nil
+		}
+	}
+
+	representatives := make([]int, k)
+	best := make([]float64, k)
+	for c := range representatives {
+		representatives[c] = -1
+	}
+	col := make([]float64, m)
+	doc := mat64.NewVector(m, col)
+	for j, c := range labels {
+		mat64.Col(col, j, mat)
+		similarity := CosineSimilarity(doc, centroids.ColView(c))
+		if representatives[c] == -1 || similarity > best[c] {
+			representatives[c] = j
+			best[c] = similarity
+		}
+	}
+	return representatives
+}
+
+// seedCentroids chooses K documents as the initial centroids using k-means++ seeding.  The
+// first centroid is chosen uniformly at random and each subsequent centroid is chosen with
+// probability proportional to the square of its cosine distance from the nearest centroid
+// already chosen, spreading the initial centroids out across the documents.
+func (k *KMeans) seedCentroids(docs *mat64.Dense, rnd *rand.Rand) *mat64.Dense {
+	m, n := docs.Dims()
+	centroids := mat64.NewDense(m, k.K, nil)
+	col := make([]float64, m)
+	nearest := make([]float64, n)
+	for j := range nearest {
+		nearest[j] = math.Inf(1)
+	}
+
+	taken := make([]bool, n)
+	chosen := rnd.Intn(n)
+	for c := 0; c < k.K; c++ {
+		taken[chosen] = true
+		mat64.Col(col, chosen, docs)
+		centroids.SetCol(c, col)
+		centroid := mat64.NewVector(m, col)
+
+		var total float64
+		for j := 0; j < n; j++ {
+			d := 1 - mat64.Dot(centroid, docs.ColView(j))
+			if d *= d; d < nearest[j] {
+				nearest[j] = d
+			}
+			if !taken[j] {
+				total += nearest[j]
+			}
+		}
+
+		// choose the next centroid with probability proportional to its squared distance,
+		// falling back to the first document not yet chosen if the remaining documents are
+		// all identical to the chosen centroids
+		chosen = -1
+		target := rnd.Float64() * total
+		for j, d := range nearest {
+			if taken[j] {
+				continue
+			}
+			if chosen < 0 {
+				chosen = j
+			}
+			if target -= d; target < 0 {
+				chosen = j
+				break
+			}
+		}
+	}
+	return centroids
+}
+
+// reseed replaces the centroid of empty cluster c with the document least similar to the
+// centroid of the cluster to which it is currently assigned, moving the document to
+// cluster c.
+func (k *KMeans) reseed(centroids *mat64.Dense, c int, docs *mat64.Dense, labels []int, similarities []float64) {
+	worst := 0
+	for j, s := range similarities {
+		if s < similarities[worst] {
+			worst = j
+		}
+	}
+	centroids.SetCol(c, mat64.Col(nil, worst, docs))
+	labels[worst] = c
+	similarities[worst] = 1
+}
@@ -0,0 +1,200 @@
+package nlp
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestKMeans(t *testing.T) {
+	m, perCluster, k := 9, 10, 3
+
+	// 3 well separated clusters where the documents of each cluster predominantly contain
+	// a different third of the terms.  Documents are interleaved so the expected cluster
+	// of document j is j % k.  Documents have very different lengths which should not
+	// affect the clustering.
+	rnd := rand.New(rand.NewSource(7))
+	n := perCluster * k
+	input := mat64.NewDense(m, n, nil)
+	for j := 0; j < n; j++ {
+		cluster := j % k
+		for i := 0; i < m; i++ {
+			v := rnd.Float64() * 0.1
+			if i/(m/k) == cluster {
+				v += 1 + rnd.Float64()*0.5
+			}
+			input.Set(i, j, v*float64(j+1))
+		}
+	}
+
+	kmeans := NewKMeans(k, 1)
+	if kmeans.Labels() != nil || kmeans.Centroids() != nil {
+		t.Errorf("Expected nil labels and centroids before fitting")
+	}
+	if err := kmeans.Fit(input); err != nil {
+		t.Fatalf("Failed to fit KMeans caused by %v", err)
+	}
+
+	labels := kmeans.Labels()
+	if len(labels) != n {
+		t.Fatalf("Expected %d labels but found %d", n, len(labels))
+	}
+	clusters := make(map[int]int)
+	for j, label := range labels {
+		expected, ok := clusters[j%k]
+		if !ok {
+			clusters[j%k] = label
+			continue
+		}
+		if label != expected {
+			t.Errorf("Expected document %d to be in cluster %d but found %d", j, expected, label)
+		}
+	}
+	seen := make(map[int]bool)
+	for _, label := range clusters {
+		seen[label] = true
+	}
+	if len(seen) != k {
+		t.Errorf("Expected %d distinct clusters but found %v", k, clusters)
+	}
+
+	centroids := kmeans.Centroids()
+	if r, c := centroids.Dims(); r != m || c != k {
+		t.Fatalf("Expected centroids matrix %d x %d but found %d x %d", m, k, r, c)
+	}
+	for c := 0; c < k; c++ {
+		if norm := mat64.Norm(centroids.ColView(c), 2); math.Abs(norm-1) > 0.000001 {
+			t.Errorf("Expected centroid %d to be unit length but found %f", c, norm)
+		}
+	}
+	for j, label := range labels {
+		if sim := CosineSimilarity(input.ColView(j), centroids.ColView(label)); sim < 0.9 {
+			t.Errorf("Expected document %d to be similar to its centroid but found %f", j, sim)
+		}
+	}
+
+	// the same seed should produce the same clustering
+	kmeans2 := NewKMeans(k, 1)
+	kmeans2.Fit(input)
+	if !mat64.Equal(centroids, kmeans2.Centroids()) {
+		t.Errorf("Expected identical centroids for the same seed")
+	}
+
+	// the well separated clusters should converge well within MaxIter
+	if it := kmeans.Iterations(); it < 1 || it >= kmeans.MaxIter {
+		t.Errorf("Expected fitting to converge early but found %d iterations", it)
+	}
+	var calls int
+	kmeans2.Progress = func(iteration int, metric float64) {
+		calls++
+		if iteration != calls || metric <= 0 || metric > float64(n) {
+			t.Errorf("Expected iteration %d with objective between 0 and %d but found %d and %f", calls, n, iteration, metric)
+		}
+	}
+	kmeans2.Tol = 0.5
+	kmeans2.Fit(input)
+	if calls != kmeans2.Iterations() {
+		t.Errorf("Expected %d progress callbacks but found %d", kmeans2.Iterations(), calls)
+	}
+	if it := kmeans2.Iterations(); it != 2 {
+		t.Errorf("Expected fitting to stop after 2 iterations with a large tolerance but found %d", it)
+	}
+
+	for _, invalid := range []int{0, n + 1} {
+		if err := NewKMeans(invalid, 1).Fit(input); err == nil {
+			t.Errorf("Expected error fitting with K = %d but found none", invalid)
+		}
+	}
+}
+
+func TestKMeansIdenticalDocuments(t *testing.T) {
+	// all but one document are identical so at least one cluster must be reseeded
+	input := mat64.NewDense(2, 5, []float64{
+		1, 1, 1, 1, 0,
+		0, 0, 0, 0, 1,
+	})
+
+	kmeans := NewKMeans(3, 1)
+	if err := kmeans.Fit(input); err != nil {
+		t.Fatalf("Failed to fit KMeans caused by %v", err)
+	}
+
+	labels := kmeans.Labels()
+	for j := 1; j < 4; j++ {
+		if labels[j] == labels[4] {
+			t.Errorf("Expected document %d in a different cluster to document 4 but found %v", j, labels)
+		}
+	}
+	centroids := kmeans.Centroids()
+	for c := 0; c < 3; c++ {
+		if norm := mat64.Norm(centroids.ColView(c), 2); math.Abs(norm-1) > 0.000001 {
+			t.Errorf("Expected centroid %d to be unit length but found %f", c, norm)
+		}
+	}
+}
+
+func TestClusterRepresentatives(t *testing.T) {
+	// 2 clusters of documents around the directions (1, 0, 0) and (0, 1, 0) with a third,
+	// empty, cluster.  The closest document of each cluster (by angle rather than length)
+	// is document 2 and document 3 respectively.
+	input := mat64.NewDense(3, 6, []float64{
+		5, 2, 10, 0.1, 1, 0,
+		1, 1, 0.5, 4, 0.5, 3,
+		1, 0, 0.5, 0, 0.5, 1,
+	})
+	labels := []int{0, 0, 0, 1, 0, 1}
+	centroids := mat64.NewDense(3, 3, []float64{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+	})
+
+	representatives := ClusterRepresentatives(input, labels, centroids)
+	expected := []int{2, 3, -1}
+	if !reflect.DeepEqual(expected, representatives) {
+		t.Fatalf("Expected representatives %v but found %v", expected, representatives)
+	}
+
+	// verify each representative is the closest document of its cluster to the centroid
+	for c, rep := range representatives {
+		if rep == -1 {
+			continue
+		}
+		best := CosineSimilarity(input.ColView(rep), centroids.ColView(c))
+		for j, label := range labels {
+			if label == c && CosineSimilarity(input.ColView(j), centroids.ColView(c)) > best {
+				t.Errorf("Expected document %d to be no closer to centroid %d than representative %d", j, c, rep)
+			}
+		}
+	}
+
+	// representatives of clusters found by KMeans are members of their clusters
+	kmeans := NewKMeans(2, 1)
+	if err := kmeans.Fit(input); err != nil {
+		t.Fatalf("Failed to fit KMeans caused by %v", err)
+	}
+	fitted := kmeans.Labels()
+	for c, rep := range ClusterRepresentatives(input, fitted, kmeans.Centroids()) {
+		if rep == -1 || fitted[rep] != c {
+			t.Errorf("Expected representative of cluster %d to be a member of the cluster but found %d", c, rep)
+		}
+	}
+
+	var tests = []struct {
+		labels    []int
+		centroids *mat64.Dense
+	}{
+		{labels[:5], centroids},
+		{labels, mat64.NewDense(2, 3, nil)},
+		{[]int{0, 0, 0, 3, 0, 1}, centroids},
+		{[]int{0, 0, 0, -1, 0, 1}, centroids},
+	}
+	for _, test := range tests {
+		if representatives := ClusterRepresentatives(input, test.labels, test.centroids); representatives != nil {
+			t.Errorf("Expected nil representatives for invalid input but found %v", representatives)
+		}
+	}
+}
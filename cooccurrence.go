@@ -0,0 +1,88 @@
+package nlp
+
+import (
+	"math"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// CooccurrenceMatrix constructs a symmetric term-term co-occurrence matrix from the supplied
+// documents along with a vocabulary mapping each term to its row (and column) within the
+// matrix.  Documents are converted to lower case and tokenised into words using the default
+// tokeniser used by the vectorisers and terms are assigned indices in the order they first
+// occur.  Element (i, j) is the number of times term j occurs within window words either
+// side of an occurance of term i within the same document e.g. with a window of 1 only
+// immediately adjacent words co-occur.  Windows are truncated at the start and end of each
+// document and do not span documents.  A word never co-occurs with itself (its own
+// position) but repeated occurances of the same term within a window are counted along the
+// diagonal.  Co-occurrence matrices are the basis of distributional word representations
+// (see PPMI()) and collocation analysis.
+func CooccurrenceMatrix(docs []string, window int) (*mat64.Dense, map[string]int) {
+	tokeniser := newDefaultTokeniser()
+	vocabulary := make(map[string]int)
+
+	tokenised := make([][]int, len(docs))
+	for d, doc := range docs {
+		words := tokeniser.Tokenise(strings.ToLower(doc))
+		tokenised[d] = make([]int, len(words))
+		for w, word := range words {
+			i, exists := vocabulary[word]
+			if !exists {
+				i = len(vocabulary)
+				vocabulary[word] = i
+			}
+			tokenised[d][w] = i
+		}
+	}
+
+	mat := mat64.NewDense(len(vocabulary), len(vocabulary), nil)
+	for _, words := range tokenised {
+		for w, i := range words {
+			for o := w + 1; o <= w+window && o < len(words); o++ {
+				j := words[o]
+				mat.Set(i, j, mat.At(i, j)+1)
+				mat.Set(j, i, mat.At(j, i)+1)
+			}
+		}
+	}
+	return mat, vocabulary
+}
+
+// PPMI returns the Positive Pointwise Mutual Information (PPMI) weighting of the supplied
+// co-occurrence matrix (as produced by CooccurrenceMatrix()).  The PMI of terms i and j is
+// the log of the ratio of the probability of them co-occurring to the probability of them
+// co-occurring if they were independent:
+//
+//	pmi(i, j) = log(p(i, j) / (p(i) * p(j)))
+//
+// where the probabilities are estimated from the co-occurrence counts, p(i) from the row
+// sums and p(j) from the column sums.  PPMI replaces negative values (and pairs that never
+// co-occur) with 0 as negative PMI values are unreliable for small corpora.  PPMI weighted
+// co-occurrence matrices are commonly used as (sparse) word embeddings or factorised with
+// TruncatedSVD to produce dense embeddings.
+func PPMI(mat mat64.Matrix) *mat64.Dense {
+	rows, cols := RowSums(mat), ColSums(mat)
+	var total float64
+	for _, v := range rows {
+		total += v
+	}
+
+	m, n := mat.Dims()
+	ppmi := mat64.NewDense(m, n, nil)
+	if total == 0 {
+		return ppmi
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			v := mat.At(i, j)
+			if v <= 0 {
+				continue
+			}
+			if pmi := math.Log(v * total / (rows[i] * cols[j])); pmi > 0 {
+				ppmi.Set(i, j, pmi)
+			}
+		}
+	}
+	return ppmi
+}
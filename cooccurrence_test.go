@@ -0,0 +1,117 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestCooccurrenceMatrix(t *testing.T) {
+	docs := []string{"The cat sat on the mat", "mat cat"}
+
+	var tests = []struct {
+		window   int
+		expected []float64
+	}{
+		{
+			window: 1,
+			expected: []float64{
+				// the, cat, sat, on, mat
+				0, 1, 0, 1, 1,
+				1, 0, 1, 0, 1,
+				0, 1, 0, 1, 0,
+				1, 0, 1, 0, 0,
+				1, 1, 0, 0, 0,
+			},
+		},
+		{
+			window: 2,
+			expected: []float64{
+				0, 1, 2, 1, 1,
+				1, 0, 1, 1, 1,
+				2, 1, 0, 1, 0,
+				1, 1, 1, 0, 1,
+				1, 1, 0, 1, 0,
+			},
+		},
+		{
+			// both occurances of "the" fall within each other's window
+			window: 4,
+			expected: []float64{
+				2, 2, 2, 2, 1,
+				2, 0, 1, 1, 2,
+				2, 1, 0, 1, 1,
+				2, 1, 1, 0, 1,
+				1, 2, 1, 1, 0,
+			},
+		},
+		{
+			window:   0,
+			expected: make([]float64, 25),
+		},
+	}
+
+	for _, test := range tests {
+		mat, vocabulary := CooccurrenceMatrix(docs, test.window)
+
+		for i, term := range []string{"the", "cat", "sat", "on", "mat"} {
+			if vocabulary[term] != i {
+				t.Errorf("Expected term '%s' at index %d but found %v", term, i, vocabulary)
+			}
+		}
+		expected := mat64.NewDense(5, 5, test.expected)
+		if !mat64.Equal(expected, mat) {
+			t.Logf("Window %d: Expected matrix: \n%v\n but found: \n%v\n",
+				test.window,
+				mat64.Formatted(expected),
+				mat64.Formatted(mat))
+			t.Fail()
+		}
+	}
+}
+
+func TestPPMI(t *testing.T) {
+	var tests = []struct {
+		input    []float64
+		expected []float64
+	}{
+		{
+			// terms that only co-occur with each other
+			input:    []float64{0, 2, 2, 0},
+			expected: []float64{0, math.Log(2), math.Log(2), 0},
+		},
+		{
+			// co-occurrences consistent with independence have a PMI of 0
+			input:    []float64{1, 1, 1, 1},
+			expected: []float64{0, 0, 0, 0},
+		},
+		{
+			// negative PMI is replaced with 0
+			input: []float64{
+				4, 1,
+				1, 0,
+			},
+			expected: []float64{
+				0, math.Log(6 / 5.0),
+				math.Log(6 / 5.0), 0,
+			},
+		},
+		{
+			input:    []float64{0, 0, 0, 0},
+			expected: []float64{0, 0, 0, 0},
+		},
+	}
+
+	for ti, test := range tests {
+		result := PPMI(mat64.NewDense(2, 2, test.input))
+		expected := mat64.NewDense(2, 2, test.expected)
+		if !mat64.EqualApprox(expected, result, 0.000001) {
+			t.Logf("Test %d: Expected matrix: \n%v\n but found: \n%v\n",
+				ti,
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package nlp
+
+import (
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Dense32 is a dense matrix of single precision (float32) values stored in row major order.
+// It requires half the memory of a mat64.Dense of the same dimensions which can be
+// significant for large term document matrices where double precision is rarely needed for
+// approximate NLP tasks.  Dense32 implements the mat64.Matrix interface so may be used
+// anywhere a mat64.Matrix is accepted (values are converted to float64 on access) e.g. as
+// the input to the transformers within this package, although most transformers will
+// output double precision matrices.
+type Dense32 struct {
+	r, c int
+	data []float32
+}
+
+// NewDense32 creates a new r x c Dense32 matrix using data, in row major order, as its
+// backing store (the slice is not copied).  If data is nil, a new slice of zeros is
+// allocated.  NewDense32 will panic if data is not nil and does not contain r*c elements.
+func NewDense32(r, c int, data []float32) *Dense32 {
+	if data == nil {
+		data = make([]float32, r*c)
+	}
+	if len(data) != r*c {
+		panic("nlp: data must contain r*c elements")
+	}
+	return &Dense32{r: r, c: c, data: data}
+}
+
+// NewDense32From creates a new Dense32 matrix containing the values of the supplied matrix
+// converted to single precision.
+func NewDense32From(mat mat64.Matrix) *Dense32 {
+	m, n := mat.Dims()
+	d := NewDense32(m, n, nil)
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			d.data[i*n+j] = float32(v)
+		})
+		return d
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			d.data[i*n+j] = float32(mat.At(i, j))
+		}
+	}
+	return d
+}
+
+// Dims returns the number of rows and columns in the matrix
+func (d *Dense32) Dims() (int, int) {
+	return d.r, d.c
+}
+
+// At returns the element at row i and column j as a float64
+func (d *Dense32) At(i, j int) float64 {
+	return float64(d.At32(i, j))
+}
+
+// At32 returns the element at row i and column j
+func (d *Dense32) At32(i, j int) float32 {
+	if i < 0 || i >= d.r || j < 0 || j >= d.c {
+		panic("nlp: index out of range")
+	}
+	return d.data[i*d.c+j]
+}
+
+// Set32 sets the element at row i and column j to v
+func (d *Dense32) Set32(i, j int, v float32) {
+	if i < 0 || i >= d.r || j < 0 || j >= d.c {
+		panic("nlp: index out of range")
+	}
+	d.data[i*d.c+j] = v
+}
+
+// T returns the transpose of the matrix
+func (d *Dense32) T() mat64.Matrix {
+	return mat64.Transpose{Matrix: d}
+}
+
+// RawData returns the backing slice of the matrix, in row major order, so that changes to
+// the slice are reflected in the matrix.
+func (d *Dense32) RawData() []float32 {
+	return d.data
+}
+
+// ToDense returns a mat64.Dense (double precision) copy of the matrix
+func (d *Dense32) ToDense() *mat64.Dense {
+	data := make([]float64, len(d.data))
+	for i, v := range d.data {
+		data[i] = float64(v)
+	}
+	return mat64.NewDense(d.r, d.c, data)
+}
+
+// normaliseColumns scales each column of the matrix, in place, by its norm of the
+// specified type (accumulated in double precision).  Columns with a norm of zero are left
+// unchanged.
+func (d *Dense32) normaliseColumns(norm NormType) {
+	p, ok := norm.p()
+	if !ok {
+		return
+	}
+
+	for j := 0; j < d.c; j++ {
+		var length float64
+		for i := j; i < len(d.data); i += d.c {
+			v := math.Abs(float64(d.data[i]))
+			switch {
+			case math.IsInf(p, 1):
+				length = math.Max(length, v)
+			case p == 1:
+				length += v
+			default:
+				length += v * v
+			}
+		}
+		if p == 2 {
+			length = math.Sqrt(length)
+		}
+		if length == 0 {
+			continue
+		}
+		for i := j; i < len(d.data); i += d.c {
+			d.data[i] = float32(float64(d.data[i]) / length)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestDense32(t *testing.T) {
+	dense := mat64.NewDense(2, 3, []float64{
+		1, 0, 2.5,
+		0, -3, 0,
+	})
+
+	var tests = []struct {
+		name  string
+		input mat64.Matrix
+	}{
+		{"Dense", dense},
+		{"Sparse", NewSparseMatrixFrom(dense)},
+	}
+
+	for _, test := range tests {
+		mat := NewDense32From(test.input)
+		if r, c := mat.Dims(); r != 2 || c != 3 {
+			t.Errorf("%s: Expected 2 x 3 matrix but found %d x %d", test.name, r, c)
+		}
+		if !mat64.Equal(dense, mat) {
+			t.Logf("%s: Expected matrix: \n%v\n but found: \n%v\n",
+				test.name,
+				mat64.Formatted(dense),
+				mat64.Formatted(mat))
+			t.Fail()
+		}
+		if !mat64.Equal(dense.T(), mat.T()) {
+			t.Errorf("%s: Expected transposed matrices to be equal", test.name)
+		}
+		if !mat64.Equal(dense, mat.ToDense()) {
+			t.Errorf("%s: Expected ToDense() to equal the original matrix", test.name)
+		}
+	}
+
+	mat := NewDense32(2, 2, nil)
+	mat.Set32(1, 0, 4)
+	if mat.At32(1, 0) != 4 || mat.At(1, 0) != 4 || mat.RawData()[2] != 4 {
+		t.Errorf("Expected element (1, 0) to be set to 4 but found %v", mat.RawData())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic creating matrix with the wrong number of elements")
+		}
+	}()
+	NewDense32(2, 2, []float32{1, 2, 3})
+}
+
+func TestDense32NormaliseColumns(t *testing.T) {
+	for _, norm := range []NormType{NoNorm, L1Norm, L2Norm, MaxNorm} {
+		expected := mat64.NewDense(3, 2, []float64{
+			1, 0,
+			-2, 0,
+			3, 0,
+		})
+		mat := NewDense32From(expected)
+		normaliseColumns(expected, norm)
+		mat.normaliseColumns(norm)
+
+		if !mat64.EqualApprox(expected, mat, 0.000001) {
+			t.Logf("Norm %d: Expected matrix: \n%v\n but found: \n%v\n",
+				norm,
+				mat64.Formatted(expected),
+				mat64.Formatted(mat))
+			t.Fail()
+		}
+	}
+
+	// values should be accumulated in double precision
+	mat := NewDense32(2, 1, []float32{math.MaxFloat32, math.MaxFloat32})
+	mat.normaliseColumns(L2Norm)
+	if v := mat.At(0, 0); math.Abs(v-1/math.Sqrt2) > 0.000001 {
+		t.Errorf("Expected normalised value %f but found %f", 1/math.Sqrt2, v)
+	}
+}
@@ -1,17 +1,45 @@
 package nlp
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix"
 	"github.com/gonum/matrix/mat64"
 )
 
+// SVDMethod is the algorithm used by TruncatedSVD to compute the factorisation
+type SVDMethod int
+
+const (
+	// ExactSVD computes the full (thin) SVD of the input matrix before truncating it to
+	// K dimensions.  This is the default.
+	ExactSVD SVDMethod = iota
+
+	// RandomisedSVD approximates the top K singular values and vectors using the
+	// randomised range finder algorithm described in: Halko, N., Martinsson, P.G. and
+	// Tropp, J.A. Finding structure with randomness: Probabilistic algorithms for
+	// constructing approximate matrix decompositions. SIAM Review 53(2), 2011.  The input
+	// matrix is multiplied by a random matrix to find a small orthonormal basis
+	// approximating its range and the exact SVD is then computed for the much smaller
+	// matrix projected onto that basis.  This is significantly faster and uses
+	// significantly less memory than ExactSVD for very large matrices where K is much
+	// smaller than the dimensions of the matrix.
+	RandomisedSVD
+)
+
 // TruncatedSVD implements the Singular Value Decomposition factorisation of matrices.
 // This produces an approximation of the input matrix at a lower rank.  This is a core
 // component of LSA (Latent Semantic Analsis)
 type TruncatedSVD struct {
-	transform *mat64.Dense
+	transform      *mat64.Dense
+	singularValues []float64
+	variance       []float64
 
 	// K is the number of dimensions to which the output, transformed, matrix should be
 	// truncated to.  The matrix output by the FitTransform() and Transform() methods will
@@ -19,12 +47,35 @@ type TruncatedSVD struct {
 	// input matrix and min(m, n, K) is the lowest value of m, n, K where m is the number of
 	// rows in the original, input matrix.
 	K int
+
+	// Method is the algorithm used to compute the factorisation, either ExactSVD (the
+	// default) or RandomisedSVD.
+	Method SVDMethod
+
+	// Oversampling is the number of additional random vectors, beyond K, used by
+	// RandomisedSVD to sample the range of the input matrix.  Larger values improve the
+	// accuracy of the approximation at the cost of speed.
+	Oversampling int
+
+	// PowerIterations is the number of power iterations used by RandomisedSVD to improve
+	// the accuracy of the approximation for matrices whose singular values decay slowly
+	// (as is typical of term document matrices).  Each iteration requires 2 further
+	// multiplications by the input matrix.
+	PowerIterations int
+
+	// Seed is used to seed the random number generator used by RandomisedSVD so that
+	// results are reproducible.
+	Seed int64
+
+	// Source, if set, is used as the source of random numbers in place of a source seeded
+	// with Seed in the same way as RandomProjection.
+	Source rand.Source
 }
 
 // NewTruncatedSVD creates a new TruncatedSVD transformer with K (the truncated
 // dimensionality) being set to the specified value k
 func NewTruncatedSVD(k int) *TruncatedSVD {
-	return &TruncatedSVD{K: k}
+	return &TruncatedSVD{K: k, Oversampling: 10, PowerIterations: 4}
 }
 
 // Fit performs the SVD factorisation on the input training data matrix, mat and
@@ -36,8 +87,17 @@ func (t *TruncatedSVD) Fit(mat mat64.Matrix) Transformer {
 
 // Transform applies the transform decomposed from the training data matrix in Fit()
 // to the input matrix.  The resulting output matrix will be the closest approximation
-// to the input matrix at a reduced rank.
+// to the input matrix at a reduced rank.  An error is returned if the transformer has not
+// been fitted or the number of rows in the matrix differs from the training data matrix.
 func (t *TruncatedSVD) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if t.transform == nil {
+		return nil, fmt.Errorf("%w: TruncatedSVD", ErrNotFitted)
+	}
+	m, _ := mat.Dims()
+	if r, _ := t.transform.Dims(); m != r {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d", ErrDimensionMismatch, m, r)
+	}
+
 	var product mat64.Dense
 
 	product.Product(t.transform.T(), mat)
@@ -48,11 +108,19 @@ func (t *TruncatedSVD) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
 // FitTransform is approximately equivalent to calling Fit() followed by Transform() on the // same matrix.  This is a useful shortcut where separate trianing data is not being
 // used to fit the model i.e. the model is fitted on the fly to the test data.
 func (t *TruncatedSVD) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
-	var svd mat64.SVD
-	if ok := svd.Factorize(mat, matrix.SVDThin); !ok {
-		return nil, fmt.Errorf("Failed SVD Factorisation of working matrix")
+	var s []float64
+	var u, v *mat64.Dense
+	var total float64
+	var err error
+
+	if t.Method == RandomisedSVD {
+		s, u, v, total, err = t.randomisedSVD(mat)
+	} else {
+		s, u, v, total, err = t.exactSVD(mat)
+	}
+	if err != nil {
+		return nil, err
 	}
-	s, u, v := t.extractSVD(&svd)
 
 	m, n := mat.Dims()
 	min := minimum(t.K, m, n)
@@ -76,12 +144,268 @@ func (t *TruncatedSVD) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
 
 	t.transform = uk
 
+	// the proportion of the total variance (squared Frobenius norm) of the input matrix
+	// captured by each of the retained components
+	t.singularValues = make([]float64, min)
+	copy(t.singularValues, s)
+	t.variance = make([]float64, min)
+	for i := range t.variance {
+		if total != 0 {
+			t.variance[i] = (s[i] * s[i]) / total
+		}
+	}
+
 	var product mat64.Dense
 	product.Product(sigmak, vk.T())
 
 	return &product, nil
 }
 
+// Clone returns a deep copy of the transformer including its fitted transform.  The copy
+// shares any configured Source with the original.
+func (t *TruncatedSVD) Clone() Transformer {
+	c := *t
+	c.transform = copyDense(t.transform)
+	c.singularValues = copyFloats(t.singularValues)
+	c.variance = copyFloats(t.variance)
+	return &c
+}
+
+// truncatedSVDEncodingVersion is the version of the binary encoding of TruncatedSVD
+// written by MarshalBinary()
+const truncatedSVDEncodingVersion = 1
+
+// truncatedSVDState is the serialisable state of a TruncatedSVD.  Fields may be added in
+// future versions but existing fields should not be removed or changed.
+type truncatedSVDState struct {
+	Transform       *mat64.Dense
+	SingularValues  []float64
+	Variance        []float64
+	K               int
+	Method          SVDMethod
+	Oversampling    int
+	PowerIterations int
+	Seed            int64
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface, encoding the fitted
+// transform, singular values and configuration of the transformer so that it may be
+// persisted and later restored with UnmarshalBinary().  Any configured Source is not
+// encoded.  The encoding begins with a version number to allow the format to evolve.
+func (t *TruncatedSVD) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(truncatedSVDEncodingVersion)
+
+	state := truncatedSVDState{
+		Transform:       t.transform,
+		SingularValues:  t.singularValues,
+		Variance:        t.variance,
+		K:               t.K,
+		Method:          t.Method,
+		Oversampling:    t.Oversampling,
+		PowerIterations: t.PowerIterations,
+		Seed:            t.Seed,
+	}
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, fmt.Errorf("Failed to encode TruncatedSVD caused by %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface, restoring the state
+// of a transformer previously encoded with MarshalBinary().  Any existing state of the
+// transformer, other than its Source, is replaced.
+func (t *TruncatedSVD) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("Failed to decode TruncatedSVD: no data")
+	}
+	if version := data[0]; version != truncatedSVDEncodingVersion {
+		return fmt.Errorf("Failed to decode TruncatedSVD: unsupported encoding version %d", version)
+	}
+
+	var state truncatedSVDState
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&state); err != nil {
+		return fmt.Errorf("Failed to decode TruncatedSVD caused by %w", err)
+	}
+
+	t.transform = state.Transform
+	t.singularValues = state.SingularValues
+	t.variance = state.Variance
+	t.K = state.K
+	t.Method = state.Method
+	t.Oversampling = state.Oversampling
+	t.PowerIterations = state.PowerIterations
+	t.Seed = state.Seed
+
+	return nil
+}
+
+// InverseTransform maps the supplied matrix, in the reduced K dimensional space output by
+// Transform() or FitTransform(), back into the original term space by multiplying it by
+// the transpose of the component matrix (the left singular vectors) learned during Fit().
+// The output matrix will be m rows by n columns, where m is the number of terms (rows) in
+// the original matrix and n is the number of columns in the supplied matrix.  This may be
+// used to interpret which terms a point in the reduced space emphasises.  The inverse is
+// lossy as the information contained in the discarded dimensions cannot be recovered so
+// the output is the closest rank K approximation of the original matrix unless K is equal
+// to or greater than the rank of the original matrix.
+func (t *TruncatedSVD) InverseTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if t.transform == nil {
+		return nil, fmt.Errorf("%w: TruncatedSVD", ErrNotFitted)
+	}
+	k, _ := mat.Dims()
+	if _, c := t.transform.Dims(); k != c {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted with %d components", ErrDimensionMismatch, k, c)
+	}
+
+	var product mat64.Dense
+	product.Mul(t.transform, mat)
+
+	return &product, nil
+}
+
+// ReconstructionError returns the Frobenius norm of the difference between the supplied
+// matrix and its reconstruction from the K dimensional space learned during Fit() i.e. the
+// result of Transform() followed by InverseTransform().  For the training data matrix this
+// is the error of the closest rank K approximation and so may be used to evaluate how much
+// information is lost by the reduction when selecting K.  The error decreases as K
+// increases, reaching 0 once K is equal to or greater than the rank of the matrix.  NaN is
+// returned if the transformer has not been fitted or the number of rows in the matrix
+// differs from the training data matrix.
+func (t *TruncatedSVD) ReconstructionError(mat mat64.Matrix) float64 {
+	reduced, err := t.Transform(mat)
+	if err != nil {
+		return math.NaN()
+	}
+	reconstructed, err := t.InverseTransform(reduced)
+	if err != nil {
+		return math.NaN()
+	}
+
+	reconstructed.Sub(mat, reconstructed)
+	return mat64.Norm(reconstructed, 2)
+}
+
+// TopTerms returns the k terms with the largest absolute loadings (weights) for the
+// specified component (latent dimension) learned during Fit(), in descending order of
+// absolute loading.  The top terms may be used to interpret the meaning of the component.
+// vocabulary maps each row (term) of the matrix supplied to Fit() to the corresponding term
+// and should contain an entry for every row.  If k exceeds the size of the vocabulary then
+// all the terms are returned.  If the transformer has not been fitted or component is not a
+// valid component index then nil is returned.
+func (t *TruncatedSVD) TopTerms(component, k int, vocabulary []string) []string {
+	if t.transform == nil {
+		return nil
+	}
+	m, c := t.transform.Dims()
+	if component < 0 || component >= c {
+		return nil
+	}
+
+	rows := make([]int, min(m, len(vocabulary)))
+	for i := range rows {
+		rows[i] = i
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return math.Abs(t.transform.At(rows[i], component)) > math.Abs(t.transform.At(rows[j], component))
+	})
+
+	if k > len(rows) {
+		k = len(rows)
+	}
+	if k < 0 {
+		k = 0
+	}
+	terms := make([]string, k)
+	for i := range terms {
+		terms[i] = vocabulary[rows[i]]
+	}
+	return terms
+}
+
+// SimilarTerms returns the k terms most similar to the specified term, measured by the
+// cosine similarity of their term vectors within the latent space learned during Fit(),
+// along with their similarity scores in descending order of similarity (with equal scores
+// ordered alphabetically).  The vector of each term is the corresponding row of the
+// truncated left singular vectors scaled by the singular values, which places terms
+// occuring in similar contexts (even if never within the same document) close together and
+// so may be used to find word associations.  vocabulary maps each term to its row within
+// the matrix supplied to Fit(), as built by CountVectoriser.  The term itself is excluded
+// and if k exceeds the number of other terms then all of them are returned.  An error is
+// returned if the transformer has not been fitted, the term is not in the vocabulary (or
+// maps to a row outside the fitted matrix) or k is negative.
+func (t *TruncatedSVD) SimilarTerms(term string, vocabulary map[string]int, k int) ([]string, []float64, error) {
+	if t.transform == nil {
+		return nil, nil, fmt.Errorf("%w: TruncatedSVD", ErrNotFitted)
+	}
+	if k < 0 {
+		return nil, nil, fmt.Errorf("%w: k (%d) must not be negative", ErrInvalidArgument, k)
+	}
+	m, _ := t.transform.Dims()
+	row, ok := vocabulary[term]
+	if !ok || row < 0 || row >= m {
+		return nil, nil, fmt.Errorf("%w: unknown term '%s'", ErrInvalidArgument, term)
+	}
+
+	vectors := mat64.DenseCopyOf(t.transform)
+	vectors.Apply(func(i, j int, v float64) float64 {
+		return v * t.singularValues[j]
+	}, vectors)
+	target := vectors.RowView(row)
+
+	var similar []TermScore
+	for other, i := range vocabulary {
+		if other == term || i < 0 || i >= m {
+			continue
+		}
+		similar = append(similar, TermScore{Term: other, Score: CosineSimilarity(target, vectors.RowView(i))})
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].Term < similar[j].Term
+	})
+
+	k = min(k, len(similar))
+	terms := make([]string, k)
+	scores := make([]float64, k)
+	for i := range terms {
+		terms[i] = similar[i].Term
+		scores[i] = similar[i].Score
+	}
+	return terms, scores, nil
+}
+
+// SingularValues returns the singular values of the matrix supplied to Fit() or
+// FitTransform() corresponding to each of the retained components (dimensions), in
+// descending order.  The length of the returned slice is min(m, n, K).
+func (t *TruncatedSVD) SingularValues() []float64 {
+	if t.singularValues == nil {
+		return nil
+	}
+	values := make([]float64, len(t.singularValues))
+	copy(values, t.singularValues)
+	return values
+}
+
+// ExplainedVarianceRatio returns the proportion of the variance of the matrix supplied to
+// Fit() or FitTransform() explained by each of the retained components (dimensions), in
+// descending order.  The variance explained by a component is calculated as the square of
+// its singular value divided by the sum of the squares of all the singular values of the
+// matrix.  K may be larger than the rank of the matrix in which case the number of
+// components (and so the length of the returned slice) is truncated to min(m, n, K).  A
+// suitable value for K may be chosen by fitting with a large K and then inspecting where the
+// cumulative sum of the ratios plateaus.
+func (t *TruncatedSVD) ExplainedVarianceRatio() []float64 {
+	if t.variance == nil {
+		return nil
+	}
+	ratios := make([]float64, len(t.variance))
+	copy(ratios, t.variance)
+	return ratios
+}
+
 func minimum(k, m, n int) int {
 	return min(k, min(m, n))
 }
@@ -93,6 +417,13 @@ func min(m, n int) int {
 	return n
 }
 
+func max(m, n int) int {
+	if m > n {
+		return m
+	}
+	return n
+}
+
 func (t *TruncatedSVD) extractSVD(svd *mat64.SVD) (s []float64, u, v *mat64.Dense) {
 	var um, vm mat64.Dense
 	um.UFromSVD(svd)
@@ -100,3 +431,381 @@ func (t *TruncatedSVD) extractSVD(svd *mat64.SVD) (s []float64, u, v *mat64.Dens
 	s = svd.Values(nil)
 	return s, &um, &vm
 }
+
+// exactSVD computes the thin SVD of mat returning the singular values, left and right
+// singular vectors and the total variance (sum of the squares of the singular values).
+func (t *TruncatedSVD) exactSVD(mat mat64.Matrix) (s []float64, u, v *mat64.Dense, total float64, err error) {
+	var svd mat64.SVD
+	if ok := svd.Factorize(mat, matrix.SVDThin); !ok {
+		return nil, nil, nil, 0, fmt.Errorf("Failed SVD Factorisation of working matrix")
+	}
+	s, u, v = t.extractSVD(&svd)
+	for _, sv := range s {
+		total += sv * sv
+	}
+	return s, u, v, total, nil
+}
+
+// randomisedSVD approximates the top K + Oversampling singular values and vectors of mat
+// using the randomised range finder with PowerIterations power iterations.  As only some of
+// the singular values are computed, the total variance is calculated as the squared
+// Frobenius norm of mat (which is equal to the sum of the squares of all its singular
+// values).
+func (t *TruncatedSVD) randomisedSVD(mat mat64.Matrix) (s []float64, u, v *mat64.Dense, total float64, err error) {
+	m, n := mat.Dims()
+	l := minimum(t.K+max(t.Oversampling, 0), m, n)
+
+	// sample the range of mat and find an orthonormal basis, q, for the samples,
+	// re-orthonormalising between power iterations to preserve accuracy
+	omega := gaussianMatrix(n, l, 1, newRand(t.Source, t.Seed))
+	var y, z mat64.Dense
+	y.Mul(mat, omega)
+	q := orthonormalise(&y)
+	for i := 0; i < t.PowerIterations; i++ {
+		z.Mul(mat.T(), q)
+		y.Mul(mat, orthonormalise(&z))
+		q = orthonormalise(&y)
+	}
+
+	// project mat onto the basis and compute the exact SVD of the smaller l x n matrix
+	var b mat64.Dense
+	b.Mul(q.T(), mat)
+	var svd mat64.SVD
+	if ok := svd.Factorize(&b, matrix.SVDThin); !ok {
+		return nil, nil, nil, 0, fmt.Errorf("Failed SVD Factorisation of projected matrix")
+	}
+	s, ub, v := t.extractSVD(&svd)
+
+	var um mat64.Dense
+	um.Mul(q, ub)
+
+	total = mat64.Norm(mat, 2)
+	total *= total
+
+	return s, &um, v, total, nil
+}
+
+// orthonormalise replaces the columns of mat with an orthonormal basis spanning the same
+// space using modified Gram-Schmidt (with a second, re-orthogonalisation pass for numerical
+// stability) returning mat.  Columns that are linearly dependent on the preceding columns
+// are set to zero.
+func orthonormalise(mat *mat64.Dense) *mat64.Dense {
+	m, c := mat.Dims()
+	col := make([]float64, m)
+	prev := make([]float64, m)
+	for j := 0; j < c; j++ {
+		mat64.Col(col, j, mat)
+		scale := floats.Norm(col, 2)
+		for pass := 0; pass < 2; pass++ {
+			for k := 0; k < j; k++ {
+				mat64.Col(prev, k, mat)
+				floats.AddScaled(col, -floats.Dot(prev, col), prev)
+			}
+		}
+		norm := floats.Norm(col, 2)
+		if norm <= scale*1e-10 {
+			norm = 0
+		} else {
+			norm = 1 / norm
+		}
+		floats.Scale(norm, col)
+		mat.SetCol(j, col)
+	}
+	return mat
+}
+
+// RandomProjection is a fast, approximate alternative to TruncatedSVD for reducing the
+// dimensionality of matrices.  The input matrix is projected into a lower dimensional space
+// by multiplying it by a random matrix whose elements are drawn from a Gaussian
+// distribution.  According to the Johnson-Lindenstrauss lemma, the distances between the
+// points (columns) are approximately preserved by such a projection provided the target
+// dimensionality, K, is sufficiently large (proportional to the log of the number of points).
+// Random projection is significantly cheaper to compute than SVD and is well suited to
+// approximate nearest neighbour search and similar applications.
+type RandomProjection struct {
+	projection *mat64.Dense
+
+	// K is the number of dimensions (rows) of the matrices output by Transform() and
+	// FitTransform().
+	K int
+
+	// Seed is used to seed the random number generator used to generate the projection
+	// matrix so that results are reproducible.
+	Seed int64
+
+	// Source, if set, is used as the source of random numbers in place of a source seeded
+	// with Seed e.g. to share a single source across multiple randomised transformers.  As
+	// the state of the source advances with use, refitting with the same Source will
+	// generate a different projection.
+	Source rand.Source
+}
+
+// NewRandomProjection creates a new RandomProjection transformer projecting into k
+// dimensions, using the specified seed for the random number generator.
+func NewRandomProjection(k int, seed int64) *RandomProjection {
+	return &RandomProjection{K: k, Seed: seed}
+}
+
+// Fit generates a random K x m projection matrix, where m is the number of rows in the
+// supplied matrix, for use in subsequent calls to Transform().  The values of the matrix are
+// not used, only its dimensions.  The elements of the projection matrix are drawn from a
+// Gaussian distribution with a mean of 0 and variance of 1/K.
+func (p *RandomProjection) Fit(mat mat64.Matrix) Transformer {
+	m, _ := mat.Dims()
+	p.projection = gaussianMatrix(p.K, m, 1/math.Sqrt(float64(p.K)), newRand(p.Source, p.Seed))
+
+	return p
+}
+
+// Transform projects the supplied matrix into K dimensions using the projection matrix
+// generated during Fit().  The output matrix will be K rows by n columns where n is the
+// number of columns in the input matrix.
+func (p *RandomProjection) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if p.projection == nil {
+		return nil, fmt.Errorf("%w: RandomProjection", ErrNotFitted)
+	}
+	m, _ := mat.Dims()
+	if _, c := p.projection.Dims(); m != c {
+		return nil, fmt.Errorf("%w: matrix has %d rows but projection was fitted on %d", ErrDimensionMismatch, m, c)
+	}
+
+	var product mat64.Dense
+	product.Mul(p.projection, mat)
+
+	return &product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (p *RandomProjection) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return p.Fit(mat).Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted projection matrix.  The
+// copy shares any configured Source with the original.
+func (p *RandomProjection) Clone() Transformer {
+	c := *p
+	c.projection = copyDense(p.projection)
+	return &c
+}
+
+// gaussianMatrix creates a r x c matrix whose elements are drawn from a Gaussian
+// distribution with a mean of 0 and a standard deviation of scale using the random number
+// generator rnd.
+func gaussianMatrix(r, c int, scale float64, rnd *rand.Rand) *mat64.Dense {
+	data := make([]float64, r*c)
+	for i := range data {
+		data[i] = rnd.NormFloat64() * scale
+	}
+	return mat64.NewDense(r, c, data)
+}
+
+// PCA implements Principal Component Analysis, reducing the dimensionality of dense feature
+// matrices (e.g. document embeddings) by projecting them onto the K orthogonal directions
+// (principal components) of greatest variance.  Unlike TruncatedSVD, the features are mean
+// centred before factorisation i.e. the mean document vector (the mean of each row across
+// the columns) is subtracted from each document (column).  Centring is appropriate for
+// dense features but destroys the sparsity of term document matrices for which
+// TruncatedSVD should be preferred.
+type PCA struct {
+	mean       []float64
+	components *mat64.Dense
+	variance   []float64
+
+	// K is the number of principal components and so the number of rows in the matrices
+	// output by Transform() and FitTransform().  K is truncated to min(m, n) where m and n
+	// are the numbers of rows and columns of the training data matrix.
+	K int
+}
+
+// NewPCA creates a new PCA transformer projecting onto the top k principal components
+func NewPCA(k int) *PCA {
+	return &PCA{K: k}
+}
+
+// Fit calculates the mean document vector and principal components of the supplied
+// training data matrix for use in subsequent calls to Transform().
+func (p *PCA) Fit(mat mat64.Matrix) Transformer {
+	p.FitTransform(mat)
+	return p
+}
+
+// Transform projects the documents (columns) of the supplied matrix onto the principal
+// components learned during Fit(), after subtracting the mean document vector of the
+// training data (rather than of the supplied matrix).  The output matrix has K rows (or
+// fewer if truncated during Fit()) and the same number of columns as the supplied matrix.
+// An error is returned if the transformer has not been fitted or the number of rows in the
+// matrix differs from the training data matrix.
+func (p *PCA) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if p.components == nil {
+		return nil, fmt.Errorf("%w: PCA", ErrNotFitted)
+	}
+	m, _ := mat.Dims()
+	if m != len(p.mean) {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d", ErrDimensionMismatch, m, len(p.mean))
+	}
+
+	var product mat64.Dense
+	product.Mul(p.components.T(), p.centre(mat))
+	return &product, nil
+}
+
+// FitTransform is equivalent to calling Fit() followed by Transform() on the same matrix.
+// This is a useful shortcut where separate trianing data is not being used to fit the
+// model i.e. the model is fitted on the fly to the test data.
+func (p *PCA) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	p.components = nil
+	p.mean = RowSums(mat)
+	for i := range p.mean {
+		p.mean[i] /= float64(n)
+	}
+
+	var svd mat64.SVD
+	if ok := svd.Factorize(p.centre(mat), matrix.SVDThin); !ok {
+		return nil, fmt.Errorf("Failed SVD Factorisation of working matrix")
+	}
+	var u mat64.Dense
+	u.UFromSVD(&svd)
+	s := svd.Values(nil)
+
+	k := minimum(p.K, m, n)
+	var total float64
+	for _, sv := range s {
+		total += sv * sv
+	}
+	p.variance = make([]float64, k)
+	for i := range p.variance {
+		if total != 0 {
+			p.variance[i] = (s[i] * s[i]) / total
+		}
+	}
+
+	components := mat64.DenseCopyOf(u.Slice(0, m, 0, k))
+	p.components = components
+
+	return p.Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted mean and components.
+func (p *PCA) Clone() Transformer {
+	c := *p
+	c.mean = copyFloats(p.mean)
+	c.components = copyDense(p.components)
+	c.variance = copyFloats(p.variance)
+	return &c
+}
+
+// centre returns a copy of the supplied matrix with the fitted mean subtracted from each
+// column.
+func (p *PCA) centre(mat mat64.Matrix) *mat64.Dense {
+	centred := mat64.DenseCopyOf(mat)
+	centred.Apply(func(i, j int, v float64) float64 {
+		return v - p.mean[i]
+	}, centred)
+	return centred
+}
+
+// Components returns a copy of the principal components learned during Fit() as the
+// columns of an m x K matrix, where m is the number of rows in the training data matrix, in
+// descending order of the variance they explain.  Components returns nil if the transformer
+// has not been fitted.
+func (p *PCA) Components() *mat64.Dense {
+	if p.components == nil {
+		return nil
+	}
+	return mat64.DenseCopyOf(p.components)
+}
+
+// Mean returns a copy of the mean document vector (the mean of each row) of the training
+// data matrix subtracted from documents before projection.  Mean returns nil if the
+// transformer has not been fitted.
+func (p *PCA) Mean() []float64 {
+	if p.components == nil {
+		return nil
+	}
+	mean := make([]float64, len(p.mean))
+	copy(mean, p.mean)
+	return mean
+}
+
+// ExplainedVarianceRatio returns the proportion of the total variance of the (centred)
+// training data explained by each of the principal components, in descending order.
+// ExplainedVarianceRatio returns nil if the transformer has not been fitted.
+func (p *PCA) ExplainedVarianceRatio() []float64 {
+	if p.components == nil {
+		return nil
+	}
+	ratios := make([]float64, len(p.variance))
+	copy(ratios, p.variance)
+	return ratios
+}
+
+// SignRandomProjection implements locality sensitive hashing (LSH) of document feature
+// vectors using sign random projections (also known as SimHash).  Each vector (column) is
+// projected onto K random hyperplanes and the sign of each projection recorded as a single
+// bit, producing a compact binary fingerprint of K bits per document.  The probability of
+// the corresponding bits of 2 fingerprints differing is proportional to the angle between
+// the original vectors and so the Hamming distance between fingerprints (see
+// HammingDistance()) approximates their cosine distance.  This makes fingerprints useful
+// for fast, approximate deduplication and nearest neighbour search.
+type SignRandomProjection struct {
+	hyperplanes *mat64.Dense
+
+	// K is the number of random hyperplanes and so the number of bits in each fingerprint.
+	// Higher values give more accurate approximations at the cost of larger fingerprints.
+	K int
+
+	// Seed is used to seed the random number generator used to generate the hyperplanes so
+	// that fingerprints are reproducible.
+	Seed int64
+
+	// Source, if set, is used as the source of random numbers in place of a source seeded
+	// with Seed in the same way as RandomProjection.
+	Source rand.Source
+}
+
+// NewSignRandomProjection creates a new SignRandomProjection generating fingerprints of k
+// bits, using the specified seed for the random number generator.
+func NewSignRandomProjection(k int, seed int64) *SignRandomProjection {
+	return &SignRandomProjection{K: k, Seed: seed}
+}
+
+// Fit generates K random hyperplanes in the m dimensional space of the supplied matrix,
+// where m is the number of rows, for use in subsequent calls to Fingerprints().  The values
+// of the matrix are not used, only its dimensions.
+func (p *SignRandomProjection) Fit(mat mat64.Matrix) *SignRandomProjection {
+	m, _ := mat.Dims()
+	p.hyperplanes = gaussianMatrix(p.K, m, 1, newRand(p.Source, p.Seed))
+	return p
+}
+
+// Fingerprints returns a fingerprint for each document (column) of the supplied matrix using
+// the hyperplanes generated during Fit().  Each fingerprint is K bits packed into
+// ceil(K/64) uint64s where bit k (bit k%64 of element k/64) is set if the vector lies on the
+// positive side of hyperplane k.
+func (p *SignRandomProjection) Fingerprints(mat mat64.Matrix) ([][]uint64, error) {
+	if p.hyperplanes == nil {
+		return nil, fmt.Errorf("%w: SignRandomProjection", ErrNotFitted)
+	}
+	m, n := mat.Dims()
+	if _, c := p.hyperplanes.Dims(); m != c {
+		return nil, fmt.Errorf("%w: matrix has %d rows but projection was fitted on %d", ErrDimensionMismatch, m, c)
+	}
+
+	var product mat64.Dense
+	product.Mul(p.hyperplanes, mat)
+
+	words := (p.K + 63) / 64
+	fingerprints := make([][]uint64, n)
+	for j := range fingerprints {
+		fingerprints[j] = make([]uint64, words)
+		for k := 0; k < p.K; k++ {
+			if product.At(k, j) > 0 {
+				fingerprints[j][k/64] |= 1 << uint(k%64)
+			}
+		}
+	}
+	return fingerprints, nil
+}
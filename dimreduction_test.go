@@ -1,6 +1,9 @@
 package nlp
 
 import (
+	"errors"
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/gonum/matrix/mat64"
@@ -67,3 +70,588 @@ func TestTruncatedSVDFitTransform(t *testing.T) {
 		}
 	}
 }
+
+func TestTruncatedSVDReconstructionError(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	prevErr := math.Inf(1)
+
+	// K of 10 exceeds the rank of the matrix and so should be truncated to min(m, n)
+	for _, k := range []int{1, 2, 3, 4, 10} {
+		transformer := NewTruncatedSVD(k)
+		reduced, err := transformer.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed Truncated SVD transform caused by %v", err)
+		}
+
+		_, c := transformer.transform.Dims()
+		if c != minimum(k, 6, 4) {
+			t.Errorf("Expected %d components for K = %d but found %d", minimum(k, 6, 4), k, c)
+		}
+
+		ratios := transformer.ExplainedVarianceRatio()
+		if len(ratios) != c {
+			t.Errorf("Expected %d explained variance ratios but found %d", c, len(ratios))
+		}
+
+		var reconstruction, diff mat64.Dense
+		reconstruction.Mul(transformer.transform, reduced)
+		diff.Sub(input, &reconstruction)
+		reconErr := transformer.ReconstructionError(input)
+		if math.Abs(reconErr-mat64.Norm(&diff, 2)) > 0.0001 {
+			t.Errorf("Expected reconstruction error %f for K = %d but found %f", mat64.Norm(&diff, 2), k, reconErr)
+		}
+
+		if reconErr > prevErr {
+			t.Errorf("Expected reconstruction error to decrease as K increases but %f > %f for K = %d",
+				reconErr, prevErr, k)
+		}
+		prevErr = reconErr
+
+		// the unexplained variance should account for the reconstruction error
+		var explained float64
+		for _, r := range ratios {
+			explained += r
+		}
+		total := mat64.Norm(input, 2)
+		if math.Abs((1-explained)*total*total-reconErr*reconErr) > 0.0001 {
+			t.Errorf("Expected unexplained variance %f to equal squared reconstruction error %f for K = %d",
+				(1-explained)*total*total, reconErr*reconErr, k)
+		}
+	}
+
+	if prevErr > 0.0001 {
+		t.Errorf("Expected full rank reconstruction to be exact but error was %f", prevErr)
+	}
+
+	if err := NewTruncatedSVD(2).ReconstructionError(input); !math.IsNaN(err) {
+		t.Errorf("Expected NaN reconstruction error for unfitted transformer but found %f", err)
+	}
+	transformer := NewTruncatedSVD(2)
+	transformer.Fit(input)
+	if err := transformer.ReconstructionError(mat64.NewDense(3, 4, nil)); !math.IsNaN(err) {
+		t.Errorf("Expected NaN reconstruction error for mismatched rows but found %f", err)
+	}
+}
+
+func TestTruncatedSVDTopTerms(t *testing.T) {
+	// each document is dominated by a single distinct term so each component should be
+	// dominated by the corresponding term
+	input := mat64.NewDense(4, 3, []float64{
+		10, 0, 1,
+		0, 6, 1,
+		1, 1, 3,
+		0, 0, 0,
+	})
+	vocabulary := []string{"cat", "dog", "fish", "bird"}
+
+	transformer := NewTruncatedSVD(3)
+	if terms := transformer.TopTerms(0, 2, vocabulary); terms != nil {
+		t.Errorf("Expected no terms before fitting but found %v", terms)
+	}
+	transformer.Fit(input)
+
+	var tests = []struct {
+		component int
+		k         int
+		terms     []string
+	}{
+		{0, 1, []string{"cat"}},
+		{1, 1, []string{"dog"}},
+		{2, 1, []string{"fish"}},
+		{0, 0, []string{}},
+		// k exceeding the vocabulary size is clamped
+		{0, 10, []string{"cat", "fish", "dog", "bird"}},
+	}
+
+	for _, test := range tests {
+		terms := transformer.TopTerms(test.component, test.k, vocabulary)
+		if len(terms) != len(test.terms) {
+			t.Errorf("Expected top terms %v for component %d but found %v", test.terms, test.component, terms)
+			continue
+		}
+		for i := range terms {
+			if terms[i] != test.terms[i] {
+				t.Errorf("Expected top terms %v for component %d but found %v", test.terms, test.component, terms)
+				break
+			}
+		}
+	}
+
+	if terms := transformer.TopTerms(3, 2, vocabulary); terms != nil {
+		t.Errorf("Expected no terms for invalid component but found %v", terms)
+	}
+}
+
+func TestTruncatedSVDSimilarTerms(t *testing.T) {
+	// "coffee" and "espresso" always occur together whereas the other terms each occur
+	// within several different contexts so the pair should be mutually most similar
+	corpus := []string{
+		"coffee espresso morning",
+		"coffee espresso cafe",
+		"espresso coffee beans",
+		"morning football match",
+		"cafe match referee",
+		"beans football referee",
+		"football match goal",
+		"morning cafe beans",
+	}
+	vectoriser := NewCountVectoriser(false)
+	mat, err := vectoriser.FitTransform(corpus...)
+	if err != nil {
+		t.Fatalf("Failed to vectorise corpus: %v", err)
+	}
+
+	transformer := NewTruncatedSVD(2)
+	if _, _, err := transformer.SimilarTerms("coffee", vectoriser.Vocabulary, 1); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected ErrNotFitted before fitting but found %v", err)
+	}
+	transformer.Fit(mat)
+
+	var tests = []struct {
+		term    string
+		similar string
+	}{
+		{"coffee", "espresso"},
+		{"espresso", "coffee"},
+	}
+
+	for _, test := range tests {
+		terms, scores, err := transformer.SimilarTerms(test.term, vectoriser.Vocabulary, 3)
+		if err != nil {
+			t.Errorf("Failed to find terms similar to '%s': %v", test.term, err)
+			continue
+		}
+		if len(terms) != 3 || len(scores) != 3 {
+			t.Errorf("Expected 3 terms similar to '%s' but found %v (%v)", test.term, terms, scores)
+			continue
+		}
+		if terms[0] != test.similar {
+			t.Errorf("Expected '%s' to be most similar to '%s' but found %v (%v)", test.similar, test.term, terms, scores)
+		}
+		for i := range terms {
+			if terms[i] == test.term {
+				t.Errorf("Expected '%s' to be excluded from its similar terms but found %v", test.term, terms)
+			}
+			if i > 0 && scores[i] > scores[i-1] {
+				t.Errorf("Expected scores in descending order but found %v", scores)
+			}
+		}
+	}
+
+	terms, _, err := transformer.SimilarTerms("coffee", vectoriser.Vocabulary, 100)
+	if err != nil || len(terms) != len(vectoriser.Vocabulary)-1 {
+		t.Errorf("Expected all %d other terms when k exceeds vocabulary but found %v (%v)", len(vectoriser.Vocabulary)-1, terms, err)
+	}
+
+	if _, _, err := transformer.SimilarTerms("tea", vectoriser.Vocabulary, 1); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for unknown term but found %v", err)
+	}
+	if _, _, err := transformer.SimilarTerms("coffee", vectoriser.Vocabulary, -1); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument for negative k but found %v", err)
+	}
+}
+
+func TestTruncatedSVDExplainedVariance(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	transformer := NewTruncatedSVD(3)
+	if transformer.SingularValues() != nil || transformer.ExplainedVarianceRatio() != nil {
+		t.Errorf("Expected no singular values or explained variance before fitting")
+	}
+	transformer.Fit(input)
+
+	values := transformer.SingularValues()
+	ratios := transformer.ExplainedVarianceRatio()
+	if len(values) != 3 || len(ratios) != 3 {
+		t.Fatalf("Expected 3 singular values and ratios but found %v and %v", values, ratios)
+	}
+
+	var sum float64
+	for i := range ratios {
+		sum += ratios[i]
+		if i > 0 && (values[i] > values[i-1] || ratios[i] > ratios[i-1]) {
+			t.Errorf("Expected singular values %v and ratios %v to be in descending order", values, ratios)
+		}
+	}
+	if sum > 1 {
+		t.Errorf("Expected explained variance ratios %v to sum to at most 1 but found %f", ratios, sum)
+	}
+
+	// singular values relate to explained variance as s_i^2 / ||A||^2
+	total := mat64.Norm(input, 2)
+	for i := range values {
+		if math.Abs(values[i]*values[i]/(total*total)-ratios[i]) > 0.000001 {
+			t.Errorf("Expected ratio %f for singular value %f but found %f",
+				values[i]*values[i]/(total*total), values[i], ratios[i])
+		}
+	}
+
+	// returned slices are copies
+	values[0] = 0
+	if transformer.SingularValues()[0] == 0 {
+		t.Errorf("Expected SingularValues() to return a copy")
+	}
+}
+
+func TestTruncatedSVDInverseTransform(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	if _, err := NewTruncatedSVD(2).InverseTransform(mat64.NewDense(2, 4, nil)); err == nil {
+		t.Errorf("Expected error inverse transforming with unfitted transformer but found none")
+	}
+
+	// full rank - the input should be recovered
+	transformer := NewTruncatedSVD(4)
+	reduced, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Fatalf("Failed Truncated SVD transform caused by %v", err)
+	}
+	result, err := transformer.InverseTransform(reduced)
+	if err != nil {
+		t.Fatalf("Failed Truncated SVD inverse transform caused by %v", err)
+	}
+	if !mat64.EqualApprox(input, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(input),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	// truncated - the inverse is lossy and yields an approximation of the input
+	transformer = NewTruncatedSVD(2)
+	reduced, _ = transformer.FitTransform(input)
+	result, _ = transformer.InverseTransform(reduced)
+	if r, c := result.Dims(); r != 6 || c != 4 {
+		t.Fatalf("Expected 6 x 4 matrix but found %d x %d", r, c)
+	}
+	if mat64.EqualApprox(input, result, 0.000001) {
+		t.Errorf("Expected truncated inverse transform to be lossy but input was recovered exactly")
+	}
+
+	if _, err := transformer.InverseTransform(input); err == nil {
+		t.Errorf("Expected error inverse transforming matrix with wrong number of rows but found none")
+	}
+}
+
+func TestTruncatedSVDMarshalBinary(t *testing.T) {
+	mat := mat64.NewDense(4, 5, []float64{
+		1, 0, 2, 0, 1,
+		0, 3, 1, 0, 0,
+		2, 1, 0, 1, 4,
+		0, 0, 1, 2, 1,
+	})
+
+	transformer := NewTruncatedSVD(2)
+	transformer.Fit(mat)
+	expected, err := transformer.Transform(mat)
+	if err != nil {
+		t.Fatalf("Failed SVD transform caused by %v", err)
+	}
+
+	data, err := transformer.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal transformer caused by %v", err)
+	}
+	var restored TruncatedSVD
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal transformer caused by %v", err)
+	}
+	if restored.K != 2 || len(restored.SingularValues()) != 2 {
+		t.Errorf("Expected K of 2 with 2 singular values but found %d and %v", restored.K, restored.SingularValues())
+	}
+
+	result, err := restored.Transform(mat)
+	if err != nil {
+		t.Fatalf("Failed SVD transform caused by %v", err)
+	}
+	if !mat64.Equal(expected, result) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	data[0] = 0
+	if err := restored.UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected error unmarshalling unsupported version but found none")
+	}
+}
+
+func TestRandomProjectionPreservesDistances(t *testing.T) {
+	m, n, k := 1000, 20, 400
+	eps := 0.4
+
+	rnd := rand.New(rand.NewSource(7))
+	data := make([]float64, m*n)
+	for i := range data {
+		data[i] = rnd.Float64()
+	}
+	input := mat64.NewDense(m, n, data)
+
+	transformer := NewRandomProjection(k, 1)
+	result, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Fatalf("Failed random projection caused by %v", err)
+	}
+
+	r, c := result.Dims()
+	if r != k || c != n {
+		t.Fatalf("Expected matrix %d x %d but found %d x %d", k, n, r, c)
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			var orig, proj mat64.Vector
+			orig.SubVec(input.ColView(i), input.ColView(j))
+			proj.SubVec(result.ColView(i), result.ColView(j))
+
+			// Johnson-Lindenstrauss bound on squared distances
+			ratio := math.Pow(mat64.Norm(&proj, 2), 2) / math.Pow(mat64.Norm(&orig, 2), 2)
+			if ratio < 1-eps || ratio > 1+eps {
+				t.Errorf("Expected squared distance between %d and %d to be preserved within %f but ratio was %f",
+					i, j, eps, ratio)
+			}
+		}
+	}
+
+	// refitting with the same seed should generate the same projection
+	result2, _ := NewRandomProjection(k, 1).FitTransform(input)
+	if !mat64.Equal(result, result2) {
+		t.Errorf("Expected identical projections for the same seed")
+	}
+
+	if _, err := transformer.Transform(mat64.NewDense(m+1, 1, nil)); err == nil {
+		t.Errorf("Expected error transforming matrix with mismatched rows but found none")
+	}
+}
+
+func TestPCAFirstComponent(t *testing.T) {
+	// synthetic 2D data with a large variance along (1, 1) and small variance along (1, -1)
+	// offset from the origin by (10, -5)
+	rnd := rand.New(rand.NewSource(1))
+	n := 500
+	data := mat64.NewDense(2, n, nil)
+	for j := 0; j < n; j++ {
+		major, minor := rnd.NormFloat64()*5, rnd.NormFloat64()*0.5
+		data.Set(0, j, 10+(major+minor)/math.Sqrt2)
+		data.Set(1, j, -5+(major-minor)/math.Sqrt2)
+	}
+
+	pca := NewPCA(2)
+	if _, err := pca.Transform(data); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+
+	reduced, err := pca.FitTransform(data)
+	if err != nil {
+		t.Fatalf("Failed to fit PCA caused by %v", err)
+	}
+	if r, c := reduced.Dims(); r != 2 || c != n {
+		t.Errorf("Expected reduced matrix of 2 x %d but found %d x %d", n, r, c)
+	}
+
+	first := pca.Components().ColView(0)
+	if dot := math.Abs(mat64.Dot(first, mat64.NewVector(2, []float64{1 / math.Sqrt2, 1 / math.Sqrt2}))); math.Abs(dot-1) > 0.01 {
+		t.Errorf("Expected first component parallel to (1, 1) but found %v", first.RawVector().Data)
+	}
+	if mean := pca.Mean(); math.Abs(mean[0]-10) > 0.5 || math.Abs(mean[1]+5) > 0.5 {
+		t.Errorf("Expected mean close to [10 -5] but found %v", mean)
+	}
+	if ratios := pca.ExplainedVarianceRatio(); ratios[0] < 0.95 || ratios[0] < ratios[1] {
+		t.Errorf("Expected first component to explain most of the variance but found %v", ratios)
+	}
+
+	// new data must be centred using the fitted mean rather than its own mean
+	mean := pca.Mean()
+	point := mat64.NewDense(2, 2, []float64{mean[0], mean[0] + 1, mean[1], mean[1] + 1})
+	projected, err := pca.Transform(point)
+	if err != nil {
+		t.Fatalf("Failed to transform new data caused by %v", err)
+	}
+	if math.Abs(projected.At(0, 0)) > 0.000001 || math.Abs(projected.At(1, 0)) > 0.000001 {
+		t.Errorf("Expected fitted mean to project to the origin but found %v", mat64.Formatted(projected))
+	}
+	if math.Abs(math.Abs(projected.At(0, 1))-math.Sqrt2) > 0.05 {
+		t.Errorf("Expected (1, 1) offset from the mean to project to ±sqrt(2) but found %f", projected.At(0, 1))
+	}
+
+	if _, err := pca.Transform(mat64.NewDense(3, 1, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+}
+
+func TestSignRandomProjectionFingerprints(t *testing.T) {
+	m, k := 100, 256
+
+	rnd := rand.New(rand.NewSource(7))
+	data := make([]float64, m*3)
+	for i := 0; i < m; i++ {
+		v := rnd.NormFloat64()
+		// similar to column 0 with a small amount of noise
+		data[i*3] = v
+		data[i*3+1] = v + rnd.NormFloat64()*0.1
+		// unrelated to column 0
+		data[i*3+2] = rnd.NormFloat64()
+	}
+	input := mat64.NewDense(m, 3, data)
+
+	lsh := NewSignRandomProjection(k, 1)
+	if _, err := lsh.Fingerprints(input); err == nil {
+		t.Errorf("Expected error fingerprinting with unfitted projection but found none")
+	}
+
+	fingerprints, err := lsh.Fit(input).Fingerprints(input)
+	if err != nil {
+		t.Fatalf("Failed to fingerprint matrix caused by %v", err)
+	}
+	if len(fingerprints) != 3 || len(fingerprints[0]) != k/64 {
+		t.Fatalf("Expected 3 fingerprints of %d words but found %v", k/64, fingerprints)
+	}
+
+	if d := HammingDistance(fingerprints[0], fingerprints[0]); d != 0 {
+		t.Errorf("Expected Hamming distance of 0 for identical vectors but found %d", d)
+	}
+	similar := HammingDistance(fingerprints[0], fingerprints[1])
+	dissimilar := HammingDistance(fingerprints[0], fingerprints[2])
+	if similar > k/8 || dissimilar < k/4 {
+		t.Errorf("Expected small Hamming distance for similar vectors and large for dissimilar but found %d and %d",
+			similar, dissimilar)
+	}
+
+	// refitting with the same seed should generate the same fingerprints
+	fingerprints2, _ := NewSignRandomProjection(k, 1).Fit(input).Fingerprints(input)
+	for j := range fingerprints {
+		if HammingDistance(fingerprints[j], fingerprints2[j]) != 0 {
+			t.Errorf("Expected identical fingerprints for the same seed")
+		}
+	}
+
+	if _, err := lsh.Fingerprints(mat64.NewDense(m+1, 1, nil)); err == nil {
+		t.Errorf("Expected error fingerprinting matrix with mismatched rows but found none")
+	}
+}
+
+func TestRandomisedTransformersSeed(t *testing.T) {
+	input := mat64.NewDense(50, 5, nil)
+
+	rp := NewRandomProjection(10, 3)
+	rp.Fit(input)
+	first := mat64.DenseCopyOf(rp.projection)
+	rp.Fit(input)
+	if !mat64.Equal(first, rp.projection) {
+		t.Errorf("Expected identical projections when refitting with the same seed")
+	}
+
+	lsh := NewSignRandomProjection(10, 3)
+	lsh.Fit(input)
+	hyperplanes := mat64.DenseCopyOf(lsh.hyperplanes)
+	lsh.Fit(input)
+	if !mat64.Equal(hyperplanes, lsh.hyperplanes) {
+		t.Errorf("Expected identical hyperplanes when refitting with the same seed")
+	}
+
+	// a supplied source takes precedence over the seed
+	rp.Source = rand.NewSource(3)
+	rp.Fit(input)
+	if !mat64.Equal(first, rp.projection) {
+		t.Errorf("Expected identical projections for a source with the same seed")
+	}
+	// the source advances with use so refitting generates a new projection
+	rp.Fit(input)
+	if mat64.Equal(first, rp.projection) {
+		t.Errorf("Expected different projection when refitting with the same source")
+	}
+}
+
+func TestTruncatedSVDRandomised(t *testing.T) {
+	m, n, rank, k := 300, 200, 10, 5
+
+	// low rank matrix with decaying singular values plus a little noise
+	rnd := rand.New(rand.NewSource(7))
+	u := gaussianMatrix(m, rank, 1, rnd)
+	v := gaussianMatrix(rank, n, 1, rnd)
+	for i := 0; i < rank; i++ {
+		row := v.RawRowView(i)
+		for j := range row {
+			row[j] *= math.Pow(0.6, float64(i))
+		}
+	}
+	var input mat64.Dense
+	input.Mul(u, v)
+	input.Add(&input, gaussianMatrix(m, n, 0.01, rnd))
+
+	exact := NewTruncatedSVD(k)
+	exactResult, err := exact.FitTransform(&input)
+	if err != nil {
+		t.Fatalf("Failed exact SVD caused by %v", err)
+	}
+
+	randomised := NewTruncatedSVD(k)
+	randomised.Method = RandomisedSVD
+	randomised.Seed = 1
+	result, err := randomised.FitTransform(&input)
+	if err != nil {
+		t.Fatalf("Failed randomised SVD caused by %v", err)
+	}
+
+	r, c := result.Dims()
+	er, ec := exactResult.Dims()
+	if r != er || c != ec {
+		t.Fatalf("Expected matrix %d x %d but found %d x %d", er, ec, r, c)
+	}
+
+	exactValues, values := exact.SingularValues(), randomised.SingularValues()
+	for i := range exactValues {
+		if math.Abs(values[i]-exactValues[i])/exactValues[i] > 1e-3 {
+			t.Errorf("Expected singular value %d of %f but found %f", i, exactValues[i], values[i])
+		}
+	}
+
+	exactRatios, ratios := exact.ExplainedVarianceRatio(), randomised.ExplainedVarianceRatio()
+	for i := range exactRatios {
+		if math.Abs(ratios[i]-exactRatios[i]) > 1e-3 {
+			t.Errorf("Expected explained variance ratio %d of %f but found %f", i, exactRatios[i], ratios[i])
+		}
+	}
+
+	// the subspaces spanned by the components should match (the components themselves may
+	// differ in sign) so compare the projectors onto each subspace
+	var pe, pr, diff mat64.Dense
+	pe.Mul(exact.transform, exact.transform.T())
+	pr.Mul(randomised.transform, randomised.transform.T())
+	diff.Sub(&pe, &pr)
+	if e := mat64.Norm(&diff, 2) / math.Sqrt(float64(2*k)); e > 0.01 {
+		t.Errorf("Expected subspace error within 0.01 but found %f", e)
+	}
+
+	// the same seed should give the same factorisation
+	randomised2 := NewTruncatedSVD(k)
+	randomised2.Method = RandomisedSVD
+	randomised2.Seed = 1
+	result2, _ := randomised2.FitTransform(&input)
+	if !mat64.Equal(result, result2) {
+		t.Errorf("Expected identical factorisations for the same seed")
+	}
+}
@@ -0,0 +1,206 @@
+package nlp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// WordVectors is a set of pre-trained word embeddings (dense vectors representing the
+// meaning of words) e.g. GloVe or word2vec embeddings, mapping each word to a vector of the
+// same dimensionality.
+type WordVectors struct {
+	dim     int
+	vectors map[string][]float64
+}
+
+// LoadWordVectors loads word embeddings from the standard text format used by GloVe and
+// word2vec, where each line contains a word followed by the values of its vector, all
+// separated by whitespace e.g.
+//
+//	fox 0.12 -0.5 0.33
+//
+// An optional header line containing the number of words and the dimensionality (as
+// written by word2vec) is skipped.  Blank lines are ignored and, if a word occurs more than
+// once, the last vector is used.  An error is returned if reading fails, a value can not be
+// parsed or the vectors do not all have the same dimensionality.
+func LoadWordVectors(r io.Reader) (*WordVectors, error) {
+	w := &WordVectors{vectors: make(map[string][]float64)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if line == 1 && isWordVectorsHeader(fields) {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("Failed to parse word vectors: line %d contains no values", line)
+		}
+
+		vec := make([]float64, len(fields)-1)
+		for i, field := range fields[1:] {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse word vectors on line %d caused by %w", line, err)
+			}
+			vec[i] = v
+		}
+		if w.dim == 0 {
+			w.dim = len(vec)
+		}
+		if len(vec) != w.dim {
+			return nil, fmt.Errorf("%w: vector for '%s' on line %d has %d dimensions but expected %d", ErrDimensionMismatch, fields[0], line, len(vec), w.dim)
+		}
+		w.vectors[fields[0]] = vec
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read word vectors caused by %w", err)
+	}
+
+	return w, nil
+}
+
+// isWordVectorsHeader returns true if the fields of a line are a word2vec header of the
+// number of words and dimensionality.
+func isWordVectorsHeader(fields []string) bool {
+	if len(fields) != 2 {
+		return false
+	}
+	for _, field := range fields {
+		if _, err := strconv.Atoi(field); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Dim returns the dimensionality of the word vectors
+func (w *WordVectors) Dim() int {
+	return w.dim
+}
+
+// Len returns the number of words with vectors
+func (w *WordVectors) Len() int {
+	return len(w.vectors)
+}
+
+// Vector returns the vector for the specified word and true or, if there is no vector for
+// the word, nil and false.  The returned vector shares its data with the WordVectors and
+// must not be modified.
+func (w *WordVectors) Vector(word string) (*mat64.Vector, bool) {
+	vec, ok := w.vectors[word]
+	if !ok {
+		return nil, false
+	}
+	return mat64.NewVector(w.dim, vec), true
+}
+
+// EmbeddingVectoriser encodes text documents into dense document embeddings by averaging
+// the word vectors of the words within each document.  Unlike term document matrices, each
+// row of the output matrices represents a dimension of the embedding space (rather than a
+// term) so that documents using different words with similar meanings have similar
+// vectors.  Words without vectors are ignored and documents containing no words with
+// vectors produce columns of zeros.  As the word vectors are pre-trained,
+// EmbeddingVectoriser requires no fitting.
+type EmbeddingVectoriser struct {
+	// Vectors are the word embeddings averaged into document vectors
+	Vectors *WordVectors
+
+	// Preprocessor is applied to each document before it is tokenised.  By default (or if
+	// nil), documents are converted to lower case which suits embeddings trained on lower
+	// cased text such as the standard GloVe embeddings.
+	Preprocessor func(string) string
+
+	// Tokeniser is used to split documents into words following preprocessing.
+	Tokeniser Tokeniser
+
+	// IDF and Vocabulary, if both set, weight the contribution of each word to the average
+	// by its inverse document frequency so that common words (which tend to carry little
+	// meaning) do not dominate the document vectors.  IDF should be a fitted
+	// TfidfTransformer and Vocabulary maps each word to the row of its weight within IDF
+	// e.g. the Vocabulary of the CountVectoriser (extracting unigrams without stemming) used
+	// to produce the matrix IDF was fitted on.  Words not present in Vocabulary are ignored.
+	IDF        *TfidfTransformer
+	Vocabulary map[string]int
+
+	stopWords map[string]struct{}
+}
+
+// NewEmbeddingVectoriser creates a new EmbeddingVectoriser averaging the supplied word
+// vectors.  Any specified stop words are removed from documents before averaging.
+func NewEmbeddingVectoriser(vectors *WordVectors, stopWords ...string) *EmbeddingVectoriser {
+	return &EmbeddingVectoriser{
+		Vectors:      vectors,
+		Preprocessor: strings.ToLower,
+		Tokeniser:    newDefaultTokeniser(),
+		stopWords:    stopWordSet(stopWords),
+	}
+}
+
+// Fit does nothing as EmbeddingVectoriser does not require fitting.  It is provided to
+// implement the Vectoriser interface.
+func (v *EmbeddingVectoriser) Fit(train ...string) Vectoriser {
+	return v
+}
+
+// Transform transforms the supplied documents into a matrix where each column is the
+// average of the word vectors of the words within the corresponding document (weighted by
+// inverse document frequency if IDF and Vocabulary are set).  The matrix has a row for each
+// dimension of the word vectors.  Each occurance of a word contributes to the average so
+// repeated words carry more weight.  An error is returned if Vectors is nil or has no
+// dimensions or if IDF is set but not fitted.
+func (v *EmbeddingVectoriser) Transform(docs ...string) (*mat64.Dense, error) {
+	if v.Vectors == nil || v.Vectors.Dim() == 0 {
+		return nil, fmt.Errorf("%w: EmbeddingVectoriser requires word vectors with at least 1 dimension", ErrInvalidArgument)
+	}
+
+	var weights []float64
+	if v.IDF != nil && v.Vocabulary != nil {
+		if weights = v.IDF.Weights(); weights == nil {
+			return nil, fmt.Errorf("%w: EmbeddingVectoriser IDF TfidfTransformer", ErrNotFitted)
+		}
+	}
+
+	mat := mat64.NewDense(v.Vectors.Dim(), len(docs), nil)
+
+	for j, doc := range docs {
+		var total float64
+		sum := mat.ColView(j)
+		words, _ := analyse(WordAnalyser, doc, v.Preprocessor, v.Tokeniser, 0, v.stopWords, nil, 1, 1)
+		for _, word := range words {
+			vec, ok := v.Vectors.Vector(word)
+			if !ok {
+				continue
+			}
+			weight := 1.0
+			if weights != nil {
+				i, ok := v.Vocabulary[word]
+				if !ok || i >= len(weights) {
+					continue
+				}
+				weight = weights[i]
+			}
+			sum.AddScaledVec(sum, weight, vec)
+			total += weight
+		}
+		if total != 0 {
+			sum.ScaleVec(1/total, sum)
+		}
+	}
+	return mat, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (v *EmbeddingVectoriser) FitTransform(docs ...string) (*mat64.Dense, error) {
+	return v.Fit(docs...).Transform(docs...)
+}
@@ -0,0 +1,145 @@
+package nlp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const testWordVectors = `4 3
+fox 1 0 0
+dog 0 1 0
+
+cat 0 0.5 0.5
+quick 1 1 -1
+`
+
+func TestLoadWordVectors(t *testing.T) {
+	vectors, err := LoadWordVectors(strings.NewReader(testWordVectors))
+	if err != nil {
+		t.Fatalf("Failed to load word vectors caused by %v", err)
+	}
+	if vectors.Dim() != 3 || vectors.Len() != 4 {
+		t.Errorf("Expected 4 vectors of 3 dimensions but found %d of %d", vectors.Len(), vectors.Dim())
+	}
+
+	vec, ok := vectors.Vector("cat")
+	if !ok || !mat64.Equal(vec, mat64.NewVector(3, []float64{0, 0.5, 0.5})) {
+		t.Errorf("Expected vector [0 0.5 0.5] for 'cat' but found %v", vec)
+	}
+	if _, ok := vectors.Vector("zebra"); ok {
+		t.Errorf("Expected no vector for 'zebra'")
+	}
+
+	// GloVe format without a header
+	vectors, err = LoadWordVectors(strings.NewReader("fox 1 2\ndog 3 4\n"))
+	if err != nil || vectors.Dim() != 2 || vectors.Len() != 2 {
+		t.Errorf("Expected 2 vectors of 2 dimensions but found %v (error %v)", vectors, err)
+	}
+
+	var invalid = []struct {
+		input    string
+		expected error
+	}{
+		{input: "fox 1 2\ndog 3\n", expected: ErrDimensionMismatch},
+		{input: "fox 1 x\n"},
+		{input: "fox 1 2\ndog\n"},
+	}
+	for _, test := range invalid {
+		_, err := LoadWordVectors(strings.NewReader(test.input))
+		if err == nil || (test.expected != nil && !errors.Is(err, test.expected)) {
+			t.Errorf("Expected error loading %q but found '%v'", test.input, err)
+		}
+	}
+}
+
+func TestEmbeddingVectoriser(t *testing.T) {
+	vectors, _ := LoadWordVectors(strings.NewReader(testWordVectors))
+	vectoriser := NewEmbeddingVectoriser(vectors, "the")
+
+	mat, err := vectoriser.FitTransform(
+		"The quick fox",
+		"the Dog and the unknown cat",
+		"fox fox dog",
+		"zebra giraffe",
+		"",
+	)
+	if err != nil {
+		t.Fatalf("Failed to transform documents caused by %v", err)
+	}
+
+	expected := mat64.NewDense(3, 5, []float64{
+		1, 0, 2.0 / 3, 0, 0,
+		0.5, 0.75, 1.0 / 3, 0, 0,
+		-0.5, 0.25, 0, 0, 0,
+	})
+	if !mat64.EqualApprox(expected, mat, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(mat))
+		t.Fail()
+	}
+
+	var invalid = []struct {
+		name    string
+		vectors *WordVectors
+	}{
+		{"nil word vectors", nil},
+		{"word vectors without dimensions", &WordVectors{}},
+	}
+	for _, test := range invalid {
+		vectoriser := NewEmbeddingVectoriser(test.vectors)
+		if _, err := vectoriser.Transform("the quick fox"); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("%s: Expected error wrapping '%v' but found '%v'", test.name, ErrInvalidArgument, err)
+		}
+	}
+}
+
+func TestEmbeddingVectoriserIDF(t *testing.T) {
+	vectors, _ := LoadWordVectors(strings.NewReader(testWordVectors))
+
+	train := []string{"the quick fox", "the dog", "the cat", "the fox"}
+	counts := NewCountVectoriser(false)
+	tfidf := NewTfidfTransformer()
+	mat, _ := counts.FitTransform(train...)
+	tfidf.Fit(mat)
+	weights := tfidf.Weights()
+
+	vectoriser := NewEmbeddingVectoriser(vectors)
+	plain, _ := vectoriser.Transform("the quick fox")
+
+	vectoriser.IDF = tfidf
+	vectoriser.Vocabulary = counts.Vocabulary
+	weighted, err := vectoriser.Transform("the quick fox", "the zebra")
+	if err != nil {
+		t.Fatalf("Failed to transform documents caused by %v", err)
+	}
+
+	// "the" has no vector and so is ignored, "quick" is rarer than "fox" and so contributes
+	// more to the weighted average than to the plain average
+	wq, wf := weights[counts.Vocabulary["quick"]], weights[counts.Vocabulary["fox"]]
+	expected := mat64.NewDense(3, 2, []float64{
+		1, 0,
+		wq / (wq + wf), 0,
+		-wq / (wq + wf), 0,
+	})
+	if !mat64.EqualApprox(expected, weighted, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(weighted))
+		t.Fail()
+	}
+	if !mat64.EqualApprox(plain, mat64.NewDense(3, 1, []float64{1, 0.5, -0.5}), 0.000001) {
+		t.Errorf("Expected plain average [1 0.5 -0.5] but found %v", mat64.Formatted(plain))
+	}
+	if weighted.At(1, 0) <= plain.At(1, 0) {
+		t.Errorf("Expected the rarer word to contribute more to the idf weighted average")
+	}
+
+	vectoriser.IDF = NewTfidfTransformer()
+	if _, err := vectoriser.Transform("the fox"); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+}
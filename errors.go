@@ -0,0 +1,53 @@
+package nlp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped with further detail) by the vectorisers, transformers
+// and other functions within this package.  Callers may test for them using errors.Is()
+// e.g.
+//
+//	if _, err := transformer.Transform(mat); errors.Is(err, nlp.ErrNotFitted) {
+//		...
+//	}
+var (
+	// ErrNotFitted is returned when a vectoriser or transformer is used before it has been
+	// fitted.
+	ErrNotFitted = errors.New("not fitted")
+
+	// ErrDimensionMismatch is returned when the dimensions of a matrix or vector are
+	// incompatible with those expected e.g. a matrix with a different number of rows (terms)
+	// to the matrix a transformer was fitted on.
+	ErrDimensionMismatch = errors.New("dimension mismatch")
+
+	// ErrNonFinite is returned when an input contains NaN or infinite values and non finite
+	// values are rejected (see NonFinitePolicy).
+	ErrNonFinite = errors.New("non finite value")
+
+	// ErrInvalidArgument is returned when an argument or configuration value is outside the
+	// range of valid values.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrEmptyVocabulary is returned when fitting to a corpus containing no terms e.g. a term
+	// document matrix with no rows or documents containing only stop words or terms pruned
+	// from the vocabulary.
+	ErrEmptyVocabulary = errors.New("empty vocabulary")
+
+	// ErrEmptyCorpus is returned when fitting to a corpus containing no documents e.g. a term
+	// document matrix with no columns.
+	ErrEmptyCorpus = errors.New("empty corpus")
+)
+
+// checkCorpus returns an error if a term document matrix with m rows (terms) and n columns
+// (documents) is empty.
+func checkCorpus(m, n int) error {
+	if n == 0 {
+		return fmt.Errorf("%w: matrix contains no documents (columns)", ErrEmptyCorpus)
+	}
+	if m == 0 {
+		return fmt.Errorf("%w: matrix contains no terms (rows)", ErrEmptyVocabulary)
+	}
+	return nil
+}
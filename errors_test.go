@@ -0,0 +1,171 @@
+package nlp
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestErrors(t *testing.T) {
+	mat := mat64.NewDense(3, 2, []float64{
+		1, 0,
+		0, 2,
+		3, 1,
+	})
+	wrongRows := mat64.NewDense(4, 2, nil)
+
+	fitted := NewTfidfTransformer()
+	fitted.Fit(mat)
+	reject := NewTfidfTransformer()
+	reject.NonFinite = RejectNonFinite
+	reject.Fit(mat)
+	svd := NewTruncatedSVD(2)
+	svd.Fit(mat)
+	bm25 := NewBM25Transformer()
+	bm25.Fit(mat)
+	lda := NewLatentDirichletAllocation(2)
+	lda.MaxIter = 1
+	lda.Fit(mat)
+
+	var tests = []struct {
+		name     string
+		fn       func() error
+		expected error
+	}{
+		{"TfidfTransformer unfitted", func() error {
+			_, err := NewTfidfTransformer().Transform(mat)
+			return err
+		}, ErrNotFitted},
+		{"TfidfTransformer rows", func() error {
+			_, err := fitted.Transform(wrongRows)
+			return err
+		}, ErrDimensionMismatch},
+		{"TfidfTransformer TransformInto", func() error {
+			return fitted.TransformInto(mat64.NewDense(3, 3, nil), mat)
+		}, ErrDimensionMismatch},
+		{"TfidfTransformer non finite", func() error {
+			_, err := reject.Transform(mat64.NewDense(3, 1, []float64{1, math.NaN(), 0}))
+			return err
+		}, ErrNonFinite},
+		{"TfidfTransformer query non finite", func() error {
+			_, err := reject.TransformQuery(map[int]float64{0: math.Inf(1)})
+			return err
+		}, ErrNonFinite},
+		{"TfidfTransformer Update", func() error {
+			return fitted.Update(mat, 2)
+		}, ErrInvalidArgument},
+		{"TfidfTransformer Subset", func() error {
+			_, err := fitted.Subset([]int{3})
+			return err
+		}, ErrInvalidArgument},
+		{"BM25Transformer unfitted", func() error {
+			_, err := NewBM25Transformer().Transform(mat)
+			return err
+		}, ErrNotFitted},
+		{"BM25Transformer rows", func() error {
+			_, err := bm25.Transform(wrongRows)
+			return err
+		}, ErrDimensionMismatch},
+		{"TruncatedSVD unfitted", func() error {
+			_, err := NewTruncatedSVD(2).Transform(mat)
+			return err
+		}, ErrNotFitted},
+		{"TruncatedSVD rows", func() error {
+			_, err := svd.Transform(wrongRows)
+			return err
+		}, ErrDimensionMismatch},
+		{"RandomProjection unfitted", func() error {
+			_, err := NewRandomProjection(2, 1).Transform(mat)
+			return err
+		}, ErrNotFitted},
+		{"LatentDirichletAllocation unfitted", func() error {
+			_, err := NewLatentDirichletAllocation(2).Transform(mat)
+			return err
+		}, ErrNotFitted},
+		{"LatentDirichletAllocation rows", func() error {
+			_, err := lda.Transform(wrongRows)
+			return err
+		}, ErrDimensionMismatch},
+		{"CountVectoriser unfitted", func() error {
+			_, err := NewCountVectoriser(false).Transform("the quick brown fox")
+			return err
+		}, ErrNotFitted},
+		{"KMeans K", func() error {
+			return NewKMeans(3, 1).Fit(mat)
+		}, ErrInvalidArgument},
+		{"TfidfTransformer empty corpus", func() error {
+			_, err := NewTfidfTransformer().FitTransform(NewSparseMatrix(3, 0, nil, nil, nil))
+			return err
+		}, ErrEmptyCorpus},
+		{"TfidfTransformer empty vocabulary", func() error {
+			_, err := NewTfidfTransformer().FitTransform(NewSparseMatrix(0, 2, nil, nil, nil))
+			return err
+		}, ErrEmptyVocabulary},
+		{"TfidfTransformer Fit empty corpus", func() error {
+			transformer := NewTfidfTransformer()
+			transformer.Fit(mat)
+			transformer.Fit(NewSparseMatrix(3, 0, nil, nil, nil))
+			_, err := transformer.Transform(mat)
+			return err
+		}, ErrEmptyCorpus},
+		{"TfidfTransformer Fit empty vocabulary", func() error {
+			transformer := NewTfidfTransformer()
+			transformer.Fit(NewSparseMatrix(0, 2, nil, nil, nil))
+			_, err := transformer.Transform(mat)
+			return err
+		}, ErrEmptyVocabulary},
+		{"TfidfTransformer refit after failed Fit", func() error {
+			transformer := NewTfidfTransformer()
+			transformer.Fit(NewSparseMatrix(3, 0, nil, nil, nil))
+			transformer.Fit(mat)
+			_, err := transformer.Transform(wrongRows)
+			return err
+		}, ErrDimensionMismatch},
+		{"CountVectoriser empty corpus", func() error {
+			_, err := NewCountVectoriser(false).FitTransform()
+			return err
+		}, ErrEmptyCorpus},
+		{"CountVectoriser empty vocabulary", func() error {
+			_, err := NewCountVectoriser(true).FitTransformSparse("the", "and of")
+			return err
+		}, ErrEmptyVocabulary},
+		{"CountVectoriser Fit pruned vocabulary", func() error {
+			vectoriser := NewCountVectoriser(false)
+			vectoriser.MinDF = 2
+			vectoriser.Fit("the quick brown fox", "jumped over a lazy dog")
+			_, err := vectoriser.Transform("the quick brown fox")
+			return err
+		}, ErrEmptyVocabulary},
+		{"CountVectoriser Fit empty corpus", func() error {
+			vectoriser := NewCountVectoriser(false)
+			vectoriser.Fit()
+			_, err := vectoriser.TransformSparse("the quick brown fox")
+			return err
+		}, ErrEmptyCorpus},
+		{"CountVectoriser refit after pruned vocabulary", func() error {
+			vectoriser := NewCountVectoriser(false)
+			vectoriser.MinDF = 2
+			vectoriser.Fit("the quick brown fox", "jumped over a lazy dog")
+			vectoriser.Fit("the quick brown fox", "the lazy dog")
+			_, err := vectoriser.Transform("the quick brown fox")
+			return err
+		}, nil},
+	}
+
+	sentinels := []error{ErrNotFitted, ErrDimensionMismatch, ErrNonFinite, ErrInvalidArgument, ErrEmptyVocabulary, ErrEmptyCorpus}
+
+	for _, test := range tests {
+		err := test.fn()
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: Expected error wrapping '%v' but found '%v'", test.name, test.expected, err)
+			continue
+		}
+		for _, sentinel := range sentinels {
+			if sentinel != test.expected && errors.Is(err, sentinel) {
+				t.Errorf("%s: Expected error not to wrap '%v' but found '%v'", test.name, sentinel, err)
+			}
+		}
+	}
+}
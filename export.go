@@ -0,0 +1,72 @@
+package nlp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// WriteMatrixCSV writes the supplied term document matrix to w in CSV format for inspection
+// or for loading into other tools (e.g. pandas).  The first row is a header containing the
+// document IDs (column labels) and each subsequent row contains a term (row label) followed
+// by the values of that row of the matrix.  The first cell of the header is "term".  If
+// terms or docIDs are nil, the row or column indices are used as labels respectively.
+// Values are formatted with the minimum precision necessary to be parsed back exactly.  An
+// error is returned if terms or docIDs are not nil and their lengths differ from the number
+// of rows or columns of the matrix respectively, or if writing fails.
+func WriteMatrixCSV(w io.Writer, mat mat64.Matrix, terms []string, docIDs []string) error {
+	return writeMatrix(w, mat, terms, docIDs, ',')
+}
+
+// WriteMatrixTSV is equivalent to WriteMatrixCSV() but separates values with tabs rather
+// than commas.
+func WriteMatrixTSV(w io.Writer, mat mat64.Matrix, terms []string, docIDs []string) error {
+	return writeMatrix(w, mat, terms, docIDs, '\t')
+}
+
+// writeMatrix writes the matrix to w with values separated by comma
+func writeMatrix(w io.Writer, mat mat64.Matrix, terms []string, docIDs []string, comma rune) error {
+	m, n := mat.Dims()
+	if terms != nil && len(terms) != m {
+		return fmt.Errorf("%w: %d terms supplied but matrix has %d rows", ErrDimensionMismatch, len(terms), m)
+	}
+	if docIDs != nil && len(docIDs) != n {
+		return fmt.Errorf("%w: %d document IDs supplied but matrix has %d columns", ErrDimensionMismatch, len(docIDs), n)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	record := make([]string, n+1)
+	record[0] = "term"
+	for j := 0; j < n; j++ {
+		if docIDs != nil {
+			record[j+1] = docIDs[j]
+		} else {
+			record[j+1] = strconv.Itoa(j)
+		}
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	for i := 0; i < m; i++ {
+		if terms != nil {
+			record[0] = terms[i]
+		} else {
+			record[0] = strconv.Itoa(i)
+		}
+		for j := 0; j < n; j++ {
+			record[j+1] = strconv.FormatFloat(mat.At(i, j), 'g', -1, 64)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,97 @@
+package nlp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestWriteMatrixCSV(t *testing.T) {
+	mat := mat64.NewDense(3, 2, []float64{
+		1, 0,
+		0.1, 2,
+		1.0 / 3, -4e-10,
+	})
+
+	var tests = []struct {
+		terms   []string
+		docIDs  []string
+		comma   rune
+		write   func(buf *bytes.Buffer, terms, docIDs []string) error
+		headers []string
+		labels  []string
+	}{
+		{
+			terms:  []string{"fox", "dog, cat", "the"},
+			docIDs: []string{"doc1", "doc2"},
+			comma:  ',',
+			write: func(buf *bytes.Buffer, terms, docIDs []string) error {
+				return WriteMatrixCSV(buf, mat, terms, docIDs)
+			},
+			headers: []string{"term", "doc1", "doc2"},
+			labels:  []string{"fox", "dog, cat", "the"},
+		},
+		{
+			comma: ',',
+			write: func(buf *bytes.Buffer, terms, docIDs []string) error {
+				return WriteMatrixCSV(buf, NewSparseMatrixFrom(mat), terms, docIDs)
+			},
+			headers: []string{"term", "0", "1"},
+			labels:  []string{"0", "1", "2"},
+		},
+		{
+			terms: []string{"fox", "dog", "the"},
+			comma: '\t',
+			write: func(buf *bytes.Buffer, terms, docIDs []string) error {
+				return WriteMatrixTSV(buf, mat, terms, docIDs)
+			},
+			headers: []string{"term", "0", "1"},
+			labels:  []string{"fox", "dog", "the"},
+		},
+	}
+
+	for ti, test := range tests {
+		var buf bytes.Buffer
+		if err := test.write(&buf, test.terms, test.docIDs); err != nil {
+			t.Errorf("Test %d: Failed to write matrix caused by %v", ti, err)
+			continue
+		}
+
+		reader := csv.NewReader(&buf)
+		reader.Comma = test.comma
+		records, err := reader.ReadAll()
+		if err != nil {
+			t.Errorf("Test %d: Failed to parse written matrix caused by %v", ti, err)
+			continue
+		}
+		if len(records) != 4 || !reflect.DeepEqual(records[0], test.headers) {
+			t.Errorf("Test %d: Expected header %v and 3 rows but found %v", ti, test.headers, records)
+			continue
+		}
+
+		for i, record := range records[1:] {
+			if record[0] != test.labels[i] {
+				t.Errorf("Test %d: Expected label '%s' for row %d but found '%s'", ti, test.labels[i], i, record[0])
+			}
+			for j, field := range record[1:] {
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil || v != mat.At(i, j) {
+					t.Errorf("Test %d: Expected value %v at (%d, %d) but found '%s'", ti, mat.At(i, j), i, j, field)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMatrixCSV(&buf, mat, []string{"fox"}, nil); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' for mismatched terms but found '%v'", ErrDimensionMismatch, err)
+	}
+	if err := WriteMatrixCSV(&buf, mat, nil, []string{"doc1", "doc2", "doc3"}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' for mismatched document IDs but found '%v'", ErrDimensionMismatch, err)
+	}
+}
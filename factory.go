@@ -0,0 +1,149 @@
+package nlp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NewTransformer creates a new term weighting Transformer identified by name and configured
+// from the supplied options, allowing pipelines to be built from configuration (e.g. JSON or
+// YAML) rather than code.  The supported names and their options are:
+//
+//	"tfidf"      TfidfTransformer: "smooth", "offset_idf", "sublinear_tf" (bool),
+//	             "log_base", "max_idf" (number) and "norm" ("none", "l1", "l2" or "max")
+//	"bm25"       BM25Transformer: "k1", "b" (number)
+//	"tf"         TfTransformer: "augmented" (bool)
+//	"logentropy" LogEntropyTransformer: no options
+//
+// Options not specified keep the defaults of the corresponding constructor e.g.
+// NewTfidfTransformer().  Numbers may be supplied as any int or float type (JSON decoders
+// produce float64).  opts may be nil.  An error wrapping ErrInvalidArgument is returned if
+// the name is unknown or an option is unknown or of the wrong type.
+func NewTransformer(name string, opts map[string]interface{}) (Transformer, error) {
+	var t Transformer
+	var set func(key string, val interface{}) error
+
+	switch strings.ToLower(name) {
+	case "tfidf":
+		tfidf := NewTfidfTransformer()
+		t = tfidf
+		set = func(key string, val interface{}) (err error) {
+			switch key {
+			case "smooth":
+				tfidf.Smooth, err = boolOption(key, val)
+			case "offset_idf":
+				tfidf.OffsetIdf, err = boolOption(key, val)
+			case "sublinear_tf":
+				tfidf.SublinearTF, err = boolOption(key, val)
+			case "log_base":
+				if tfidf.LogBase, err = floatOption(key, val); err == nil {
+					err = checkLogBase(tfidf.LogBase)
+				}
+			case "max_idf":
+				tfidf.MaxIDF, err = floatOption(key, val)
+			case "norm":
+				tfidf.Norm, err = normOption(key, val)
+			default:
+				err = unknownOption(name, key)
+			}
+			return err
+		}
+	case "bm25":
+		bm25 := NewBM25Transformer()
+		t = bm25
+		set = func(key string, val interface{}) (err error) {
+			switch key {
+			case "k1":
+				bm25.K1, err = floatOption(key, val)
+			case "b":
+				bm25.B, err = floatOption(key, val)
+			default:
+				err = unknownOption(name, key)
+			}
+			return err
+		}
+	case "tf":
+		tf := NewTfTransformer()
+		t = tf
+		set = func(key string, val interface{}) (err error) {
+			switch key {
+			case "augmented":
+				tf.Augmented, err = boolOption(key, val)
+			default:
+				err = unknownOption(name, key)
+			}
+			return err
+		}
+	case "logentropy":
+		t = NewLogEntropyTransformer()
+		set = func(key string, val interface{}) error {
+			return unknownOption(name, key)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown transformer '%s'", ErrInvalidArgument, name)
+	}
+
+	// apply options in a consistent order so that errors are deterministic
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := set(key, opts[key]); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// unknownOption returns an error for an option not supported by the named transformer
+func unknownOption(name, key string) error {
+	return fmt.Errorf("%w: unknown option '%s' for transformer '%s'", ErrInvalidArgument, key, name)
+}
+
+// boolOption returns the value of a boolean option
+func boolOption(key string, val interface{}) (bool, error) {
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: option '%s' must be a bool but found %T", ErrInvalidArgument, key, val)
+	}
+	return b, nil
+}
+
+// floatOption returns the value of a numeric option as a float64
+func floatOption(key string, val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("%w: option '%s' must be a number but found %T", ErrInvalidArgument, key, val)
+}
+
+// normOption returns the NormType named by the value of a norm option
+func normOption(key string, val interface{}) (NormType, error) {
+	s, ok := val.(string)
+	if !ok {
+		return NoNorm, fmt.Errorf("%w: option '%s' must be a string but found %T", ErrInvalidArgument, key, val)
+	}
+	switch strings.ToLower(s) {
+	case "none", "":
+		return NoNorm, nil
+	case "l1":
+		return L1Norm, nil
+	case "l2":
+		return L2Norm, nil
+	case "max":
+		return MaxNorm, nil
+	}
+	return NoNorm, fmt.Errorf("%w: unknown norm '%s' for option '%s'", ErrInvalidArgument, s, key)
+}
@@ -0,0 +1,87 @@
+package nlp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestNewTransformer(t *testing.T) {
+	mat := mat64.NewDense(4, 3, []float64{
+		1, 0, 2,
+		3, 1, 0,
+		0, 0, 1,
+		1, 1, 1,
+	})
+
+	tfidf := NewTfidfTransformer()
+	tfidf.Norm = L2Norm
+	tfidf.SublinearTF = true
+	tfidf.LogBase = 2
+	bm25 := NewBM25Transformer()
+	bm25.K1 = 1.2
+	tf := NewTfTransformer()
+	tf.Augmented = true
+
+	var tests = []struct {
+		name     string
+		opts     map[string]interface{}
+		expected Transformer
+	}{
+		{name: "tfidf", expected: NewTfidfTransformer()},
+		{
+			name:     "tfidf",
+			opts:     map[string]interface{}{"norm": "l2", "sublinear_tf": true, "log_base": 2},
+			expected: tfidf,
+		},
+		{name: "bm25", expected: NewBM25Transformer()},
+		{name: "BM25", opts: map[string]interface{}{"k1": 1.2}, expected: bm25},
+		{name: "tf", expected: NewTfTransformer()},
+		{name: "tf", opts: map[string]interface{}{"augmented": true}, expected: tf},
+		{name: "logentropy", expected: NewLogEntropyTransformer()},
+	}
+
+	for ti, test := range tests {
+		transformer, err := NewTransformer(test.name, test.opts)
+		if err != nil {
+			t.Errorf("Test %d: Failed to create transformer '%s' caused by %v", ti, test.name, err)
+			continue
+		}
+
+		result, err := transformer.FitTransform(mat)
+		if err != nil {
+			t.Errorf("Test %d: Failed to transform matrix caused by %v", ti, err)
+			continue
+		}
+		expected, _ := test.expected.FitTransform(mat)
+		if !mat64.EqualApprox(expected, result, 0.000001) {
+			t.Logf("Test %d: Expected matrix: \n%v\n but found: \n%v\n", ti,
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+}
+
+func TestNewTransformerInvalid(t *testing.T) {
+	var tests = []struct {
+		name string
+		opts map[string]interface{}
+	}{
+		{name: "word2vec"},
+		{name: "tfidf", opts: map[string]interface{}{"smoothing": true}},
+		{name: "tfidf", opts: map[string]interface{}{"smooth": "yes"}},
+		{name: "tfidf", opts: map[string]interface{}{"norm": "l3"}},
+		{name: "tfidf", opts: map[string]interface{}{"log_base": 1}},
+		{name: "tfidf", opts: map[string]interface{}{"log_base": -2}},
+		{name: "bm25", opts: map[string]interface{}{"k1": "1.2"}},
+		{name: "logentropy", opts: map[string]interface{}{"norm": "l2"}},
+	}
+
+	for ti, test := range tests {
+		if _, err := NewTransformer(test.name, test.opts); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Test %d: Expected error wrapping '%v' but found '%v'", ti, ErrInvalidArgument, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package nlp
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sync"
+)
+
+// mersennePrime is the prime 2^61 - 1 used as the modulus of the MinHash hash functions
+const mersennePrime = (1 << 61) - 1
+
+// MinHashSignature is a MinHash signature of a set of tokens (or terms) containing the
+// minimum hash value of the set's elements under each of the hash functions.
+type MinHashSignature []uint64
+
+// MinHash produces MinHash signatures of sets of tokens (e.g. the words or shingles of a
+// document) for estimating the Jaccard similarity between sets.  Each signature contains
+// the minimum value of each of K random hash functions (approximating random permutations)
+// over the elements of the set.  The probability of the corresponding values of 2
+// signatures being equal is the Jaccard similarity of the sets and so the proportion of
+// equal values (see JaccardEstimate()) estimates the similarity using fixed size signatures
+// regardless of the size of the sets.  This makes MinHash suited to near duplicate
+// detection across large collections of documents where calculating the exact Jaccard
+// similarity (see JaccardSimilarity()) between every pair of documents would be too slow.
+type MinHash struct {
+	// lock guards the hash functions, a and b, together with the K, Seed and Source from
+	// which they were generated
+	lock   sync.Mutex
+	a, b   []uint64
+	k      int
+	seed   int64
+	source rand.Source
+
+	// K is the number of hash functions and so the length of each signature.  The error of
+	// the estimated similarity is proportional to 1/sqrt(K).
+	K int
+
+	// Seed is used to seed the random number generator used to generate the hash functions
+	// so that signatures are reproducible.  Signatures may only be compared if produced
+	// using the same K and Seed.
+	Seed int64
+
+	// Source, if set, is used as the source of random numbers in place of a source seeded
+	// with Seed in the same way as RandomProjection.
+	Source rand.Source
+}
+
+// NewMinHash creates a new MinHash producing signatures of length k, using the specified
+// seed for the random number generator.
+func NewMinHash(k int, seed int64) *MinHash {
+	h := &MinHash{K: k, Seed: seed}
+	h.functions()
+	return h
+}
+
+// functions returns the K hash functions, of the form (a*x + b) mod p, generating them if
+// they have not yet been generated or K, Seed or Source have changed since they were.  The
+// returned slices are never modified (regeneration allocates new slices) so may be used
+// without holding the lock, allowing signatures to be produced concurrently.
+func (h *MinHash) functions() (a, b []uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.a != nil && h.k == h.K && h.seed == h.Seed && h.source == h.Source {
+		return h.a, h.b
+	}
+	k := max(h.K, 0)
+	rnd := newRand(h.Source, h.Seed)
+	h.a = make([]uint64, k)
+	h.b = make([]uint64, k)
+	for i := range h.a {
+		h.a[i] = 1 + uint64(rnd.Int63n(mersennePrime-1))
+		h.b[i] = uint64(rnd.Int63n(mersennePrime))
+	}
+	h.k, h.seed, h.source = h.K, h.Seed, h.Source
+	return h.a, h.b
+}
+
+// Signature returns the MinHash signature of the set of supplied tokens.  Duplicate tokens
+// do not affect the signature.  The signature of an empty set contains only the maximum
+// uint64 value.
+func (h *MinHash) Signature(tokens []string) MinHashSignature {
+	a, b := h.functions()
+	sig := emptySignature(len(a))
+	for _, token := range tokens {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(token))
+		update(sig, a, b, hasher.Sum64())
+	}
+	return sig
+}
+
+// SignatureIndices returns the MinHash signature of the set of supplied term indices (e.g.
+// the rows of the terms present within a document) in the same way as Signature().
+func (h *MinHash) SignatureIndices(indices []int) MinHashSignature {
+	a, b := h.functions()
+	sig := emptySignature(len(a))
+	for _, i := range indices {
+		update(sig, a, b, uint64(i))
+	}
+	return sig
+}
+
+// emptySignature returns the signature of length k of an empty set
+func emptySignature(k int) MinHashSignature {
+	sig := make(MinHashSignature, k)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	return sig
+}
+
+// update updates the signature with the values of the element x under the hash functions
+// with coefficients a and b
+func update(sig MinHashSignature, a, b []uint64, x uint64) {
+	x %= mersennePrime
+	for i := range sig {
+		hi, lo := bits.Mul64(a[i], x)
+		_, v := bits.Div64(hi, lo, mersennePrime)
+		v = (v + b[i]) % mersennePrime
+		if v < sig[i] {
+			sig[i] = v
+		}
+	}
+}
+
+// JaccardEstimate estimates the Jaccard similarity of the sets represented by the 2 supplied
+// MinHash signatures as the proportion of their corresponding values that are equal.  Both
+// signatures should have been produced by the same MinHash (or one with the same K and
+// Seed).  If the signatures are of different lengths, only the shorter length is compared.
+// Consistent with JaccardSimilarity(), the similarity of 2 empty sets is 0.
+func JaccardEstimate(a, b MinHashSignature) float64 {
+	n := min(len(a), len(b))
+	if n == 0 {
+		return 0
+	}
+
+	var equal int
+	for i := 0; i < n; i++ {
+		if a[i] == b[i] && a[i] != math.MaxUint64 {
+			equal++
+		}
+	}
+	return float64(equal) / float64(n)
+}
@@ -0,0 +1,103 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestMinHashJaccardEstimate(t *testing.T) {
+	tokens := func(start, end int) []string {
+		var toks []string
+		for i := start; i < end; i++ {
+			toks = append(toks, fmt.Sprintf("token%d", i))
+		}
+		return toks
+	}
+
+	var tests = []struct {
+		a, b    []string
+		jaccard float64
+	}{
+		{a: tokens(0, 100), b: tokens(0, 100), jaccard: 1},
+		{a: tokens(0, 100), b: tokens(50, 150), jaccard: 50 / 150.0},
+		{a: tokens(0, 100), b: tokens(20, 100), jaccard: 0.8},
+		{a: tokens(0, 100), b: tokens(100, 200), jaccard: 0},
+		{a: nil, b: nil, jaccard: 0},
+	}
+
+	hash := NewMinHash(512, 1)
+	for _, test := range tests {
+		estimate := JaccardEstimate(hash.Signature(test.a), hash.Signature(test.b))
+		if math.Abs(estimate-test.jaccard) > 0.06 {
+			t.Errorf("Expected estimate within 0.06 of %f but found %f", test.jaccard, estimate)
+		}
+	}
+
+	// the estimate should agree with the exact Jaccard similarity of term indices
+	a := mat64.NewVector(6, []float64{1, 1, 0, 1, 0, 1})
+	b := mat64.NewVector(6, []float64{1, 0, 0, 1, 1, 1})
+	estimate := JaccardEstimate(hash.SignatureIndices([]int{0, 1, 3, 5}), hash.SignatureIndices([]int{0, 3, 4, 5}))
+	if exact := JaccardSimilarity(a, b); math.Abs(estimate-exact) > 0.06 {
+		t.Errorf("Expected estimate within 0.06 of %f but found %f", exact, estimate)
+	}
+}
+
+func TestMinHashReproducible(t *testing.T) {
+	tokens := []string{"the", "quick", "brown", "fox"}
+
+	sig := NewMinHash(64, 7).Signature(tokens)
+	if len(sig) != 64 {
+		t.Errorf("Expected signature of length 64 but found %d", len(sig))
+	}
+	if other := NewMinHash(64, 7).Signature(tokens); !reflect.DeepEqual(sig, other) {
+		t.Errorf("Expected signatures with the same seed to be identical")
+	}
+	if other := NewMinHash(64, 8).Signature(tokens); reflect.DeepEqual(sig, other) {
+		t.Errorf("Expected signatures with different seeds to differ")
+	}
+	if other := NewMinHash(64, 7).Signature([]string{"fox", "brown", "quick", "the", "fox"}); !reflect.DeepEqual(sig, other) {
+		t.Errorf("Expected signature to be independent of token order and duplicates")
+	}
+
+	// changing the configuration after use regenerates the hash functions
+	h := NewMinHash(64, 7)
+	h.Signature(tokens)
+	h.Seed = 8
+	if other := h.Signature(tokens); reflect.DeepEqual(sig, other) {
+		t.Errorf("Expected signatures to differ after changing the seed")
+	}
+	h.Seed = 7
+	if other := h.Signature(tokens); !reflect.DeepEqual(sig, other) {
+		t.Errorf("Expected signatures to match after restoring the seed")
+	}
+	h.Source = rand.NewSource(99)
+	if other := h.Signature(tokens); reflect.DeepEqual(sig, other) {
+		t.Errorf("Expected signatures to differ after changing the source")
+	}
+	h.Source = nil
+	h.K = 32
+	if other := h.Signature(tokens); len(other) != 32 {
+		t.Errorf("Expected signature of length 32 after changing K but found %d", len(other))
+	}
+
+	// signatures may be produced concurrently
+	h = &MinHash{K: 16, Seed: 3}
+	expected := NewMinHash(16, 3).Signature(tokens)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if other := h.Signature(tokens); !reflect.DeepEqual(expected, other) {
+				t.Errorf("Expected concurrent signatures to be identical")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,814 @@
+package nlp
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Pipeline chains together a vectoriser and a sequence of transformers so that text
+// documents may be processed through all of the stages with a single call e.g.
+// vectorisation -> tf-idf weighting -> truncated SVD.  The first stage, the vectoriser,
+// converts the documents into a term document matrix and each subsequent transformer is
+// applied, in order, to the matrix output by the previous stage.
+type Pipeline struct {
+	Vectoriser   Vectoriser
+	Transformers []Transformer
+
+	// buffers pools the intermediate matrices passed between stages during Transform() for
+	// reuse across calls.  If nil (e.g. the Pipeline was not constructed with NewPipeline)
+	// every stage allocates its output.
+	buffers *matrixPool
+}
+
+// NewPipeline constructs a new Pipeline from the specified vectoriser and transformers.
+// The transformers will be applied in the order specified.
+func NewPipeline(vectoriser Vectoriser, transformers ...Transformer) *Pipeline {
+	return &Pipeline{Vectoriser: vectoriser, Transformers: transformers, buffers: &matrixPool{}}
+}
+
+// Fit fits each stage of the pipeline in turn to the supplied training documents.  Each
+// transformer is fitted to the output of the previous stage (having itself been fitted).
+// If any stage fails, fitting stops and the error is returned identifying the failed stage.
+func (p *Pipeline) Fit(docs ...string) error {
+	_, err := p.FitTransform(docs...)
+	return err
+}
+
+// Transform transforms the supplied documents, applying each of the previously fitted
+// stages of the pipeline in turn, and returns the output of the final stage.  If any stage
+// fails, processing stops and the error is returned identifying the failed stage.  To
+// reduce the bytes allocated across repeated calls (e.g. when serving queries), the
+// intermediate outputs of stages able to write their output into a supplied matrix (the
+// vectorisers and TfidfTransformer) are written into buffers drawn from, and returned to, a
+// pool for each stage, provided the following stage is known not to retain its input.  The
+// number of allocations is largely unchanged as each stage still allocates while
+// processing the documents.  The output of the final stage is always newly allocated and
+// owned by the caller.  Transform may be called concurrently provided each stage supports
+// concurrent calls to Transform().
+func (p *Pipeline) Transform(docs ...string) (*mat64.Dense, error) {
+	mat, pooled, err := p.vectorise(docs)
+	if err != nil {
+		return nil, stageError(0, p.Vectoriser, err)
+	}
+
+	for i, t := range p.Transformers {
+		stage := i + 1
+		var out *mat64.Dense
+		into, ok := t.(intoTransformer)
+		outPooled := ok && p.pooled(stage)
+		if outPooled {
+			m, n := mat.Dims()
+			out = p.buffers.get(stage, m, n)
+			if err = into.TransformInto(out, mat); err != nil {
+				p.buffers.put(stage, out)
+			}
+		} else {
+			out, err = t.Transform(mat)
+		}
+		if pooled {
+			p.buffers.put(stage-1, mat)
+		}
+		if err != nil {
+			return nil, stageError(stage, t, err)
+		}
+		mat, pooled = out, outPooled
+	}
+	return mat, nil
+}
+
+// intoVectoriser is implemented by vectorisers able to write their output into a supplied
+// matrix allowing Pipeline to reuse the matrix across calls to Transform().
+type intoVectoriser interface {
+	NumFeatures() int
+	TransformInto(dst *mat64.Dense, docs ...string) error
+}
+
+// intoTransformer is implemented by transformers able to write their output into a supplied
+// matrix allowing Pipeline to reuse the matrix across calls to Transform().
+type intoTransformer interface {
+	TransformInto(dst *mat64.Dense, src mat64.Matrix) error
+}
+
+// vectorise transforms the supplied documents with the pipeline's Vectoriser, writing the
+// output into a buffer drawn from the pool of the stage if the output is to be pooled (in
+// which case true is returned).
+func (p *Pipeline) vectorise(docs []string) (*mat64.Dense, bool, error) {
+	into, ok := p.Vectoriser.(intoVectoriser)
+	if !ok || !p.pooled(0) || len(docs) == 0 || into.NumFeatures() == 0 {
+		mat, err := p.Vectoriser.Transform(docs...)
+		return mat, false, err
+	}
+	mat := p.buffers.get(0, into.NumFeatures(), len(docs))
+	if err := into.TransformInto(mat, docs...); err != nil {
+		p.buffers.put(0, mat)
+		return nil, false, err
+	}
+	return mat, true, nil
+}
+
+// pooled returns true if the output of the specified stage may be written into a buffer
+// drawn from the pool of the stage.  Only intermediate outputs are pooled and only when the
+// stage producing the output implements TransformInto and the stage consuming it is known
+// not to retain a reference to its input beyond the call.
+func (p *Pipeline) pooled(stage int) bool {
+	if p.buffers == nil || stage >= len(p.Transformers) {
+		return false
+	}
+	if stage == 0 {
+		if _, ok := p.Vectoriser.(intoVectoriser); !ok {
+			return false
+		}
+	} else if _, ok := p.Transformers[stage-1].(intoTransformer); !ok {
+		return false
+	}
+	return releasesInput(p.Transformers[stage])
+}
+
+// releasesInput returns true if the transformer is known to neither retain nor return (a
+// view of) the matrix supplied to Transform() so that the matrix may be safely reused once
+// Transform() returns.  Transformers of other types (including those wrapping other
+// transformers) are assumed to retain their input.
+func releasesInput(t Transformer) bool {
+	switch t.(type) {
+	case *TfidfTransformer, *BM25Transformer, *LogEntropyTransformer, *TficfTransformer,
+		*TfTransformer, *Normaliser, *ClipTransformer, *TruncatedSVD, *RandomProjection,
+		*PCA, *StandardScaler:
+		return true
+	}
+	return false
+}
+
+// matrixPool maintains a sync.Pool of reusable dense matrices for each stage of a Pipeline.
+type matrixPool struct {
+	lock  sync.Mutex
+	pools []*sync.Pool
+}
+
+// stage returns the pool for the specified stage, creating it if necessary.
+func (p *matrixPool) stage(i int) *sync.Pool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for len(p.pools) <= i {
+		p.pools = append(p.pools, &sync.Pool{})
+	}
+	return p.pools[i]
+}
+
+// get returns an r x c matrix from the pool of the specified stage, allocating a new matrix
+// if the pool is empty or the pooled matrix has different dimensions (e.g. a different
+// number of documents was transformed).  The values of the matrix are undefined.
+func (p *matrixPool) get(i, r, c int) *mat64.Dense {
+	if mat, ok := p.stage(i).Get().(*mat64.Dense); ok {
+		if m, n := mat.Dims(); m == r && n == c {
+			return mat
+		}
+	}
+	return mat64.NewDense(r, c, nil)
+}
+
+// put returns the matrix to the pool of the specified stage for reuse.
+func (p *matrixPool) put(i int, mat *mat64.Dense) {
+	p.stage(i).Put(mat)
+}
+
+// TransformOne transforms a single document, applying each of the previously fitted stages
+// of the pipeline in turn, and returns the output of the final stage as a vector.  This is
+// equivalent to calling Transform() with the single document and taking the only column
+// of the output.
+func (p *Pipeline) TransformOne(doc string) (*mat64.Vector, error) {
+	mat, err := p.Transform(doc)
+	if err != nil {
+		return nil, err
+	}
+	return mat.ColView(0), nil
+}
+
+// TransformT is equivalent to Transform() but returns the transpose of the output of the
+// final stage so that each row, rather than each column, represents one of the supplied
+// documents, as expected by many classifiers and other libraries.  The stages of the
+// pipeline always operate on matrices with documents as columns and so the output is
+// transposed into a newly allocated matrix.
+func (p *Pipeline) TransformT(docs ...string) (*mat64.Dense, error) {
+	mat, err := p.Transform(docs...)
+	if err != nil {
+		return nil, err
+	}
+	return mat64.DenseCopyOf(mat.T()), nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same documents.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (p *Pipeline) FitTransform(docs ...string) (*mat64.Dense, error) {
+	mat, err := p.Vectoriser.FitTransform(docs...)
+	if err != nil {
+		return nil, stageError(0, p.Vectoriser, err)
+	}
+
+	for i, t := range p.Transformers {
+		mat, err = t.FitTransform(mat)
+		if err != nil {
+			return nil, stageError(i+1, t, err)
+		}
+	}
+	return mat, nil
+}
+
+// FitTransformBatched fits the pipeline to the supplied documents and returns an iterator
+// over the transformed documents in batches of (up to) batchSize documents so that the
+// output for the whole corpus is never held in memory at once.  The Vectoriser is fitted to
+// all of the documents in a single streaming pass (building its vocabulary without
+// constructing a matrix) after which each transformer is fitted in turn by a further pass
+// over the documents, in batches, transforming each batch with the (fitted) preceding stages
+// before fitting the transformer to the batch with Fit() (for the first batch) or
+// PartialFit() (for subsequent batches).  Each transformer must therefore implement a
+// PartialFit(mat64.Matrix) Transformer method (e.g. TfidfTransformer) accumulating its
+// statistics across batches.  The output is identical to FitTransform() split into batches
+// of columns.  An error is returned if batchSize is less than 1, any transformer does not
+// support PartialFit() or any stage fails.
+func (p *Pipeline) FitTransformBatched(docs []string, batchSize int) (*BatchIterator, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("%w: batch size (%d) must be at least 1", ErrInvalidArgument, batchSize)
+	}
+	if err := checkCorpus(1, len(docs)); err != nil {
+		return nil, stageError(0, p.Vectoriser, err)
+	}
+	p.Vectoriser.Fit(docs...)
+
+	for i, t := range p.Transformers {
+		partial, ok := t.(interface {
+			PartialFit(mat mat64.Matrix) Transformer
+		})
+		if !ok {
+			return nil, fmt.Errorf("%w: pipeline stage %d (%T) does not support batched fitting", ErrInvalidArgument, i+1, t)
+		}
+
+		preceding := &Pipeline{Vectoriser: p.Vectoriser, Transformers: p.Transformers[:i], buffers: p.buffers}
+		batches := preceding.batches(docs, batchSize)
+		for first := true; ; first = false {
+			mat, err := batches.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if first {
+				t.Fit(mat)
+			} else {
+				partial.PartialFit(mat)
+			}
+		}
+	}
+	return p.batches(docs, batchSize), nil
+}
+
+// batches returns an iterator transforming the supplied documents with the pipeline in
+// batches of batchSize documents
+func (p *Pipeline) batches(docs []string, batchSize int) *BatchIterator {
+	return &BatchIterator{pipeline: p, docs: docs, size: batchSize}
+}
+
+// BatchIterator iterates over the output of a Pipeline for successive batches of documents
+// as returned by Pipeline.FitTransformBatched().
+type BatchIterator struct {
+	pipeline *Pipeline
+	docs     []string
+	size     int
+	next     int
+}
+
+// Next transforms the next batch of documents with the pipeline and returns the output of
+// the final stage with one column per document of the batch.  The batches are returned in
+// the order of the documents with each batch, other than (possibly) the last, containing
+// the same number of documents.  Once all of the documents have been transformed, Next
+// returns io.EOF.  If any stage of the pipeline fails, the error is returned identifying
+// the failed stage.
+func (b *BatchIterator) Next() (*mat64.Dense, error) {
+	if b.next >= len(b.docs) {
+		return nil, io.EOF
+	}
+	end := min(b.next+b.size, len(b.docs))
+	mat, err := b.pipeline.Transform(b.docs[b.next:end]...)
+	if err != nil {
+		return nil, err
+	}
+	b.next = end
+	return mat, nil
+}
+
+// pipelineEncodingVersion is the version of the encoding of Pipeline written by Save()
+const pipelineEncodingVersion = 1
+
+// pipelineStages maps the names of the stage types that may be saved with Pipeline.Save()
+// to constructors for the stages into which they are loaded by Pipeline.Load().
+var pipelineStages = map[string]func() interface{}{
+	"CountVectoriser":   func() interface{} { return NewCountVectoriser(false) },
+	"HashingVectoriser": func() interface{} { return NewHashingVectoriser(0) },
+	"TfidfTransformer":  func() interface{} { return NewTfidfTransformer() },
+	"TruncatedSVD":      func() interface{} { return NewTruncatedSVD(0) },
+}
+
+// pipelineManifest is the serialisable state of a Pipeline, listing its stages (vectoriser
+// first) in order.  Fields may be added in future versions but existing fields should not
+// be removed or changed.
+type pipelineManifest struct {
+	Stages []pipelineStage
+}
+
+// pipelineStage is the type name and binary encoding of a single stage of a Pipeline
+type pipelineStage struct {
+	Type string
+	Data []byte
+}
+
+// Save writes the fitted state of every stage of the pipeline to w as a single artifact, so
+// that the entire pipeline may later be restored with Load() e.g. to fit a model once and
+// serve it within a separate process.  The artifact holds a manifest of the types of the
+// stages, in order, along with the encoding of each stage produced by its MarshalBinary()
+// method.  Only CountVectoriser, HashingVectoriser, TfidfTransformer and TruncatedSVD
+// stages are supported and, as for their MarshalBinary() methods, function valued
+// configuration (e.g. Preprocessor, Tokeniser, Stemmer or WeightFunc) is not saved.  An
+// error is returned if any stage is not supported or can not be encoded, in which case
+// nothing is written, or if writing fails.
+func (p *Pipeline) Save(w io.Writer) error {
+	stages := p.stages()
+
+	manifest := pipelineManifest{Stages: make([]pipelineStage, len(stages))}
+	for i, stage := range stages {
+		name := stageName(stage)
+		newStage, ok := pipelineStages[name]
+		m, marshals := stage.(encoding.BinaryMarshaler)
+		if !ok || !marshals || reflect.TypeOf(newStage()) != reflect.TypeOf(stage) {
+			return fmt.Errorf("%w: pipeline stage %d (%T) does not support saving", ErrInvalidArgument, i, stage)
+		}
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("Failed to encode pipeline stage %d (%T) caused by %w", i, stage, err)
+		}
+		manifest.Stages[i] = pipelineStage{Type: name, Data: data}
+	}
+
+	if _, err := w.Write([]byte{pipelineEncodingVersion}); err != nil {
+		return fmt.Errorf("Failed to write pipeline caused by %w", err)
+	}
+	if err := gob.NewEncoder(w).Encode(manifest); err != nil {
+		return fmt.Errorf("Failed to write pipeline caused by %w", err)
+	}
+	return nil
+}
+
+// Load reads a pipeline previously written with Save() from r, replacing the Vectoriser and
+// Transformers of the pipeline with newly constructed stages of the saved types restored to
+// their saved state.  Function valued configuration of the stages is restored to the
+// defaults of their constructors (e.g. NewCountVectoriser()) and so must be reassigned if
+// non-default values were used.  An error is returned if reading fails, the data was not
+// written by Save() or any stage can not be decoded, in which case the pipeline is left
+// unchanged.
+func (p *Pipeline) Load(r io.Reader) error {
+	var version [1]byte
+	if _, err := io.ReadFull(r, version[:]); err != nil {
+		return fmt.Errorf("Failed to read pipeline caused by %w", err)
+	}
+	if version[0] != pipelineEncodingVersion {
+		return fmt.Errorf("Failed to decode pipeline: unsupported encoding version %d", version[0])
+	}
+
+	var manifest pipelineManifest
+	if err := gob.NewDecoder(r).Decode(&manifest); err != nil {
+		return fmt.Errorf("Failed to read pipeline caused by %w", err)
+	}
+	if len(manifest.Stages) == 0 {
+		return fmt.Errorf("Failed to decode pipeline: no stages")
+	}
+
+	var vectoriser Vectoriser
+	transformers := make([]Transformer, 0, len(manifest.Stages)-1)
+	for i, s := range manifest.Stages {
+		newStage, ok := pipelineStages[s.Type]
+		if !ok {
+			return fmt.Errorf("Failed to decode pipeline: unsupported stage %d type '%s'", i, s.Type)
+		}
+		stage := newStage()
+		if err := stage.(encoding.BinaryUnmarshaler).UnmarshalBinary(s.Data); err != nil {
+			return fmt.Errorf("Failed to decode pipeline stage %d (%s) caused by %w", i, s.Type, err)
+		}
+
+		if i == 0 {
+			v, ok := stage.(Vectoriser)
+			if !ok {
+				return fmt.Errorf("Failed to decode pipeline: first stage (%s) is not a vectoriser", s.Type)
+			}
+			vectoriser = v
+			continue
+		}
+		t, ok := stage.(Transformer)
+		if !ok {
+			return fmt.Errorf("Failed to decode pipeline: stage %d (%s) is not a transformer", i, s.Type)
+		}
+		transformers = append(transformers, t)
+	}
+
+	p.Vectoriser = vectoriser
+	p.Transformers = transformers
+	return nil
+}
+
+// stages returns all of the stages of the pipeline in order, starting with the vectoriser
+func (p *Pipeline) stages() []interface{} {
+	stages := make([]interface{}, 0, len(p.Transformers)+1)
+	stages = append(stages, p.Vectoriser)
+	for _, t := range p.Transformers {
+		stages = append(stages, t)
+	}
+	return stages
+}
+
+// stageName returns the name of the (pointer) type of the supplied pipeline stage
+func stageName(stage interface{}) string {
+	t := reflect.TypeOf(stage)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// CachedPipeline wraps a Pipeline, caching the fitted state of its stages within a directory
+// so that repeatedly fitting the same pipeline to the same training documents (e.g. across
+// runs during experimentation) loads the previously fitted state rather than refitting.
+// The cache key is derived from the training documents and the types of the stages.  Only
+// stages implementing both encoding.BinaryMarshaler and encoding.BinaryUnmarshaler (e.g.
+// TfidfTransformer) are cached, other stages are refitted on each call to Fit() (which is
+// inexpensive for stages requiring no fitting such as Normaliser).  Changes to the
+// configuration of the stages are not reflected in the cache key and so the cache directory
+// should be cleared following such changes.
+type CachedPipeline struct {
+	Pipeline *Pipeline
+
+	// Dir is the directory in which the fitted state of the stages is cached.  It is created
+	// when first required.
+	Dir string
+}
+
+// NewCachedPipeline wraps the supplied pipeline, caching the fitted state of its stages
+// within the directory dir.
+func NewCachedPipeline(p *Pipeline, dir string) *CachedPipeline {
+	return &CachedPipeline{Pipeline: p, Dir: dir}
+}
+
+// Fit fits each stage of the pipeline to the supplied training documents in the same way as
+// Pipeline.Fit() except that stages previously fitted to the same documents are loaded from
+// the cache rather than refitted.  Newly fitted stages are saved to the cache.  If all of the
+// stages are loaded from the cache, the documents are not processed at all.  Cached state
+// that can no longer be decoded is treated as absent and replaced.  An error is returned if
+// any stage fails or the cache can not be read or written.
+func (c *CachedPipeline) Fit(docs ...string) error {
+	stages := c.Pipeline.stages()
+	key := c.key(docs, stages)
+
+	loaded := make([]bool, len(stages))
+	all := true
+	for i, stage := range stages {
+		ok, err := c.load(key, i, stage)
+		if err != nil {
+			return err
+		}
+		loaded[i] = ok
+		all = all && ok
+	}
+	if all {
+		return nil
+	}
+
+	var mat *mat64.Dense
+	var err error
+	if loaded[0] {
+		mat, err = c.Pipeline.Vectoriser.Transform(docs...)
+	} else {
+		mat, err = c.Pipeline.Vectoriser.FitTransform(docs...)
+	}
+	if err != nil {
+		return stageError(0, c.Pipeline.Vectoriser, err)
+	}
+	if !loaded[0] {
+		if err := c.save(key, 0, c.Pipeline.Vectoriser); err != nil {
+			return err
+		}
+	}
+
+	for i, t := range c.Pipeline.Transformers {
+		if loaded[i+1] {
+			mat, err = t.Transform(mat)
+		} else {
+			mat, err = t.FitTransform(mat)
+		}
+		if err != nil {
+			return stageError(i+1, t, err)
+		}
+		if !loaded[i+1] {
+			if err := c.save(key, i+1, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Transform transforms the supplied documents, applying each of the previously fitted (or
+// loaded) stages of the pipeline in turn, in the same way as Pipeline.Transform().
+func (c *CachedPipeline) Transform(docs ...string) (*mat64.Dense, error) {
+	return c.Pipeline.Transform(docs...)
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same documents.
+func (c *CachedPipeline) FitTransform(docs ...string) (*mat64.Dense, error) {
+	if err := c.Fit(docs...); err != nil {
+		return nil, err
+	}
+	return c.Transform(docs...)
+}
+
+// key returns the cache key for fitting the supplied stages to the supplied documents
+func (c *CachedPipeline) key(docs []string, stages []interface{}) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, stage := range stages {
+		fmt.Fprintf(h, "%T\n", stage)
+	}
+	for _, doc := range docs {
+		binary.LittleEndian.PutUint64(n[:], uint64(len(doc)))
+		h.Write(n[:])
+		h.Write([]byte(doc))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path returns the path of the cache file for stage i
+func (c *CachedPipeline) path(key string, i int) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%s-%d.bin", key, i))
+}
+
+// load restores the state of stage i from the cache, returning false if the stage can not be
+// cached or no (decodable) cached state exists.
+func (c *CachedPipeline) load(key string, i int, stage interface{}) (bool, error) {
+	u, ok := stage.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return false, nil
+	}
+	if _, ok := stage.(encoding.BinaryMarshaler); !ok {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(c.path(key, i))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Failed to read cached pipeline stage %d (%T) caused by %w", i, stage, err)
+	}
+	return u.UnmarshalBinary(data) == nil, nil
+}
+
+// save writes the state of stage i to the cache if the stage can be cached
+func (c *CachedPipeline) save(key string, i int, stage interface{}) error {
+	m, ok := stage.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil
+	}
+	if _, ok := stage.(encoding.BinaryUnmarshaler); !ok {
+		return nil
+	}
+
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("Failed to encode pipeline stage %d (%T) caused by %w", i, stage, err)
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("Failed to create pipeline cache directory caused by %w", err)
+	}
+	if err := os.WriteFile(c.path(key, i), data, 0644); err != nil {
+		return fmt.Errorf("Failed to write cached pipeline stage %d (%T) caused by %w", i, stage, err)
+	}
+	return nil
+}
+
+// ComposedTransformer applies 2 transformers in sequence as a single Transformer, with the
+// second transformer applied to the output of the first.  It is a lightweight alternative to
+// Pipeline where only 2 stages are required or where a pair of transformers should be
+// treated as a single stage e.g. within a Pipeline or passed to a function accepting a
+// Transformer.  ComposedTransformers may themselves be composed to chain further stages.
+type ComposedTransformer struct {
+	First  Transformer
+	Second Transformer
+}
+
+// Compose creates a new ComposedTransformer applying first and then second.
+func Compose(first, second Transformer) Transformer {
+	return &ComposedTransformer{First: first, Second: second}
+}
+
+// Fit fits the first transformer to the supplied matrix and then fits the second
+// transformer to the output of the first.  If the first transformer fails to transform the
+// matrix, the second transformer is not fitted and FitTransform() should be used to obtain
+// the error.
+func (c *ComposedTransformer) Fit(mat mat64.Matrix) Transformer {
+	c.FitTransform(mat)
+	return c
+}
+
+// Transform applies the first transformer to the supplied matrix followed by the second
+// transformer to the output of the first.  If either transformer fails, the error is
+// returned identifying the failed transformer.
+func (c *ComposedTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	intermediate, err := c.First.Transform(mat)
+	if err != nil {
+		return nil, composeError(1, c.First, err)
+	}
+	result, err := c.Second.Transform(intermediate)
+	if err != nil {
+		return nil, composeError(2, c.Second, err)
+	}
+	return result, nil
+}
+
+// FitTransform fits the first transformer and applies it to the supplied matrix and then
+// fits and applies the second transformer to the output of the first.  If either
+// transformer fails, the error is returned identifying the failed transformer.
+func (c *ComposedTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	intermediate, err := c.First.FitTransform(mat)
+	if err != nil {
+		return nil, composeError(1, c.First, err)
+	}
+	result, err := c.Second.FitTransform(intermediate)
+	if err != nil {
+		return nil, composeError(2, c.Second, err)
+	}
+	return result, nil
+}
+
+// Clone returns a new ComposedTransformer composing clones of the composed transformers
+// (or the same transformers where they do not implement Cloner).
+func (c *ComposedTransformer) Clone() Transformer {
+	clone := func(t Transformer) Transformer {
+		if cloner, ok := t.(Cloner); ok {
+			return cloner.Clone()
+		}
+		return t
+	}
+	return Compose(clone(c.First), clone(c.Second))
+}
+
+func composeError(i int, t Transformer, err error) error {
+	return fmt.Errorf("Failed composed transformer %d (%T) caused by %w", i, t, err)
+}
+
+// FrozenTransformer wraps a fitted Transformer to prevent it from being accidentally refitted
+// e.g. when a transformer loaded for serving is included in a Pipeline that is later
+// refitted.  Transform() is delegated to the wrapped transformer while Fit() does nothing
+// so refitting a Pipeline (or a transformer built with Compose()) refits the other stages
+// but leaves frozen stages unchanged.
+type FrozenTransformer struct {
+	Transformer Transformer
+}
+
+// Freeze wraps the supplied (fitted) transformer in a FrozenTransformer.
+func Freeze(t Transformer) *FrozenTransformer {
+	return &FrozenTransformer{Transformer: t}
+}
+
+// Fit does nothing as the wrapped transformer is frozen.  It is provided to implement the
+// Transformer interface.
+func (f *FrozenTransformer) Fit(mat mat64.Matrix) Transformer {
+	return f
+}
+
+// Transform applies the wrapped transformer to the supplied matrix.
+func (f *FrozenTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return f.Transformer.Transform(mat)
+}
+
+// FitTransform applies the wrapped transformer to the supplied matrix without refitting
+// it, equivalent to calling Fit() (which does nothing) followed by Transform().
+func (f *FrozenTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return f.Transformer.Transform(mat)
+}
+
+// Clone returns a new FrozenTransformer wrapping a clone of the wrapped transformer or, if the
+// wrapped transformer does not implement Cloner, the same (frozen and so unmodified)
+// transformer.
+func (f *FrozenTransformer) Clone() Transformer {
+	if c, ok := f.Transformer.(Cloner); ok {
+		return Freeze(c.Clone())
+	}
+	return Freeze(f.Transformer)
+}
+
+// Orientation specifies the layout of the matrices accepted and returned by an
+// OrientedTransformer.
+type Orientation int
+
+const (
+	// TermsInRows is the layout used throughout this package where each row of a matrix
+	// represents a term (or feature) and each column a document.
+	TermsInRows Orientation = iota
+
+	// DocsInRows is the transposed layout, used by many other libraries (e.g. scikit-learn),
+	// where each row of a matrix represents a document and each column a term (or feature).
+	DocsInRows
+)
+
+// OrientedTransformer wraps a Transformer so that it may be fitted to and applied to
+// matrices of either Orientation without the caller transposing them.  With DocsInRows,
+// the supplied matrices are transposed before being passed to the wrapped transformer (so
+// that e.g. document frequencies are counted along the correct axis) and the output of the
+// wrapped transformer is transposed so that documents remain as rows.  With TermsInRows
+// (the default) all calls are passed through unchanged.  The result is identical to
+// transposing the matrices manually.  *SparseMatrix inputs are transposed into new sparse
+// matrices so that the wrapped transformer may still take advantage of their sparsity.
+type OrientedTransformer struct {
+	Transformer Transformer
+	Orientation Orientation
+}
+
+// Orient wraps the supplied transformer in an OrientedTransformer accepting and returning
+// matrices with the specified orientation.
+func Orient(t Transformer, orientation Orientation) *OrientedTransformer {
+	return &OrientedTransformer{Transformer: t, Orientation: orientation}
+}
+
+// Fit fits the wrapped transformer to the supplied training data matrix, transposing it
+// first if the Orientation is DocsInRows.
+func (o *OrientedTransformer) Fit(mat mat64.Matrix) Transformer {
+	o.Transformer.Fit(o.in(mat))
+	return o
+}
+
+// Transform applies the wrapped transformer to the supplied matrix, with the input and
+// output matrices transposed if the Orientation is DocsInRows.
+func (o *OrientedTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	result, err := o.Transformer.Transform(o.in(mat))
+	if err != nil {
+		return nil, err
+	}
+	return o.out(result), nil
+}
+
+// FitTransform fits the wrapped transformer to, and applies it to, the supplied matrix with
+// the input and output matrices transposed if the Orientation is DocsInRows.
+func (o *OrientedTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	result, err := o.Transformer.FitTransform(o.in(mat))
+	if err != nil {
+		return nil, err
+	}
+	return o.out(result), nil
+}
+
+// Clone returns a new OrientedTransformer with the same Orientation wrapping a clone of the
+// wrapped transformer (or the same transformer if it does not implement Cloner).
+func (o *OrientedTransformer) Clone() Transformer {
+	if c, ok := o.Transformer.(Cloner); ok {
+		return Orient(c.Clone(), o.Orientation)
+	}
+	return Orient(o.Transformer, o.Orientation)
+}
+
+// in returns the supplied matrix in the TermsInRows orientation expected by the wrapped
+// transformer
+func (o *OrientedTransformer) in(mat mat64.Matrix) mat64.Matrix {
+	if o.Orientation != DocsInRows {
+		return mat
+	}
+	if s, ok := mat.(*SparseMatrix); ok {
+		return s.transpose()
+	}
+	return mat.T()
+}
+
+// out returns the supplied output of the wrapped transformer in the configured orientation
+func (o *OrientedTransformer) out(mat *mat64.Dense) *mat64.Dense {
+	if o.Orientation != DocsInRows {
+		return mat
+	}
+	return mat64.DenseCopyOf(mat.T())
+}
+
+func stageError(i int, stage interface{}, err error) error {
+	return fmt.Errorf("Failed pipeline stage %d (%T) caused by %w", i, stage, err)
+}
@@ -0,0 +1,587 @@
+package nlp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+type failingTransformer struct {
+	err error
+}
+
+func (f *failingTransformer) Fit(mat mat64.Matrix) Transformer {
+	return f
+}
+
+func (f *failingTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return nil, f.err
+}
+
+func (f *failingTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return nil, f.err
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline := NewPipeline(NewCountVectoriser(true), NewTfidfTransformer(), NewTruncatedSVD(2))
+
+	result, err := pipeline.FitTransform(trainSet...)
+	if err != nil {
+		t.Fatalf("Failed pipeline fit transform caused by %v", err)
+	}
+
+	// manually chain the same stages for comparison
+	vectoriser := NewCountVectoriser(true)
+	transformer := NewTfidfTransformer()
+	reducer := NewTruncatedSVD(2)
+
+	mat, _ := vectoriser.FitTransform(trainSet...)
+	mat, _ = transformer.FitTransform(mat)
+	expected, _ := reducer.FitTransform(mat)
+
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	result, err = pipeline.Transform(testSet...)
+	if err != nil {
+		t.Fatalf("Failed pipeline transform caused by %v", err)
+	}
+
+	mat, _ = vectoriser.Transform(testSet...)
+	mat, _ = transformer.Transform(mat)
+	expected, _ = reducer.Transform(mat)
+
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	resultT, err := pipeline.TransformT(testSet...)
+	if err != nil {
+		t.Fatalf("Failed pipeline transposed transform caused by %v", err)
+	}
+	if !mat64.Equal(result.T(), resultT) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(result.T()),
+			mat64.Formatted(resultT))
+		t.Fail()
+	}
+
+	for j, doc := range testSet {
+		vec, err := pipeline.TransformOne(doc)
+		if err != nil {
+			t.Fatalf("Failed pipeline single document transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(result.ColView(j), vec, 0.000001) {
+			t.Errorf("Expected single document transform of document %d to equal column %d of batch result", j, j)
+		}
+	}
+
+	refit := NewPipeline(NewCountVectoriser(true), NewTfidfTransformer(), NewTruncatedSVD(2))
+	if err := refit.Fit(trainSet...); err != nil {
+		t.Fatalf("Failed pipeline fit caused by %v", err)
+	}
+	result2, _ := refit.Transform(testSet...)
+	if !mat64.EqualApprox(result, result2, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(result),
+			mat64.Formatted(result2))
+		t.Fail()
+	}
+}
+
+func TestPipelineTransformPooled(t *testing.T) {
+	vectoriser := NewCountVectoriser(true)
+	transformers := []Transformer{NewTfidfTransformer(), NewNormaliser(L2Norm), NewTruncatedSVD(2)}
+	pipeline := NewPipeline(vectoriser, transformers...)
+	if err := pipeline.Fit(trainSet...); err != nil {
+		t.Fatalf("Failed pipeline fit caused by %v", err)
+	}
+
+	// the vectoriser and tf-idf outputs are intermediate and so should be pooled
+	for stage, expected := range []bool{true, true, false, false} {
+		if pooled := pipeline.pooled(stage); pooled != expected {
+			t.Errorf("Expected pooling of stage %d output to be %t but found %t", stage, expected, pooled)
+		}
+	}
+
+	// an equivalent pipeline without pooling
+	unpooled := &Pipeline{Vectoriser: vectoriser, Transformers: transformers}
+	inputs := [][]string{testSet, trainSet, testSet[:1], trainSet[2:5]}
+	expected := make([]*mat64.Dense, len(inputs))
+	for i, docs := range inputs {
+		var err error
+		if expected[i], err = unpooled.Transform(docs...); err != nil {
+			t.Fatalf("Failed pipeline transform caused by %v", err)
+		}
+	}
+
+	first, err := pipeline.Transform(testSet...)
+	if err != nil {
+		t.Fatalf("Failed pipeline transform caused by %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				i := (g + n) % len(inputs)
+				result, err := pipeline.Transform(inputs[i]...)
+				if err != nil {
+					t.Errorf("Failed pipeline transform caused by %v", err)
+					return
+				}
+				if !mat64.Equal(expected[i], result) {
+					t.Errorf("Expected matrix: \n%v\n but found: \n%v\n",
+						mat64.Formatted(expected[i]),
+						mat64.Formatted(result))
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// outputs of earlier calls are owned by the caller and so unaffected by later calls
+	if !mat64.Equal(expected[0], first) {
+		t.Errorf("Expected earlier output to be unchanged but found: \n%v\n", mat64.Formatted(first))
+	}
+
+	// buffers are returned to the pool when a later stage fails
+	failing := NewPipeline(vectoriser, NewTfidfTransformer().Fit(mat64.NewDense(2, 1, []float64{1, 1})), NewNormaliser(L2Norm))
+	for i := 0; i < 2; i++ {
+		if _, err := failing.Transform(testSet...); !errors.Is(err, ErrDimensionMismatch) {
+			t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+		}
+	}
+}
+
+func TestPipelineStageError(t *testing.T) {
+	stageErr := errors.New("stage failed")
+	pipeline := NewPipeline(NewCountVectoriser(false), NewTfidfTransformer(), &failingTransformer{err: stageErr}, NewTruncatedSVD(2))
+
+	_, err := pipeline.FitTransform(trainSet...)
+	if !errors.Is(err, stageErr) {
+		t.Errorf("Expected error caused by '%v' but found '%v'", stageErr, err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "stage 2") {
+		t.Errorf("Expected error to identify the failed stage but found '%v'", err)
+	}
+
+	_, err = pipeline.Transform(testSet...)
+	if !errors.Is(err, stageErr) {
+		t.Errorf("Expected error caused by '%v' but found '%v'", stageErr, err)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	mat := mat64.NewDense(4, 3, []float64{
+		1, 0, 2,
+		0, 3, 1,
+		1, 1, 0,
+		0, 0, 4,
+	})
+	test := mat64.NewDense(4, 2, []float64{
+		2, 0,
+		1, 1,
+		0, 3,
+		1, 0,
+	})
+
+	// manually chained
+	tfidf := NewTfidfTransformer()
+	weighted, _ := tfidf.FitTransform(mat)
+	normaliser := NewNormaliser(L2Norm)
+	expectedFit, _ := normaliser.FitTransform(weighted)
+	weighted, _ = tfidf.Transform(test)
+	expected, _ := normaliser.Transform(weighted)
+
+	composed := Compose(NewTfidfTransformer(), NewNormaliser(L2Norm))
+	fitted, err := composed.FitTransform(mat)
+	if err != nil {
+		t.Fatalf("Failed to fit composed transformer caused by %v", err)
+	}
+	if !mat64.EqualApprox(expectedFit, fitted, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expectedFit),
+			mat64.Formatted(fitted))
+		t.Fail()
+	}
+
+	result, err := composed.Fit(mat).Transform(test)
+	if err != nil {
+		t.Fatalf("Failed to transform with composed transformer caused by %v", err)
+	}
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	// errors identify the failing transformer
+	failure := errors.New("failed")
+	composed = Compose(NewTfidfTransformer(), &failingTransformer{err: failure})
+	if _, err := composed.FitTransform(mat); !errors.Is(err, failure) || !strings.Contains(err.Error(), "transformer 2") {
+		t.Errorf("Expected error identifying the second transformer but found '%v'", err)
+	}
+	if _, err := Compose(NewTfidfTransformer(), NewNormaliser(L2Norm)).Transform(mat); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+}
+
+// countingTransformer wraps a TfidfTransformer, counting the number of times it is fitted
+type countingTransformer struct {
+	*TfidfTransformer
+	fits int
+}
+
+func (c *countingTransformer) Fit(mat mat64.Matrix) Transformer {
+	c.fits++
+	c.TfidfTransformer.Fit(mat)
+	return c
+}
+
+func (c *countingTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return c.Fit(mat).Transform(mat)
+}
+
+func TestCachedPipeline(t *testing.T) {
+	dir := t.TempDir()
+
+	newPipeline := func() (*CachedPipeline, *countingTransformer) {
+		counter := &countingTransformer{TfidfTransformer: NewTfidfTransformer()}
+		return NewCachedPipeline(NewPipeline(NewHashingVectoriser(64), counter), dir), counter
+	}
+
+	pipeline, counter := newPipeline()
+	expected, err := pipeline.FitTransform(trainSet...)
+	if err != nil {
+		t.Fatalf("Failed to fit pipeline caused by %v", err)
+	}
+	if counter.fits != 1 {
+		t.Errorf("Expected transformer to be fitted once but was fitted %d times", counter.fits)
+	}
+
+	// a new pipeline fitted to the same documents should load the fitted state from the cache
+	pipeline, counter = newPipeline()
+	if err := pipeline.Fit(trainSet...); err != nil {
+		t.Fatalf("Failed to fit pipeline caused by %v", err)
+	}
+	if counter.fits != 0 {
+		t.Errorf("Expected transformer to be loaded from the cache but was fitted %d times", counter.fits)
+	}
+	result, err := pipeline.Transform(trainSet...)
+	if err != nil {
+		t.Fatalf("Failed to transform documents caused by %v", err)
+	}
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	// different training documents should miss the cache
+	if err := pipeline.Fit(testSet...); err != nil {
+		t.Fatalf("Failed to fit pipeline caused by %v", err)
+	}
+	if counter.fits != 1 {
+		t.Errorf("Expected transformer to be fitted for new documents but was fitted %d times", counter.fits)
+	}
+}
+
+func TestPipelineFitTransformBatched(t *testing.T) {
+	newPipeline := func() *Pipeline {
+		tfidf := NewTfidfTransformer()
+		tfidf.Norm = L2Norm
+		return NewPipeline(NewCountVectoriser(true), tfidf, NewTfidfTransformer())
+	}
+
+	expected, err := newPipeline().FitTransform(trainSet...)
+	if err != nil {
+		t.Fatalf("Failed to fit transform documents caused by %v", err)
+	}
+	rows, cols := expected.Dims()
+
+	for _, batchSize := range []int{1, 2, 3, len(trainSet), len(trainSet) + 1} {
+		batches, err := newPipeline().FitTransformBatched(trainSet, batchSize)
+		if err != nil {
+			t.Fatalf("Failed to fit documents in batches of %d caused by %v", batchSize, err)
+		}
+
+		var offset int
+		for {
+			batch, err := batches.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to transform batch caused by %v", err)
+			}
+			r, c := batch.Dims()
+			if r != rows || c > batchSize || offset+c > cols {
+				t.Fatalf("Unexpected %d x %d batch at offset %d for batches of %d", r, c, offset, batchSize)
+			}
+			if !mat64.EqualApprox(expected.Slice(0, rows, offset, offset+c), batch, 0.000001) {
+				t.Errorf("Expected batch at offset %d for batches of %d to match the unbatched output", offset, batchSize)
+			}
+			offset += c
+		}
+		if offset != cols {
+			t.Errorf("Expected %d documents across all batches of %d but found %d", cols, batchSize, offset)
+		}
+	}
+
+	if _, err := newPipeline().FitTransformBatched(trainSet, 0); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+	unsupported := NewPipeline(NewCountVectoriser(true), NewTruncatedSVD(2))
+	if _, err := unsupported.FitTransformBatched(trainSet, 2); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+	if _, err := newPipeline().FitTransformBatched(nil, 2); !errors.Is(err, ErrEmptyCorpus) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrEmptyCorpus, err)
+	}
+}
+
+func TestPipelineSaveLoad(t *testing.T) {
+	vectoriser := NewCountVectoriser(true)
+	vectoriser.MaxNGram = 2
+	tfidf := NewTfidfTransformer()
+	tfidf.Norm = L2Norm
+	pipeline := NewPipeline(vectoriser, tfidf, NewTruncatedSVD(2))
+	if err := pipeline.Fit(trainSet...); err != nil {
+		t.Fatalf("Failed to fit pipeline caused by %v", err)
+	}
+	expected, err := pipeline.Transform(testSet...)
+	if err != nil {
+		t.Fatalf("Failed to transform documents caused by %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pipeline.Save(&buf); err != nil {
+		t.Fatalf("Failed to save pipeline caused by %v", err)
+	}
+	data := buf.Bytes()
+
+	var loaded Pipeline
+	if err := loaded.Load(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Failed to load pipeline caused by %v", err)
+	}
+	if _, ok := loaded.Vectoriser.(*CountVectoriser); !ok {
+		t.Errorf("Expected vectoriser of type *CountVectoriser but found %T", loaded.Vectoriser)
+	}
+	if len(loaded.Transformers) != 2 {
+		t.Fatalf("Expected 2 transformers but found %d", len(loaded.Transformers))
+	}
+	if _, ok := loaded.Transformers[0].(*TfidfTransformer); !ok {
+		t.Errorf("Expected transformer of type *TfidfTransformer but found %T", loaded.Transformers[0])
+	}
+	if _, ok := loaded.Transformers[1].(*TruncatedSVD); !ok {
+		t.Errorf("Expected transformer of type *TruncatedSVD but found %T", loaded.Transformers[1])
+	}
+
+	result, err := loaded.Transform(testSet...)
+	if err != nil {
+		t.Fatalf("Failed to transform documents with loaded pipeline caused by %v", err)
+	}
+	if !mat64.Equal(expected, result) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	// stages that can not be saved are rejected
+	unsupported := NewPipeline(NewHashingVectoriser(64), NewPCA(2))
+	if err := unsupported.Save(&bytes.Buffer{}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+
+	// corrupt data is rejected and leaves the pipeline unchanged
+	for _, corrupt := range [][]byte{nil, {0}, data[:len(data)/2]} {
+		if err := loaded.Load(bytes.NewReader(corrupt)); err == nil {
+			t.Errorf("Expected error loading corrupt pipeline but found none")
+		}
+	}
+	if _, err := loaded.Transform(testSet...); err != nil {
+		t.Errorf("Expected failed load to leave pipeline unchanged but found %v", err)
+	}
+}
+
+func TestOrientedTransformer(t *testing.T) {
+	train := mat64.NewDense(4, 5, []float64{
+		1, 0, 2, 0, 1,
+		0, 3, 1, 0, 0,
+		2, 1, 0, 1, 4,
+		0, 0, 1, 2, 1,
+	})
+	test := mat64.NewDense(4, 2, []float64{
+		5, 0,
+		0, 1,
+		1, 1,
+		0, 2,
+	})
+
+	tfidf := func() Transformer {
+		tfidf := NewTfidfTransformer()
+		tfidf.Norm = L2Norm
+		return tfidf
+	}
+	var tests = []func() Transformer{
+		tfidf,
+		func() Transformer { return NewBM25Transformer() },
+		func() Transformer { return NewNormaliser(L1Norm) },
+		func() Transformer { return NewTruncatedSVD(2) },
+		func() Transformer { return Compose(tfidf(), NewTruncatedSVD(2)) },
+	}
+
+	for _, newTransformer := range tests {
+		reference := newTransformer()
+		expectedFit, err := reference.FitTransform(train)
+		if err != nil {
+			t.Fatalf("%T: Failed to fit transform matrix caused by %v", reference, err)
+		}
+		expected, _ := reference.Transform(test)
+
+		for _, input := range []mat64.Matrix{mat64.DenseCopyOf(train.T()), NewSparseMatrixFrom(train.T())} {
+			oriented := Orient(newTransformer(), DocsInRows)
+			resultFit, err := oriented.FitTransform(input)
+			if err != nil {
+				t.Errorf("%T: Failed to fit transform matrix caused by %v", reference, err)
+				continue
+			}
+			result, err := oriented.Transform(NewSparseMatrixFrom(test.T()))
+			if err != nil {
+				t.Errorf("%T: Failed to transform matrix caused by %v", reference, err)
+				continue
+			}
+
+			if !mat64.EqualApprox(expectedFit.T(), resultFit, 0.000001) || !mat64.EqualApprox(expected.T(), result, 0.000001) {
+				t.Logf("%T: Expected matrices: \n%v\n%v\n but found: \n%v\n%v\n", reference,
+					mat64.Formatted(expectedFit.T()), mat64.Formatted(expected.T()),
+					mat64.Formatted(resultFit), mat64.Formatted(result))
+				t.Fail()
+			}
+		}
+	}
+
+	// document frequencies are counted along the rows when DocsInRows
+	oriented := Orient(NewTfidfTransformer(), DocsInRows)
+	oriented.Fit(train.T())
+	reference := NewTfidfTransformer().Fit(train)
+	if !reflect.DeepEqual(reference.(*TfidfTransformer).Weights(), oriented.Transformer.(*TfidfTransformer).Weights()) {
+		t.Errorf("Expected weights %v but found %v", reference.(*TfidfTransformer).Weights(), oriented.Transformer.(*TfidfTransformer).Weights())
+	}
+
+	// TermsInRows passes matrices through unchanged
+	expected, _ := reference.Transform(test)
+	result, err := Orient(reference, TermsInRows).Transform(test)
+	if err != nil || !mat64.Equal(expected, result) {
+		t.Errorf("Expected TermsInRows to be equivalent to the wrapped transformer but found %v", err)
+	}
+
+	if _, err := oriented.Transform(test); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+}
+
+func TestFrozenTransformer(t *testing.T) {
+	vectoriser := NewCountVectoriser(true)
+	mat, _ := vectoriser.FitTransform(trainSet...)
+	test, _ := vectoriser.Transform(testSet...)
+
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	expected, _ := transformer.Transform(test)
+
+	frozen := Freeze(transformer)
+	var _ Transformer = frozen
+
+	// refitting on different data should have no effect
+	if frozen.Fit(test) != frozen {
+		t.Errorf("Expected Fit() to return the frozen transformer")
+	}
+	result, err := frozen.Transform(test)
+	if err != nil {
+		t.Fatalf("Failed frozen transform caused by %v", err)
+	}
+	if !mat64.Equal(expected, result) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	// fit transforming should transform without refitting
+	if result, err := frozen.FitTransform(test); err != nil || !mat64.Equal(expected, result) {
+		t.Errorf("Expected frozen fit transform to match transform but found error %v", err)
+	}
+
+	// refitting a pipeline containing a frozen stage should refit only the other stages
+	pipeline := NewPipeline(NewCountVectoriser(true), frozen)
+	if err := pipeline.Fit(trainSet...); err != nil {
+		t.Fatalf("Failed to fit pipeline with frozen stage caused by %v", err)
+	}
+	if result, err := pipeline.Transform(testSet...); err != nil || !mat64.Equal(expected, result) {
+		t.Errorf("Expected pipeline with frozen stage to transform but found error %v", err)
+	}
+	normalised, _ := NewNormaliser(L1Norm).Transform(expected)
+	composed := Compose(frozen, NewNormaliser(L1Norm))
+	if result, err := composed.FitTransform(test); err != nil || !mat64.Equal(normalised, result) {
+		t.Errorf("Expected composed frozen stage to transform but found error %v", err)
+	}
+}
+
+func BenchmarkPipelineTransform(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	docs := make([]string, 500)
+	for i := range docs {
+		words := make([]string, 50)
+		for j := range words {
+			words[j] = fmt.Sprintf("w%d", rnd.Intn(2000))
+		}
+		docs[i] = strings.Join(words, " ")
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	transformers := []Transformer{NewTfidfTransformer(), NewTruncatedSVD(50)}
+	pipeline := NewPipeline(vectoriser, transformers...)
+	if err := pipeline.Fit(docs...); err != nil {
+		b.Fatalf("Failed pipeline fit caused by %v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		pipeline *Pipeline
+	}{
+		{"Pooled", pipeline},
+		{"Unpooled", &Pipeline{Vectoriser: vectoriser, Transformers: transformers}},
+	}
+
+	for _, test := range tests {
+		b.Run(test.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				test.pipeline.Transform(docs[:100]...)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// StandardScaler standardises the features (rows) of dense feature matrices (e.g. document
+// embeddings or the output of TruncatedSVD) to zero mean and unit variance across the
+// documents (columns) i.e. each value x of feature i is transformed to (x - mean[i]) / std[i]
+// where mean[i] and std[i] are the mean and (population) standard deviation of the feature
+// within the training data.  Standardisation is often required by downstream linear models
+// and distance based algorithms which are otherwise dominated by the features with the
+// largest magnitudes.  Features with zero variance within the training data (e.g. constant
+// features) are left unchanged.  As centring destroys sparsity, StandardScaler is not
+// suitable for term document matrices.
+type StandardScaler struct {
+	mean []float64
+	std  []float64
+}
+
+// NewStandardScaler creates a new StandardScaler.
+func NewStandardScaler() *StandardScaler {
+	return &StandardScaler{}
+}
+
+// Fit calculates the mean and standard deviation of each feature (row) of the supplied
+// training data matrix across its documents (columns) for use in subsequent calls to
+// Transform().  If the matrix contains no rows or columns the transformer is left unfitted
+// and FitTransform() returns ErrEmptyVocabulary or ErrEmptyCorpus respectively.
+func (s *StandardScaler) Fit(mat mat64.Matrix) Transformer {
+	s.mean = nil
+	s.std = nil
+
+	m, n := mat.Dims()
+	if checkCorpus(m, n) != nil {
+		return s
+	}
+
+	s.mean = make([]float64, m)
+	s.std = make([]float64, m)
+	for i := 0; i < m; i++ {
+		var sum float64
+		for j := 0; j < n; j++ {
+			sum += mat.At(i, j)
+		}
+		mean := sum / float64(n)
+
+		var squares float64
+		for j := 0; j < n; j++ {
+			d := mat.At(i, j) - mean
+			squares += d * d
+		}
+		s.mean[i] = mean
+		s.std[i] = math.Sqrt(squares / float64(n))
+	}
+	return s
+}
+
+// Transform standardises each feature (row) of the supplied matrix using the mean and
+// standard deviation calculated during Fit() from the training data (rather than from the
+// supplied matrix) so that new documents are scaled consistently with the training data.
+// An error is returned if the transformer has not been fitted or the number of rows in the
+// matrix differs from the training data matrix.
+func (s *StandardScaler) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if s.mean == nil {
+		return nil, fmt.Errorf("%w: StandardScaler", ErrNotFitted)
+	}
+	if m, _ := mat.Dims(); m != len(s.mean) {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d", ErrDimensionMismatch, m, len(s.mean))
+	}
+
+	scaled := mat64.DenseCopyOf(mat)
+	scaled.Apply(func(i, j int, v float64) float64 {
+		if s.std[i] == 0 {
+			return v
+		}
+		return (v - s.mean[i]) / s.std[i]
+	}, scaled)
+	return scaled, nil
+}
+
+// FitTransform is equivalent to calling Fit() followed by Transform() on the same matrix.
+// An error is returned if the matrix is empty.
+func (s *StandardScaler) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if err := checkCorpus(mat.Dims()); err != nil {
+		return nil, err
+	}
+	return s.Fit(mat).Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted means and standard
+// deviations.
+func (s *StandardScaler) Clone() Transformer {
+	return &StandardScaler{
+		mean: copyFloats(s.mean),
+		std:  copyFloats(s.std),
+	}
+}
+
+// Mean returns a copy of the mean of each feature (row) of the training data.  Mean returns
+// nil if the transformer has not been fitted.
+func (s *StandardScaler) Mean() []float64 {
+	return copyFloats(s.mean)
+}
+
+// Std returns a copy of the (population) standard deviation of each feature (row) of the
+// training data.  Std returns nil if the transformer has not been fitted.
+func (s *StandardScaler) Std() []float64 {
+	return copyFloats(s.std)
+}
@@ -0,0 +1,75 @@
+package nlp
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestStandardScaler(t *testing.T) {
+	// the last feature is constant and so has zero variance
+	train := mat64.NewDense(3, 5, []float64{
+		1, 2, 3, 4, 5,
+		-10, 0, 10, 40, 5,
+		7, 7, 7, 7, 7,
+	})
+
+	scaler := NewStandardScaler()
+	result, err := scaler.FitTransform(train)
+	if err != nil {
+		t.Fatalf("Failed to transform matrix caused by %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		row := mat64.Row(nil, i, result)
+		var mean, variance float64
+		for _, v := range row {
+			mean += v
+		}
+		mean /= float64(len(row))
+		for _, v := range row {
+			variance += (v - mean) * (v - mean)
+		}
+		variance /= float64(len(row))
+
+		if math.Abs(mean) > 0.000001 || math.Abs(variance-1) > 0.000001 {
+			t.Errorf("Expected feature %d to have zero mean and unit variance but found %f and %f", i, mean, variance)
+		}
+	}
+	for j := 0; j < 5; j++ {
+		if result.At(2, j) != 7 {
+			t.Errorf("Expected zero variance feature to be unchanged but found %f", result.At(2, j))
+		}
+	}
+
+	// new data is scaled with the training statistics
+	test := mat64.NewDense(3, 2, []float64{
+		3, 3 + math.Sqrt2,
+		9, 9,
+		0, 1,
+	})
+	expected := mat64.NewDense(3, 2, []float64{
+		0, 1,
+		0, 0,
+		0, 1,
+	})
+	result, err = scaler.Transform(test)
+	if err != nil {
+		t.Fatalf("Failed to transform matrix caused by %v", err)
+	}
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	if _, err := scaler.Transform(mat64.NewDense(2, 2, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+	if _, err := NewStandardScaler().Transform(test); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+}
@@ -0,0 +1,128 @@
+package nlp
+
+import (
+	"fmt"
+)
+
+// Result is a single document matching a query to a SearchIndex along with its relevance
+// score.
+type Result struct {
+	// DocID is the index of the document within the documents supplied to Index()
+	DocID int
+
+	// Score is the cosine similarity between the query and the document
+	Score float64
+}
+
+// SearchIndex is a simple search engine ranking documents by their relevance to free text
+// queries.  Documents are vectorised with Vectoriser and weighted with Transformer (TF-IDF)
+// when indexed and queries are vectorised and weighted in the same way before being
+// compared with the indexed documents using cosine similarity.  The Vectoriser and
+// Transformer may be configured (e.g. to extract n-grams or apply sublinear term
+// frequencies) before calling Index().
+type SearchIndex struct {
+	Vectoriser  *CountVectoriser
+	Transformer *TfidfTransformer
+
+	neighbours *NearestNeighbours
+	docs       int
+}
+
+// NewSearchIndex creates a new, empty, SearchIndex with English stop words removed from
+// documents and queries.
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		Vectoriser:  NewCountVectoriser(true),
+		Transformer: NewTfidfTransformer(),
+	}
+}
+
+// Index fits the Vectoriser and Transformer to the supplied documents and indexes them for
+// searching, replacing any previously indexed documents.  An error is returned if docs is
+// empty or the documents contain no terms (following stop word removal).
+func (s *SearchIndex) Index(docs []string) error {
+	if len(docs) == 0 {
+		return fmt.Errorf("%w: no documents to index", ErrInvalidArgument)
+	}
+	counts, err := s.Vectoriser.FitTransformSparse(docs...)
+	if err != nil {
+		return fmt.Errorf("Failed to vectorise documents caused by %w", err)
+	}
+	weighted, err := s.Transformer.FitTransform(counts)
+	if err != nil {
+		return fmt.Errorf("Failed to weight documents caused by %w", err)
+	}
+
+	s.neighbours = NewNearestNeighbours(weighted)
+	s.docs = len(docs)
+	return nil
+}
+
+// Search returns up to k of the indexed documents most relevant to the supplied query in
+// descending order of relevance (documents with equal scores are ordered by DocID).  Only
+// documents sharing at least one term with the query are returned so an empty query, or a
+// query containing only terms that do not occur within the indexed documents, returns no
+// results.  An error is returned if no documents have been indexed or k is negative.
+func (s *SearchIndex) Search(query string, k int) ([]Result, error) {
+	if s.neighbours == nil {
+		return nil, fmt.Errorf("%w: SearchIndex has no indexed documents", ErrNotFitted)
+	}
+	if k < 0 {
+		return nil, fmt.Errorf("%w: k (%d) must not be negative", ErrInvalidArgument, k)
+	}
+
+	counts, err := s.Vectoriser.TransformOne(query)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to vectorise query caused by %w", err)
+	}
+	weighted, err := s.Transformer.Transform(counts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to weight query caused by %w", err)
+	}
+
+	docs, scores, err := s.neighbours.Query(weighted.ColView(0), min(k, s.docs))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(docs))
+	for i, doc := range docs {
+		if scores[i] <= 0 {
+			break
+		}
+		results = append(results, Result{DocID: doc, Score: scores[i]})
+	}
+	return results, nil
+}
+
+// Keywords returns up to k keywords of the supplied document, being the terms of the
+// document with the highest TF-IDF weights (as fitted by Index()), in descending order of
+// weight.  The document need not have been indexed.  Terms not occuring within the indexed
+// documents, or occuring in all of them, carry no weight and are not returned so fewer than
+// k keywords are returned if the document contains fewer than k such distinct terms.  An
+// error is returned if no documents have been indexed or k is negative.
+func (s *SearchIndex) Keywords(doc string, k int) ([]string, error) {
+	if s.neighbours == nil {
+		return nil, fmt.Errorf("%w: SearchIndex has no indexed documents", ErrNotFitted)
+	}
+	if k < 0 {
+		return nil, fmt.Errorf("%w: k (%d) must not be negative", ErrInvalidArgument, k)
+	}
+
+	counts, err := s.Vectoriser.TransformOne(doc)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to vectorise document caused by %w", err)
+	}
+
+	terms := make([]string, len(s.Vectoriser.Vocabulary))
+	for term, i := range s.Vectoriser.Vocabulary {
+		terms[i] = term
+	}
+
+	scores := s.Transformer.ExplainDocument(0, counts, terms, k)
+	keywords := make([]string, len(scores))
+	for i, score := range scores {
+		keywords[i] = score.Term
+	}
+	return keywords, nil
+}
@@ -0,0 +1,125 @@
+package nlp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSearchIndex(t *testing.T) {
+	docs := []string{
+		"The quick brown fox jumped over the lazy dog",
+		"Stock markets rallied as interest rates fell",
+		"A lazy afternoon spent reading in the garden",
+		"The central bank raised interest rates again",
+		"Foxes and dogs are common in the countryside",
+	}
+
+	index := NewSearchIndex()
+	if err := index.Index(docs); err != nil {
+		t.Fatalf("Failed to index documents caused by %v", err)
+	}
+
+	var tests = []struct {
+		query    string
+		k        int
+		expected []int
+	}{
+		{query: "interest rates", k: 2, expected: []int{3, 1}},
+		{query: "quick brown fox", k: 1, expected: []int{0}},
+		{query: "central bank", k: 5, expected: []int{3}},
+		{query: "lazy", k: 10, expected: []int{2, 0}},
+		{query: "interest rates", k: 0, expected: []int{}},
+		{query: "", k: 3, expected: []int{}},
+		{query: "the and", k: 3, expected: []int{}},
+		{query: "zebra giraffe", k: 3, expected: []int{}},
+	}
+
+	for _, test := range tests {
+		results, err := index.Search(test.query, test.k)
+		if err != nil {
+			t.Errorf("Query '%s': Failed to search caused by %v", test.query, err)
+			continue
+		}
+		if results == nil || len(results) != len(test.expected) {
+			t.Errorf("Query '%s': Expected %d results but found %v", test.query, len(test.expected), results)
+			continue
+		}
+		for i, result := range results {
+			if result.DocID != test.expected[i] {
+				t.Errorf("Query '%s': Expected document %d at rank %d but found %v", test.query, test.expected[i], i, results)
+			}
+			if result.Score <= 0 || result.Score > 1+1e-9 {
+				t.Errorf("Query '%s': Expected score in (0, 1] but found %f", test.query, result.Score)
+			}
+			if i > 0 && result.Score > results[i-1].Score {
+				t.Errorf("Query '%s': Expected results in descending order of score but found %v", test.query, results)
+			}
+		}
+	}
+}
+
+func TestSearchIndexKeywords(t *testing.T) {
+	docs := []string{
+		"The quick brown fox jumped over the lazy dog",
+		"Stock markets rallied as interest rates fell",
+		"A lazy afternoon spent reading in the garden",
+		"The central bank raised interest rates again",
+		"Foxes and dogs are common in the countryside",
+	}
+
+	index := NewSearchIndex()
+	if _, err := index.Keywords("interest rates", 1); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+	if err := index.Index(docs); err != nil {
+		t.Fatalf("Failed to index documents caused by %v", err)
+	}
+
+	var tests = []struct {
+		doc      string
+		k        int
+		expected []string
+	}{
+		// "rates" stands out by occuring 3 times, "zebra" is unknown and "the" is a stop word
+		{doc: "Rates, rates and more rates at the bank for the zebra", k: 1, expected: []string{"rates"}},
+		{doc: "Rates, rates and more rates at the bank for the zebra", k: 5, expected: []string{"rates", "bank"}},
+		{doc: "the lazy zebra", k: 3, expected: []string{"lazy"}},
+		{doc: "zebra", k: 3, expected: []string{}},
+		{doc: "interest rates", k: 0, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		keywords, err := index.Keywords(test.doc, test.k)
+		if err != nil {
+			t.Errorf("Failed to extract keywords from '%s' caused by %v", test.doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.expected, keywords) {
+			t.Errorf("Expected keywords %v for '%s' (k = %d) but found %v", test.expected, test.doc, test.k, keywords)
+		}
+	}
+
+	if _, err := index.Keywords("fox", -1); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' for negative k but found '%v'", ErrInvalidArgument, err)
+	}
+}
+
+func TestSearchIndexErrors(t *testing.T) {
+	if _, err := NewSearchIndex().Search("fox", 1); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' searching empty index but found '%v'", ErrNotFitted, err)
+	}
+
+	index := NewSearchIndex()
+	if err := index.Index(nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' indexing no documents but found '%v'", ErrInvalidArgument, err)
+	}
+	if err := index.Index([]string{"the", "and"}); err == nil {
+		t.Errorf("Expected error indexing documents containing only stop words")
+	}
+
+	index.Index([]string{"the quick brown fox"})
+	if _, err := index.Search("fox", -1); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' for negative k but found '%v'", ErrInvalidArgument, err)
+	}
+}
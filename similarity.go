@@ -0,0 +1,390 @@
+package nlp
+
+import (
+	"container/heap"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// CosineSimilarity calculates the distance between the angles of 2 vectors i.e. how
+// similar they are.  Possible values range up to 1 (exact match).  If either vector has
+// a norm of zero (contains only zeros) the similarity is 0.
+func CosineSimilarity(a, b *mat64.Vector) float64 {
+	// Cosine angle between two vectors is equal to their dot product divided by
+	// the product of their norms
+	dotProduct := mat64.Dot(a, b)
+	norma := mat64.Norm(a, 2.0)
+	normb := mat64.Norm(b, 2.0)
+
+	if norma == 0 || normb == 0 {
+		return 0
+	}
+
+	return (dotProduct / (norma * normb))
+}
+
+// DistanceFunc is a function measuring the distance (or similarity) between 2 vectors e.g.
+// EuclideanDistance.  Functions should be symmetric i.e. f(a, b) == f(b, a).
+type DistanceFunc func(a, b *mat64.Vector) float64
+
+// EuclideanDistance calculates the Euclidean (straight line) distance between 2 vectors.
+// Identical vectors have a distance of 0.
+func EuclideanDistance(a, b *mat64.Vector) float64 {
+	var diff mat64.Vector
+	diff.SubVec(a, b)
+	return mat64.Norm(&diff, 2)
+}
+
+// JaccardSimilarity calculates the Jaccard similarity coefficient of 2 vectors, treating
+// the indices of their non zero elements as sets (e.g. the sets of terms present in 2
+// documents) and so ignoring the magnitudes of the values.  The similarity is the size of
+// the intersection of the sets divided by the size of their union and ranges from 0
+// (disjoint) to 1 (identical sets).  If both vectors contain only zeros the similarity is 0.
+func JaccardSimilarity(a, b *mat64.Vector) float64 {
+	var intersection, union int
+	for i := 0; i < a.Len(); i++ {
+		x, y := a.At(i, 0) != 0, b.At(i, 0) != 0
+		if x && y {
+			intersection++
+		}
+		if x || y {
+			union++
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// Deduplicate removes near duplicate documents from the supplied documents, e.g. prior to
+// fitting so that repeated documents do not skew document frequencies, returning the
+// documents retained and their indices within docs.  Each document is tokenised (in lower
+// case, using the default tokeniser) into a set of words and is dropped if the Jaccard
+// similarity of its set with that of any previously retained document exceeds threshold so
+// the first of each group of near duplicates is retained e.g. a threshold of 0 drops any
+// document sharing a word with an earlier document whereas a threshold of 1 drops none.
+// Documents containing no words are considered exact duplicates of each other.  Every pair of retained documents is
+// compared so, for very large corpora, MinHash signatures should be used instead.
+func Deduplicate(docs []string, threshold float64) ([]string, []int) {
+	tokeniser := newDefaultTokeniser()
+
+	var kept []string
+	var indices []int
+	var sets []map[string]struct{}
+	for i, doc := range docs {
+		set := make(map[string]struct{})
+		for _, token := range tokeniser.Tokenise(strings.ToLower(doc)) {
+			set[token] = struct{}{}
+		}
+
+		duplicate := false
+		for _, other := range sets {
+			if setJaccard(set, other) > threshold {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		kept = append(kept, doc)
+		indices = append(indices, i)
+		sets = append(sets, set)
+	}
+	return kept, indices
+}
+
+// setJaccard returns the Jaccard similarity of 2 sets of words where 2 empty sets have a
+// similarity of 1.
+func setJaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var intersection int
+	for word := range a {
+		if _, ok := b[word]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// PairwiseDistance calculates the distance between every pair of documents (columns) in the
+// supplied term document matrix using the specified metric, e.g. EuclideanDistance.  The
+// result is a symmetric n x n matrix, where n is the number of documents, with the element
+// at (i, j) being metric(i, j).  As the metric is assumed to be symmetric, it is only
+// evaluated once for each pair.
+func PairwiseDistance(mat mat64.Matrix, metric DistanceFunc) *mat64.Dense {
+	m, n := mat.Dims()
+	cols := make([]*mat64.Vector, n)
+	for j := range cols {
+		cols[j] = mat64.NewVector(m, mat64.Col(nil, j, mat))
+	}
+
+	distances := mat64.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			d := metric(cols[i], cols[j])
+			distances.Set(i, j, d)
+			distances.Set(j, i, d)
+		}
+	}
+	return distances
+}
+
+// PairwiseCosine calculates the cosine similarity between every pair of documents (columns)
+// in the supplied term document matrix.  The result is a symmetric n x n matrix, where n is
+// the number of documents, with the element at (i, j) being the cosine similarity between
+// documents i and j.  Documents with a norm of zero have a similarity of 0 with every
+// document (including themselves).
+func PairwiseCosine(mat mat64.Matrix) *mat64.Dense {
+	var normalised mat64.Dense
+	normalised.Clone(mat)
+	normaliseColumns(&normalised, L2Norm)
+
+	var similarities mat64.Dense
+	similarities.Mul(normalised.T(), &normalised)
+
+	return &similarities
+}
+
+// CrossSimilarity calculates the cosine similarity between every document (column) of the
+// term document matrix a and every document of the term document matrix b e.g. to match
+// documents of one corpus (such as CVs) with those of another (such as job descriptions).
+// The result is an x by y matrix, where x and y are the numbers of documents in a and b
+// respectively, with the element at (i, j) being the cosine similarity between document i
+// of a and document j of b.  The documents of both matrices are normalised and the
+// similarities calculated with a single matrix multiplication.  Documents with a norm of
+// zero have a similarity of 0 with every document.  An error is returned if the matrices
+// have different numbers of rows (terms).
+func CrossSimilarity(a, b mat64.Matrix) (*mat64.Dense, error) {
+	ra, _ := a.Dims()
+	rb, _ := b.Dims()
+	if ra != rb {
+		return nil, fmt.Errorf("%w: matrices have %d and %d rows (terms)", ErrDimensionMismatch, ra, rb)
+	}
+	return NewSimilarityScorer(a).Scores(b), nil
+}
+
+// TopKNeighbours returns, for every document (column) of the supplied matrix, the indices
+// of the k other documents most similar to it, measured by cosine similarity, in descending
+// order of similarity with documents of equal similarity ordered by index.  Element i of
+// the result holds the neighbours of document i, which is never included among its own
+// neighbours.  Unlike PairwiseCosine(), the n x n matrix of similarities is never
+// materialised: the similarities of each document are calculated in turn and only the k
+// most similar retained (in a heap).  For a *SparseMatrix the similarities are calculated
+// directly from the stored non zero elements (see SparseCosineScores()) so, beyond the
+// input and result, memory use is proportional to n rather than n^2 making TopKNeighbours
+// suitable for large sparse corpora.  Other matrices are first copied into a normalised
+// dense matrix.  If k exceeds the number of other documents then all of them are returned
+// and if k is negative nil is returned.  A matrix with no documents has no neighbours.
+func TopKNeighbours(mat mat64.Matrix, k int) [][]int {
+	if k < 0 {
+		return nil
+	}
+	_, n := mat.Dims()
+	if n == 0 {
+		return [][]int{}
+	}
+	k = min(k, n-1)
+
+	scores := neighbourScores(mat)
+	neighbours := make([][]int, n)
+	top := make(neighbourHeap, 0, k+1)
+	for i := 0; i < n; i++ {
+		similarities := scores(i)
+
+		top = top[:0]
+		for j := 0; j < n; j++ {
+			if j == i {
+				continue
+			}
+			c := neighbour{index: j, score: similarities[j]}
+			if len(top) < k {
+				heap.Push(&top, c)
+			} else if k > 0 && top.less(top[0], c) {
+				top[0] = c
+				heap.Fix(&top, 0)
+			}
+		}
+
+		neighbours[i] = make([]int, len(top))
+		for r := len(top) - 1; r >= 0; r-- {
+			neighbours[i][r] = heap.Pop(&top).(neighbour).index
+		}
+	}
+	return neighbours
+}
+
+// neighbourScores returns a function calculating the cosine similarity between document
+// (column) i of the supplied matrix and every document of the matrix for TopKNeighbours().
+// The returned slice may be reused by subsequent calls.
+func neighbourScores(mat mat64.Matrix) func(i int) []float64 {
+	m, n := mat.Dims()
+	if s, ok := mat.(*SparseMatrix); ok {
+		return func(i int) []float64 {
+			start, end := s.indptr[i], s.indptr[i+1]
+			return SparseCosineScores(s, NewSparseVector(m, s.ind[start:end], s.data[start:end]))
+		}
+	}
+
+	var normalised mat64.Dense
+	normalised.Clone(mat)
+	normaliseColumns(&normalised, L2Norm)
+	scores := make([]float64, n)
+	vec := mat64.NewVector(n, scores)
+	return func(i int) []float64 {
+		vec.MulVec(normalised.T(), normalised.ColView(i))
+		return scores
+	}
+}
+
+// neighbour is a candidate neighbour of a document along with its similarity score.
+type neighbour struct {
+	index int
+	score float64
+}
+
+// neighbourHeap is a min heap of candidate neighbours implementing heap.Interface with the
+// least similar candidate (with ties broken by the highest index) at the root so that it may
+// be cheaply replaced by a more similar candidate.
+type neighbourHeap []neighbour
+
+// less reports whether candidate a is less similar than candidate b.
+func (h neighbourHeap) less(a, b neighbour) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.index > b.index
+}
+
+func (h neighbourHeap) Len() int            { return len(h) }
+func (h neighbourHeap) Less(i, j int) bool  { return h.less(h[i], h[j]) }
+func (h neighbourHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighbourHeap) Push(x interface{}) { *h = append(*h, x.(neighbour)) }
+
+func (h *neighbourHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// NearestNeighbours supports finding the documents most similar to a query, measured by
+// cosine similarity, within a matrix of document feature vectors (columns) e.g. the output
+// of TruncatedSVD.  The document vectors are normalised once on construction so that each
+// query only requires a single matrix vector multiplication.
+type NearestNeighbours struct {
+	docs *mat64.Dense
+}
+
+// NewNearestNeighbours creates a new NearestNeighbours index of the documents (columns)
+// of the supplied matrix.  The matrix is copied.
+func NewNearestNeighbours(mat mat64.Matrix) *NearestNeighbours {
+	var docs mat64.Dense
+	docs.Clone(mat)
+	normaliseColumns(&docs, L2Norm)
+	return &NearestNeighbours{docs: &docs}
+}
+
+// Query returns the indices of the k documents most similar to the supplied query feature
+// vector along with their cosine similarity scores, sorted in descending order of
+// similarity.  Documents with equal scores are ordered by index.  An error is returned if k
+// exceeds the number of documents or the query vector's dimensionality does not match the
+// documents'.
+func (n *NearestNeighbours) Query(vec *mat64.Vector, k int) ([]int, []float64, error) {
+	m, docs := n.docs.Dims()
+	if k < 0 || k > docs {
+		return nil, nil, fmt.Errorf("%w: k (%d) must be between 0 and the number of documents (%d)", ErrInvalidArgument, k, docs)
+	}
+	if vec.Len() != m {
+		return nil, nil, fmt.Errorf("%w: query vector has %d dimensions but documents have %d", ErrDimensionMismatch, vec.Len(), m)
+	}
+
+	var scores mat64.Vector
+	scores.MulVec(n.docs.T(), vec)
+	if norm := mat64.Norm(vec, 2); norm != 0 {
+		scores.ScaleVec(1/norm, &scores)
+	}
+
+	indices := make([]int, docs)
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return scores.At(indices[i], 0) > scores.At(indices[j], 0)
+	})
+
+	indices = indices[:k]
+	similarities := make([]float64, k)
+	for i, doc := range indices {
+		similarities[i] = scores.At(doc, 0)
+	}
+	return indices, similarities, nil
+}
+
+// SimilarityScorer scores batches of query documents against a fixed set of reference
+// documents by cosine similarity e.g. for classification by retrieval where queries are
+// labelled according to their most similar references.  The reference document vectors are
+// normalised once on construction and the query vectors once per batch so that all the
+// scores for a batch are calculated with a single matrix multiplication.
+type SimilarityScorer struct {
+	references *mat64.Dense
+}
+
+// NewSimilarityScorer creates a new SimilarityScorer for the reference documents (columns)
+// of the supplied matrix.  The matrix is copied.
+func NewSimilarityScorer(references mat64.Matrix) *SimilarityScorer {
+	var refs mat64.Dense
+	refs.Clone(references)
+	normaliseColumns(&refs, L2Norm)
+	return &SimilarityScorer{references: &refs}
+}
+
+// Scores returns the cosine similarity between every reference document and every query
+// document (column) of the supplied matrix as a matrix of r rows by q columns, where r is
+// the number of reference documents and q the number of query documents, with the element
+// at (i, j) being the cosine similarity between reference i and query j.  Documents with a
+// norm of zero have a similarity of 0 with every document.  If the number of rows in the
+// query matrix differs from the reference matrix then nil is returned.
+func (s *SimilarityScorer) Scores(queries mat64.Matrix) *mat64.Dense {
+	m, _ := queries.Dims()
+	if r, _ := s.references.Dims(); m != r {
+		return nil
+	}
+
+	var normalised mat64.Dense
+	normalised.Clone(queries)
+	normaliseColumns(&normalised, L2Norm)
+
+	var scores mat64.Dense
+	scores.Mul(s.references.T(), &normalised)
+	return &scores
+}
+
+// HammingDistance returns the number of bits that differ between the 2 supplied bit packed
+// fingerprints as produced by SignRandomProjection.  If the fingerprints are of different
+// lengths, the extra elements of the longer fingerprint are compared against zero bits.
+func HammingDistance(a, b []uint64) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	var dist int
+	for i := range a {
+		var v uint64
+		if i < len(b) {
+			v = b[i]
+		}
+		dist += bits.OnesCount64(a[i] ^ v)
+	}
+	return dist
+}
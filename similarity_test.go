@@ -0,0 +1,483 @@
+package nlp
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	var tests = []struct {
+		a          []float64
+		b          []float64
+		similarity float64
+	}{
+		{[]float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{[]float64{1, 2, 3}, []float64{2, 4, 6}, 1},
+		{[]float64{1, 0, 0}, []float64{0, 1, 0}, 0},
+		{[]float64{1, 0, 0}, []float64{-1, 0, 0}, -1},
+		{[]float64{1, 1, 0}, []float64{1, 0, 0}, 1 / math.Sqrt2},
+		{[]float64{0, 0, 0}, []float64{1, 2, 3}, 0},
+		{[]float64{0, 0, 0}, []float64{0, 0, 0}, 0},
+	}
+
+	for _, test := range tests {
+		a := mat64.NewVector(len(test.a), test.a)
+		b := mat64.NewVector(len(test.b), test.b)
+
+		similarity := CosineSimilarity(a, b)
+
+		if math.Abs(similarity-test.similarity) > 0.000001 {
+			t.Errorf("Expected similarity of %f between %v and %v but found %f",
+				test.similarity, test.a, test.b, similarity)
+		}
+	}
+}
+
+func TestEuclideanDistance(t *testing.T) {
+	var tests = []struct {
+		a        []float64
+		b        []float64
+		distance float64
+	}{
+		{[]float64{1, 2, 3}, []float64{1, 2, 3}, 0},
+		{[]float64{0, 0, 0}, []float64{0, 0, 0}, 0},
+		{[]float64{1, 0, 0}, []float64{0, 1, 0}, math.Sqrt2},
+		{[]float64{3, 0, 0}, []float64{0, 0, 4}, 5},
+		{[]float64{1, 2, 3}, []float64{2, 4, 6}, math.Sqrt(14)},
+	}
+
+	for _, test := range tests {
+		a := mat64.NewVector(len(test.a), test.a)
+		b := mat64.NewVector(len(test.b), test.b)
+
+		distance := EuclideanDistance(a, b)
+
+		if math.Abs(distance-test.distance) > 0.000001 {
+			t.Errorf("Expected distance of %f between %v and %v but found %f",
+				test.distance, test.a, test.b, distance)
+		}
+	}
+}
+
+func TestJaccardSimilarity(t *testing.T) {
+	var tests = []struct {
+		a          []float64
+		b          []float64
+		similarity float64
+	}{
+		{[]float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		// magnitudes are ignored
+		{[]float64{1, 0, 3}, []float64{5, 0, 1}, 1},
+		{[]float64{1, 0, 0}, []float64{0, 1, 0}, 0},
+		{[]float64{1, 1, 0, 0}, []float64{1, 0, 1, 0}, 1.0 / 3},
+		{[]float64{0, 0, 0}, []float64{1, 2, 3}, 0},
+		{[]float64{0, 0, 0}, []float64{0, 0, 0}, 0},
+	}
+
+	for _, test := range tests {
+		a := mat64.NewVector(len(test.a), test.a)
+		b := mat64.NewVector(len(test.b), test.b)
+
+		similarity := JaccardSimilarity(a, b)
+
+		if math.Abs(similarity-test.similarity) > 0.000001 {
+			t.Errorf("Expected similarity of %f between %v and %v but found %f",
+				test.similarity, test.a, test.b, similarity)
+		}
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	var tests = []struct {
+		docs      []string
+		threshold float64
+		kept      []int
+	}{
+		{
+			docs: []string{
+				"The quick brown fox jumped over the lazy dog",
+				"Stock markets rallied as interest rates fell",
+				"the quick brown fox jumped over the lazy dog!",
+			},
+			threshold: 0.9,
+			kept:      []int{0, 1},
+		},
+		{
+			// the third document shares 8 of 9 distinct words with the first
+			docs: []string{
+				"The quick brown fox jumped over the lazy dog",
+				"Stock markets rallied as interest rates fell",
+				"The quick brown fox jumped over the lazy cat",
+			},
+			threshold: 0.7,
+			kept:      []int{0, 1},
+		},
+		{
+			docs: []string{
+				"The quick brown fox jumped over the lazy dog",
+				"Stock markets rallied as interest rates fell",
+				"The quick brown fox jumped over the lazy cat",
+			},
+			threshold: 1,
+			kept:      []int{0, 1, 2},
+		},
+		{
+			docs:      []string{"fox dog", "Dog fox", "FOX, DOG", "dog dog fox"},
+			threshold: 0.99,
+			kept:      []int{0},
+		},
+		// documents are only dropped if their similarity exceeds the threshold
+		{
+			docs:      []string{"fox dog", "Dog fox"},
+			threshold: 1,
+			kept:      []int{0, 1},
+		},
+		{
+			docs:      []string{"fox dog", "fox cat"},
+			threshold: 0,
+			kept:      []int{0},
+		},
+		{
+			docs:      []string{"", "the fox", "  "},
+			threshold: 0.99,
+			kept:      []int{0, 1},
+		},
+		{docs: nil, threshold: 0.5, kept: nil},
+	}
+
+	for ti, test := range tests {
+		kept, indices := Deduplicate(test.docs, test.threshold)
+		if !reflect.DeepEqual(test.kept, indices) {
+			t.Errorf("Test %d: Expected indices %v to be kept but found %v", ti, test.kept, indices)
+			continue
+		}
+		for i, index := range indices {
+			if kept[i] != test.docs[index] {
+				t.Errorf("Test %d: Expected kept document %d to be '%s' but found '%s'", ti, i, test.docs[index], kept[i])
+			}
+		}
+	}
+}
+
+func TestPairwiseDistance(t *testing.T) {
+	input := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 1, 0, 0,
+		0, 0, 0, 0,
+	})
+
+	var tests = []struct {
+		metric   DistanceFunc
+		expected []float64
+	}{
+		{
+			metric: EuclideanDistance,
+			expected: []float64{
+				0, math.Sqrt2, 1, 1,
+				math.Sqrt2, 0, math.Sqrt(5), 1,
+				1, math.Sqrt(5), 0, 2,
+				1, 1, 2, 0,
+			},
+		},
+		{
+			metric: JaccardSimilarity,
+			expected: []float64{
+				1, 0, 1, 0,
+				0, 1, 0, 0,
+				1, 0, 1, 0,
+				0, 0, 0, 0,
+			},
+		},
+		{
+			// user supplied metric
+			metric: func(a, b *mat64.Vector) float64 {
+				return mat64.Dot(a, b)
+			},
+			expected: []float64{
+				1, 0, 2, 0,
+				0, 1, 0, 0,
+				2, 0, 4, 0,
+				0, 0, 0, 0,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		expected := mat64.NewDense(4, 4, test.expected)
+		result := PairwiseDistance(input, test.metric)
+
+		if !mat64.EqualApprox(expected, result, 0.000001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+}
+
+func TestPairwiseCosine(t *testing.T) {
+	input := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 1, 0, 0,
+		0, 0, 0, 0,
+	})
+
+	expected := mat64.NewDense(4, 4, []float64{
+		1, 0, 1, 0,
+		0, 1, 0, 0,
+		1, 0, 1, 0,
+		0, 0, 0, 0,
+	})
+
+	result := PairwiseCosine(input)
+
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	// should agree with CosineSimilarity for every pair of columns
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			expected := CosineSimilarity(input.ColView(i), input.ColView(j))
+			if math.Abs(result.At(i, j)-expected) > 0.000001 {
+				t.Errorf("Expected similarity %f at (%d, %d) but found %f", expected, i, j, result.At(i, j))
+			}
+		}
+	}
+}
+
+func TestCrossSimilarity(t *testing.T) {
+	// 3 CVs and 2 job descriptions over the terms: go, python, sql, design
+	cvs := mat64.NewDense(4, 3, []float64{
+		3, 0, 0,
+		1, 0, 4,
+		0, 0, 2,
+		0, 5, 0,
+	})
+	jobs := mat64.NewDense(4, 2, []float64{
+		0, 2,
+		1, 0,
+		1, 0,
+		0, 0,
+	})
+
+	result, err := CrossSimilarity(cvs, jobs)
+	if err != nil {
+		t.Fatalf("Failed to calculate similarities caused by %v", err)
+	}
+	if r, c := result.Dims(); r != 3 || c != 2 {
+		t.Fatalf("Expected 3 x 2 matrix of similarities but found %d x %d", r, c)
+	}
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 2; j++ {
+			expected := CosineSimilarity(cvs.ColView(i), jobs.ColView(j))
+			if math.Abs(result.At(i, j)-expected) > 0.000001 {
+				t.Errorf("Expected similarity %f between CV %d and job %d but found %f", expected, i, j, result.At(i, j))
+			}
+		}
+	}
+
+	// the python and sql CV best matches the first job and the go CV the second
+	for j, best := range []int{2, 0} {
+		for i := 0; i < 3; i++ {
+			if i != best && result.At(i, j) >= result.At(best, j) {
+				t.Errorf("Expected CV %d to be the best match for job %d but found CV %d", best, j, i)
+			}
+		}
+	}
+
+	if _, err := CrossSimilarity(cvs, mat64.NewDense(3, 2, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+}
+
+func TestTopKNeighbours(t *testing.T) {
+	input := mat64.NewDense(3, 5, []float64{
+		1, 0, 1, 0, 2,
+		0, 1, 1, 0, 0,
+		0, 0, 0, 1, 0,
+	})
+
+	var tests = []struct {
+		k        int
+		expected [][]int
+	}{
+		{k: 0, expected: [][]int{{}, {}, {}, {}, {}}},
+		{k: 1, expected: [][]int{{4}, {2}, {0}, {0}, {0}}},
+		{k: 2, expected: [][]int{{4, 2}, {2, 0}, {0, 1}, {0, 1}, {0, 2}}},
+		{k: 10, expected: [][]int{{4, 2, 1, 3}, {2, 0, 3, 4}, {0, 1, 4, 3}, {0, 1, 2, 4}, {0, 2, 1, 3}}},
+	}
+
+	sparse := NewSparseMatrixFrom(input)
+	for _, test := range tests {
+		if result := TopKNeighbours(input, test.k); !reflect.DeepEqual(test.expected, result) {
+			t.Errorf("k: %d - Expected neighbours %v but found %v", test.k, test.expected, result)
+		}
+		if result := TopKNeighbours(sparse, test.k); !reflect.DeepEqual(test.expected, result) {
+			t.Errorf("k: %d - Expected neighbours %v for sparse matrix but found %v", test.k, test.expected, result)
+		}
+	}
+
+	// should agree with the dense computation of all pairwise similarities
+	mat := mat64.NewDense(6, 20, nil)
+	rnd := newRand(nil, 7)
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 20; j++ {
+			mat.Set(i, j, float64(rnd.Intn(4)))
+		}
+	}
+	dense := PairwiseCosine(mat)
+	k := 5
+	for _, m := range []mat64.Matrix{mat, NewSparseMatrixFrom(mat)} {
+		for i, neighbours := range TopKNeighbours(m, k) {
+			expected := make([]float64, 0, 19)
+			for j := 0; j < 20; j++ {
+				if j != i {
+					expected = append(expected, dense.At(i, j))
+				}
+			}
+			sort.Sort(sort.Reverse(sort.Float64Slice(expected)))
+
+			if len(neighbours) != k {
+				t.Errorf("%T: Expected %d neighbours of document %d but found %d", m, k, i, len(neighbours))
+				continue
+			}
+			for r, j := range neighbours {
+				if j == i || math.Abs(dense.At(i, j)-expected[r]) > 0.000001 {
+					t.Errorf("%T: Expected neighbours of document %d with similarities %v but found %v", m, i, expected[:k], neighbours)
+					break
+				}
+			}
+		}
+	}
+
+	if result := TopKNeighbours(input, -1); result != nil {
+		t.Errorf("Expected nil for negative k but found %v", result)
+	}
+	if result := TopKNeighbours(NewSparseMatrix(3, 0, nil, nil, nil), 2); result == nil || len(result) != 0 {
+		t.Errorf("Expected no neighbours for a matrix without documents but found %v", result)
+	}
+}
+
+func TestNearestNeighboursQuery(t *testing.T) {
+	docs := mat64.NewDense(3, 5, []float64{
+		1, 0, 1, 0, 2,
+		0, 1, 1, 0, 0,
+		0, 0, 0, 1, 0,
+	})
+
+	var tests = []struct {
+		query        []float64
+		k            int
+		indices      []int
+		similarities []float64
+	}{
+		{[]float64{1, 0, 0}, 2, []int{0, 4}, []float64{1, 1}},
+		{[]float64{1, 1, 0}, 3, []int{2, 0, 1}, []float64{1, 1 / math.Sqrt2, 1 / math.Sqrt2}},
+		{[]float64{0, 0, 3}, 1, []int{3}, []float64{1}},
+		{[]float64{0, 1, 0}, 5, []int{1, 2, 0, 3, 4}, []float64{1, 1 / math.Sqrt2, 0, 0, 0}},
+		{[]float64{1, 0, 0}, 0, []int{}, []float64{}},
+	}
+
+	nn := NewNearestNeighbours(docs)
+
+	for _, test := range tests {
+		indices, similarities, err := nn.Query(mat64.NewVector(len(test.query), test.query), test.k)
+		if err != nil {
+			t.Errorf("Failed query caused by %v", err)
+			continue
+		}
+
+		if len(indices) != test.k || len(similarities) != test.k {
+			t.Errorf("Expected %d results but found %d", test.k, len(indices))
+			continue
+		}
+
+		for i := range indices {
+			if indices[i] != test.indices[i] || math.Abs(similarities[i]-test.similarities[i]) > 0.000001 {
+				t.Errorf("Expected results %v %v for query %v but found %v %v",
+					test.indices, test.similarities, test.query, indices, similarities)
+				break
+			}
+		}
+	}
+
+	if _, _, err := nn.Query(mat64.NewVector(3, []float64{1, 0, 0}), 6); err == nil {
+		t.Errorf("Expected error when k exceeds the number of documents but found none")
+	}
+	if _, _, err := nn.Query(mat64.NewVector(2, []float64{1, 0}), 1); err == nil {
+		t.Errorf("Expected error for query with mismatched dimensions but found none")
+	}
+}
+
+func TestSimilarityScorer(t *testing.T) {
+	references := mat64.NewDense(4, 3, []float64{
+		1, 0, 2,
+		0, 3, 1,
+		2, 0, 0,
+		0, 1, 0,
+	})
+	queries := mat64.NewDense(4, 4, []float64{
+		1, 0, 0, 5,
+		1, 2, 0, 0,
+		0, 0, 0, 1,
+		3, 0, 0, 2,
+	})
+
+	scorer := NewSimilarityScorer(references)
+	for _, q := range []mat64.Matrix{queries, NewSparseMatrixFrom(queries)} {
+		scores := scorer.Scores(q)
+		if r, c := scores.Dims(); r != 3 || c != 4 {
+			t.Errorf("Expected 3 x 4 matrix of scores but found %d x %d", r, c)
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 4; j++ {
+				expected := CosineSimilarity(references.ColView(i), queries.ColView(j))
+				if math.Abs(scores.At(i, j)-expected) > 0.000001 {
+					t.Errorf("Expected score %f for reference %d and query %d but found %f", expected, i, j, scores.At(i, j))
+				}
+			}
+		}
+	}
+
+	// the references should be copied
+	references.Set(0, 0, 100)
+	if scores := scorer.Scores(queries); math.Abs(scores.At(0, 0)-CosineSimilarity(mat64.NewVector(4, []float64{1, 0, 2, 0}), queries.ColView(0))) > 0.000001 {
+		t.Errorf("Expected scores to be unaffected by modifying the reference matrix")
+	}
+
+	if scores := scorer.Scores(mat64.NewDense(3, 1, nil)); scores != nil {
+		t.Errorf("Expected nil for mismatched query dimensions but found %v", scores)
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	var tests = []struct {
+		a    []uint64
+		b    []uint64
+		dist int
+	}{
+		{[]uint64{0}, []uint64{0}, 0},
+		{[]uint64{0xff}, []uint64{0xff}, 0},
+		{[]uint64{0xff}, []uint64{0x0f}, 4},
+		{[]uint64{1, 1 << 63}, []uint64{0, 0}, 2},
+		{[]uint64{1, 3}, []uint64{1}, 2},
+		{nil, nil, 0},
+	}
+
+	for _, test := range tests {
+		if dist := HammingDistance(test.a, test.b); dist != test.dist {
+			t.Errorf("Expected Hamming distance %d between %v and %v but found %d", test.dist, test.a, test.b, dist)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package nlp
+
+import (
+	"sort"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+)
+
+// SparseMatrix is a sparse matrix stored in CSC (Compressed Sparse Column) format.  Only the
+// non zero elements of the matrix are stored, column by column, making it well suited for
+// term document matrices where the columns (documents) typically only contain a small
+// fraction of the terms within the vocabulary.  SparseMatrix implements the mat64.Matrix
+// interface so may be used anywhere a mat64.Matrix is accepted although element access via
+// At() is slower than for dense matrices.
+type SparseMatrix struct {
+	r, c int
+
+	// indptr holds the offsets into ind and data of the start of each column such that the
+	// row indices and values for column j are stored in ind[indptr[j]:indptr[j+1]] and
+	// data[indptr[j]:indptr[j+1]] respectively.
+	indptr []int
+	ind    []int
+	data   []float64
+}
+
+// NewSparseMatrix creates a new r x c SparseMatrix from the supplied CSC (Compressed Sparse
+// Column) formatted slices.  indptr must have c+1 elements and contain the offsets into ind
+// and data of the start of each column (with the last element being the total number of non
+// zero elements).  ind contains the row indices of each non zero element, which must be in
+// ascending order within each column, and data the corresponding values.  If all the slices
+// are nil, an empty r x c matrix is created.  The slices are used as the backing store for
+// the matrix and are not copied.
+func NewSparseMatrix(r, c int, indptr []int, ind []int, data []float64) *SparseMatrix {
+	if indptr == nil {
+		indptr = make([]int, c+1)
+	}
+	if len(indptr) != c+1 {
+		panic("nlp: indptr must have one more element than the number of columns")
+	}
+	if len(ind) != len(data) || len(ind) != indptr[c] {
+		panic("nlp: ind and data must contain indptr[c] elements")
+	}
+	return &SparseMatrix{r: r, c: c, indptr: indptr, ind: ind, data: data}
+}
+
+// NewSparseMatrixFrom creates a new SparseMatrix containing the non zero elements of the
+// supplied matrix.
+func NewSparseMatrixFrom(mat mat64.Matrix) *SparseMatrix {
+	if s, ok := mat.(*SparseMatrix); ok {
+		return s.clone()
+	}
+
+	m, n := mat.Dims()
+	s := &SparseMatrix{r: m, c: n, indptr: make([]int, n+1)}
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			if v := mat.At(i, j); v != 0 {
+				s.ind = append(s.ind, i)
+				s.data = append(s.data, v)
+			}
+		}
+		s.indptr[j+1] = len(s.ind)
+	}
+	return s
+}
+
+// Dims returns the dimensions of the matrix
+func (s *SparseMatrix) Dims() (int, int) {
+	return s.r, s.c
+}
+
+// At returns the element of the matrix at row i, column j.  At will panic if i or j are out
+// of bounds.
+func (s *SparseMatrix) At(i, j int) float64 {
+	if uint(i) >= uint(s.r) || uint(j) >= uint(s.c) {
+		panic(matrix.ErrIndexOutOfRange)
+	}
+	start, end := s.indptr[j], s.indptr[j+1]
+	k := start + sort.SearchInts(s.ind[start:end], i)
+	if k < end && s.ind[k] == i {
+		return s.data[k]
+	}
+	return 0
+}
+
+// T returns the transpose of the matrix
+func (s *SparseMatrix) T() mat64.Matrix {
+	return mat64.Transpose{Matrix: s}
+}
+
+// transpose returns a new SparseMatrix holding the transpose of the matrix.  Unlike T(),
+// which returns a view accessed via At(), the result is stored in CSC format (and so
+// supports the fast paths available to *SparseMatrix).
+func (s *SparseMatrix) transpose() *SparseMatrix {
+	t := &SparseMatrix{
+		r:      s.c,
+		c:      s.r,
+		indptr: make([]int, s.r+1),
+		ind:    make([]int, len(s.ind)),
+		data:   make([]float64, len(s.data)),
+	}
+	for _, i := range s.ind {
+		t.indptr[i+1]++
+	}
+	for i := 0; i < s.r; i++ {
+		t.indptr[i+1] += t.indptr[i]
+	}
+
+	next := make([]int, s.r)
+	copy(next, t.indptr)
+	s.DoNonZero(func(i, j int, v float64) {
+		t.ind[next[i]] = j
+		t.data[next[i]] = v
+		next[i]++
+	})
+	return t
+}
+
+// NNZ returns the number of stored non zero elements within the matrix
+func (s *SparseMatrix) NNZ() int {
+	return len(s.data)
+}
+
+// DoNonZero calls the function fn for each of the stored non zero elements of the matrix in
+// column major order.
+func (s *SparseMatrix) DoNonZero(fn func(i, j int, v float64)) {
+	for j := 0; j < s.c; j++ {
+		for k := s.indptr[j]; k < s.indptr[j+1]; k++ {
+			fn(s.ind[k], j, s.data[k])
+		}
+	}
+}
+
+// ToDense returns a dense copy of the matrix
+func (s *SparseMatrix) ToDense() *mat64.Dense {
+	dense := mat64.NewDense(s.r, s.c, nil)
+	s.DoNonZero(func(i, j int, v float64) {
+		dense.Set(i, j, v)
+	})
+	return dense
+}
+
+func (s *SparseMatrix) clone() *SparseMatrix {
+	c := &SparseMatrix{
+		r:      s.r,
+		c:      s.c,
+		indptr: make([]int, len(s.indptr)),
+		ind:    make([]int, len(s.ind)),
+		data:   make([]float64, len(s.data)),
+	}
+	copy(c.indptr, s.indptr)
+	copy(c.ind, s.ind)
+	copy(c.data, s.data)
+	return c
+}
+
+// appendColumn appends a column to the matrix containing the values of the supplied map of
+// row indices to values.  Zero values are not stored.
+func (s *SparseMatrix) appendColumn(values map[int]float64) {
+	start := len(s.ind)
+	for i, v := range values {
+		if v != 0 {
+			s.ind = append(s.ind, i)
+		}
+	}
+	sort.Ints(s.ind[start:])
+
+	for _, i := range s.ind[start:] {
+		s.data = append(s.data, values[i])
+	}
+	s.indptr = append(s.indptr, len(s.ind))
+	s.c++
+}
+
+// normaliseColumns scales the non zero values in each column of the matrix, in place, by the
+// column's norm of the specified type.  Columns with a norm of zero are left unchanged.
+func (s *SparseMatrix) normaliseColumns(norm NormType) {
+	p, ok := norm.p()
+	if !ok {
+		return
+	}
+	for j := 0; j < s.c; j++ {
+		col := s.data[s.indptr[j]:s.indptr[j+1]]
+		if length := floats.Norm(col, p); length != 0 {
+			floats.Scale(1/length, col)
+		}
+	}
+}
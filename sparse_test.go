@@ -0,0 +1,119 @@
+package nlp
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestSparseMatrix(t *testing.T) {
+	var tests = []struct {
+		m     int
+		n     int
+		input []float64
+		nnz   int
+	}{
+		{
+			m: 4, n: 3,
+			input: []float64{
+				1, 0, 0,
+				0, 0, 2,
+				3, 0, 4,
+				0, 0, 0,
+			},
+			nnz: 4,
+		},
+		{
+			m: 2, n: 2,
+			input: []float64{
+				0, 0,
+				0, 0,
+			},
+			nnz: 0,
+		},
+	}
+
+	for _, test := range tests {
+		dense := mat64.NewDense(test.m, test.n, test.input)
+		sparse := NewSparseMatrixFrom(dense)
+
+		if sparse.NNZ() != test.nnz {
+			t.Errorf("Expected %d non zero elements but found %d", test.nnz, sparse.NNZ())
+		}
+
+		m, n := sparse.Dims()
+		if m != test.m || n != test.n {
+			t.Errorf("Expected matrix %d x %d but found %d x %d", test.m, test.n, m, n)
+		}
+
+		if !mat64.Equal(dense, sparse) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(dense),
+				mat64.Formatted(sparse))
+			t.Fail()
+		}
+
+		if !mat64.Equal(dense, sparse.ToDense()) {
+			t.Logf("Expected dense matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(dense),
+				mat64.Formatted(sparse.ToDense()))
+			t.Fail()
+		}
+
+		if !mat64.Equal(dense.T(), sparse.T()) {
+			t.Logf("Expected transposed matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(dense.T()),
+				mat64.Formatted(sparse.T()))
+			t.Fail()
+		}
+
+		count := 0
+		sparse.DoNonZero(func(i, j int, v float64) {
+			count++
+			if v != dense.At(i, j) {
+				t.Errorf("Expected %f at (%d, %d) but found %f", dense.At(i, j), i, j, v)
+			}
+		})
+		if count != test.nnz {
+			t.Errorf("Expected DoNonZero to visit %d elements but visited %d", test.nnz, count)
+		}
+	}
+}
+
+func TestSparseMatrixTranspose(t *testing.T) {
+	dense := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 0, 3, 4,
+		5, 0, 0, 6,
+	})
+
+	transposed := NewSparseMatrixFrom(dense).transpose()
+	if !mat64.Equal(dense.T(), transposed) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(dense.T()),
+			mat64.Formatted(transposed))
+		t.Fail()
+	}
+	if !mat64.Equal(dense, transposed.transpose()) {
+		t.Errorf("Expected transposing twice to restore the original matrix")
+	}
+	if transposed.NNZ() != 6 {
+		t.Errorf("Expected 6 non zero elements but found %d", transposed.NNZ())
+	}
+}
+
+func TestNewSparseMatrix(t *testing.T) {
+	sparse := NewSparseMatrix(3, 2, []int{0, 2, 3}, []int{0, 2, 1}, []float64{1, 2, 3})
+	expected := mat64.NewDense(3, 2, []float64{
+		1, 0,
+		0, 3,
+		2, 0,
+	})
+
+	if !mat64.Equal(expected, sparse) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(sparse))
+		t.Fail()
+	}
+}
@@ -0,0 +1,205 @@
+package nlp
+
+import (
+	"sort"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix"
+	"github.com/gonum/matrix/mat64"
+)
+
+// SparseVector is a sparse column vector storing only its non zero elements, as parallel
+// slices of indices and values sorted by index.  Queries typically contain only a handful of
+// the terms within a vocabulary so a SparseVector requires memory proportional only to the
+// number of distinct terms in the query rather than to the size of the vocabulary.
+// SparseVector implements the mat64.Matrix interface (as an n x 1 matrix) so may be supplied
+// to transformers in place of a dense vector.
+type SparseVector struct {
+	n    int
+	ind  []int
+	data []float64
+}
+
+// NewSparseVector creates a new SparseVector of length n from the supplied indices and
+// values of its non zero elements.  ind must be in ascending order and contain the same
+// number of elements as data.  The slices are used as the backing store for the vector and
+// are not copied.
+func NewSparseVector(n int, ind []int, data []float64) *SparseVector {
+	if len(ind) != len(data) {
+		panic("nlp: ind and data must contain the same number of elements")
+	}
+	for k, i := range ind {
+		if uint(i) >= uint(n) || (k > 0 && i <= ind[k-1]) {
+			panic("nlp: ind must be in ascending order and within the length of the vector")
+		}
+	}
+	return &SparseVector{n: n, ind: ind, data: data}
+}
+
+// NewSparseVectorFromMap creates a new SparseVector of length n from the supplied map of
+// indices to values, in the same form as accepted by TfidfTransformer.TransformQuery().
+// Zero values are not stored.
+func NewSparseVectorFromMap(n int, values map[int]float64) *SparseVector {
+	v := &SparseVector{n: n}
+	for i, val := range values {
+		if uint(i) >= uint(n) {
+			panic(matrix.ErrIndexOutOfRange)
+		}
+		if val != 0 {
+			v.ind = append(v.ind, i)
+		}
+	}
+	sort.Ints(v.ind)
+	v.data = make([]float64, len(v.ind))
+	for k, i := range v.ind {
+		v.data[k] = values[i]
+	}
+	return v
+}
+
+// NewSparseVectorFrom creates a new SparseVector containing the non zero elements of the
+// supplied dense vector.
+func NewSparseVectorFrom(vec *mat64.Vector) *SparseVector {
+	v := &SparseVector{n: vec.Len()}
+	for i := 0; i < v.n; i++ {
+		if val := vec.At(i, 0); val != 0 {
+			v.ind = append(v.ind, i)
+			v.data = append(v.data, val)
+		}
+	}
+	return v
+}
+
+// Len returns the length of the vector
+func (v *SparseVector) Len() int {
+	return v.n
+}
+
+// Dims returns the dimensions of the vector as a n x 1 matrix
+func (v *SparseVector) Dims() (int, int) {
+	return v.n, 1
+}
+
+// At returns the element of the vector at row i.  At will panic if i is out of bounds or j
+// is not 0.
+func (v *SparseVector) At(i, j int) float64 {
+	if uint(i) >= uint(v.n) || j != 0 {
+		panic(matrix.ErrIndexOutOfRange)
+	}
+	k := sort.SearchInts(v.ind, i)
+	if k < len(v.ind) && v.ind[k] == i {
+		return v.data[k]
+	}
+	return 0
+}
+
+// T returns the transpose of the vector
+func (v *SparseVector) T() mat64.Matrix {
+	return mat64.Transpose{Matrix: v}
+}
+
+// NNZ returns the number of stored non zero elements within the vector
+func (v *SparseVector) NNZ() int {
+	return len(v.data)
+}
+
+// DoNonZero calls the function fn for each of the stored non zero elements of the vector in
+// ascending order of index.
+func (v *SparseVector) DoNonZero(fn func(i int, val float64)) {
+	for k, i := range v.ind {
+		fn(i, v.data[k])
+	}
+}
+
+// ToVector returns a dense copy of the vector
+func (v *SparseVector) ToVector() *mat64.Vector {
+	vec := mat64.NewVector(v.n, nil)
+	for k, i := range v.ind {
+		vec.SetVec(i, v.data[k])
+	}
+	return vec
+}
+
+// Norm returns the Euclidean (L2) norm of the vector
+func (v *SparseVector) Norm() float64 {
+	return floats.Norm(v.data, 2)
+}
+
+// Dot returns the dot product of the vector with the supplied dense vector, visiting only
+// the non zero elements of the sparse vector.  b may be a column view of a matrix (e.g.
+// mat.ColView(j)) to avoid copying document vectors.  Dot will panic if the lengths of the
+// vectors differ.
+func (v *SparseVector) Dot(b *mat64.Vector) float64 {
+	if b.Len() != v.n {
+		panic(matrix.ErrShape)
+	}
+	var dot float64
+	for k, i := range v.ind {
+		dot += v.data[k] * b.At(i, 0)
+	}
+	return dot
+}
+
+// CosineSimilarity calculates the cosine similarity between the vector and the supplied
+// dense vector (e.g. a document column of a term document matrix obtained with ColView())
+// without converting the sparse vector to a dense one.  The result is equivalent to the
+// package level CosineSimilarity() function applied to the dense equivalent.  If either
+// vector has a norm of zero the similarity is 0.  CosineSimilarity will panic if the lengths
+// of the vectors differ.
+func (v *SparseVector) CosineSimilarity(b *mat64.Vector) float64 {
+	dot := v.Dot(b)
+	norma := v.Norm()
+	normb := mat64.Norm(b, 2)
+
+	if norma == 0 || normb == 0 {
+		return 0
+	}
+	return dot / (norma * normb)
+}
+
+// SparseCosineScores calculates the cosine similarity between the sparse query vector and
+// every document (column) of the sparse index matrix, returning one score per document.
+// Only the stored non zero elements of the index and query are visited (the row indices of
+// each document are merged with the indices of the query) so no dense intermediate vector
+// or matrix is constructed and the cost is proportional to the number of non zero elements
+// rather than to the size of the vocabulary.  This makes SparseCosineScores suitable for
+// scoring queries against large, sparse, search indexes (e.g. TF-IDF weighted term document
+// matrices output by TransformSparse()).  Documents, or queries, with a norm of zero have a
+// similarity of 0.  If the length of the query differs from the number of rows in the index
+// then nil is returned.
+func SparseCosineScores(index *SparseMatrix, query *SparseVector) []float64 {
+	m, n := index.Dims()
+	if query.Len() != m {
+		return nil
+	}
+
+	scores := make([]float64, n)
+	qnorm := query.Norm()
+	if qnorm == 0 {
+		return scores
+	}
+
+	for j := 0; j < n; j++ {
+		start, end := index.indptr[j], index.indptr[j+1]
+		ind, data := index.ind[start:end], index.data[start:end]
+
+		var dot float64
+		for a, b := 0, 0; a < len(ind) && b < len(query.ind); {
+			switch {
+			case ind[a] < query.ind[b]:
+				a++
+			case ind[a] > query.ind[b]:
+				b++
+			default:
+				dot += data[a] * query.data[b]
+				a++
+				b++
+			}
+		}
+		if dot == 0 {
+			continue
+		}
+		scores[j] = dot / (floats.Norm(data, 2) * qnorm)
+	}
+	return scores
+}
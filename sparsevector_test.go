@@ -0,0 +1,111 @@
+package nlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestSparseVectorConversions(t *testing.T) {
+	dense := mat64.NewVector(6, []float64{0, 2, 0, 0, -1, 3})
+
+	var tests = []struct {
+		name   string
+		vector *SparseVector
+	}{
+		{"NewSparseVector", NewSparseVector(6, []int{1, 4, 5}, []float64{2, -1, 3})},
+		{"NewSparseVectorFromMap", NewSparseVectorFromMap(6, map[int]float64{5: 3, 1: 2, 4: -1, 0: 0})},
+		{"NewSparseVectorFrom", NewSparseVectorFrom(dense)},
+	}
+
+	for _, test := range tests {
+		if test.vector.Len() != 6 || test.vector.NNZ() != 3 {
+			t.Errorf("%s: Expected length 6 with 3 non zero elements but found %d and %d",
+				test.name, test.vector.Len(), test.vector.NNZ())
+		}
+		if !mat64.Equal(dense, test.vector.ToVector()) {
+			t.Errorf("%s: Expected %v but found %v", test.name, dense.RawVector().Data, test.vector.ToVector().RawVector().Data)
+		}
+		if !mat64.Equal(dense, test.vector) {
+			t.Errorf("%s: Expected elements accessed with At() to equal %v", test.name, dense.RawVector().Data)
+		}
+	}
+}
+
+func TestSparseVectorCosineSimilarity(t *testing.T) {
+	docs := mat64.NewDense(5, 4, []float64{
+		1, 0, 3, 0,
+		0, 2, 1, 0,
+		4, 0, 0, 0,
+		0, 1, 2, 0,
+		2, 0, 0, 0,
+	})
+
+	queries := []*mat64.Vector{
+		mat64.NewVector(5, []float64{1, 0, 0, 0, 0}),
+		mat64.NewVector(5, []float64{0, 1.5, 0, 0.5, 0}),
+		mat64.NewVector(5, []float64{2, 1, 1, 1, 1}),
+		mat64.NewVector(5, []float64{0, 0, 0, 0, 0}),
+	}
+
+	for qi, query := range queries {
+		sparse := NewSparseVectorFrom(query)
+		for j := 0; j < 4; j++ {
+			col := docs.ColView(j)
+			expected := CosineSimilarity(query, col)
+			if similarity := sparse.CosineSimilarity(col); math.Abs(similarity-expected) > 0.000001 {
+				t.Errorf("Query %d: Expected similarity %f with document %d but found %f", qi, expected, j, similarity)
+			}
+			if dot := sparse.Dot(col); math.Abs(dot-mat64.Dot(query, col)) > 0.000001 {
+				t.Errorf("Query %d: Expected dot product %f with document %d but found %f", qi, mat64.Dot(query, col), j, dot)
+			}
+		}
+	}
+}
+
+func TestSparseCosineScores(t *testing.T) {
+	docs := mat64.NewDense(5, 4, []float64{
+		1, 0, 3, 0,
+		0, 2, 1, 0,
+		4, 0, 0, 0,
+		0, 1, 2, 0,
+		2, 0, 0, 0,
+	})
+	index := NewSparseMatrixFrom(docs)
+
+	queries := []*mat64.Vector{
+		mat64.NewVector(5, []float64{1, 0, 0, 0, 0}),
+		mat64.NewVector(5, []float64{0, 1.5, 0, 0.5, 0}),
+		mat64.NewVector(5, []float64{2, 1, 1, 1, 1}),
+		mat64.NewVector(5, []float64{0, 0, 0, 0, 0}),
+		mat64.NewVector(5, []float64{0, 0, 0, 0, -1}),
+	}
+
+	for qi, query := range queries {
+		scores := SparseCosineScores(index, NewSparseVectorFrom(query))
+		if len(scores) != 4 {
+			t.Errorf("Query %d: Expected 4 scores but found %d", qi, len(scores))
+			continue
+		}
+		for j := 0; j < 4; j++ {
+			if expected := CosineSimilarity(query, docs.ColView(j)); math.Abs(scores[j]-expected) > 0.000001 {
+				t.Errorf("Query %d: Expected score %f for document %d but found %f", qi, expected, j, scores[j])
+			}
+		}
+	}
+
+	if scores := SparseCosineScores(index, NewSparseVector(4, nil, nil)); scores != nil {
+		t.Errorf("Expected nil for mismatched query length but found %v", scores)
+	}
+}
+
+func BenchmarkSparseCosineScores50000x10000(b *testing.B) {
+	index := randomSparseTermDocMatrix(50000, 10000, 100)
+	query := NewSparseVectorFromMap(50000, map[int]float64{7: 1, 1009: 2, 23456: 1, 49999: 3})
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		SparseCosineScores(index, query)
+	}
+}
@@ -0,0 +1,250 @@
+package nlp
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// RowSums returns the sum of each row of the supplied matrix.  For a term document matrix
+// of term counts this is the total number of occurances of each term across all the
+// documents.  If the supplied matrix is a *SparseMatrix, only its non zero elements are
+// visited.
+func RowSums(mat mat64.Matrix) []float64 {
+	m, n := mat.Dims()
+	sums := make([]float64, m)
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			sums[i] += v
+		})
+		return sums
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			sums[i] += mat.At(i, j)
+		}
+	}
+	return sums
+}
+
+// ColSums returns the sum of each column of the supplied matrix.  For a term document
+// matrix of term counts this is the total number of terms within each document.  If the
+// supplied matrix is a *SparseMatrix, only its non zero elements are visited.
+func ColSums(mat mat64.Matrix) []float64 {
+	m, n := mat.Dims()
+	sums := make([]float64, n)
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			sums[j] += v
+		})
+		return sums
+	}
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			sums[j] += mat.At(i, j)
+		}
+	}
+	return sums
+}
+
+// TermFrequency is a term along with its total frequency across a corpus
+type TermFrequency struct {
+	Term      string
+	Frequency float64
+}
+
+// MostFrequentTerms returns the k terms with the highest total frequency (row sum) within
+// the supplied term document matrix, in descending order of frequency with ties broken
+// alphabetically.  vocabulary maps each term to its row within the matrix, as built by
+// CountVectoriser, and terms mapping to rows outside the matrix are ignored.  If k exceeds
+// the number of terms then all the terms are returned.
+func MostFrequentTerms(mat mat64.Matrix, vocabulary map[string]int, k int) []TermFrequency {
+	sums := RowSums(mat)
+
+	terms := make([]TermFrequency, 0, len(vocabulary))
+	for term, i := range vocabulary {
+		if i >= 0 && i < len(sums) {
+			terms = append(terms, TermFrequency{Term: term, Frequency: sums[i]})
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Frequency != terms[j].Frequency {
+			return terms[i].Frequency > terms[j].Frequency
+		}
+		return terms[i].Term < terms[j].Term
+	})
+
+	if k < 0 {
+		k = 0
+	}
+	if k < len(terms) {
+		terms = terms[:k]
+	}
+	return terms
+}
+
+// DocFreqPercentiles returns the document frequency (the number of documents, or columns,
+// in which a term is non zero) at each of the requested percentiles of the distribution of
+// document frequencies across all the terms (rows) of the supplied term document matrix.
+// This may be used to choose data driven values for the MinDF and MaxDF pruning thresholds
+// of CountVectoriser e.g. the 99th percentile as a MaxDF.  Percentiles are specified in the
+// range 0 to 100 and calculated using the nearest rank method so that each returned value
+// is a document frequency of at least one term: the pth percentile is the smallest document
+// frequency such that at least p% of terms have a document frequency less than or equal to
+// it, with the 0th percentile being the minimum.  If the matrix has no rows or any
+// percentile is outside the range 0 to 100 then nil is returned.
+func DocFreqPercentiles(mat mat64.Matrix, percentiles []float64) []int {
+	m, _ := mat.Dims()
+	if m == 0 {
+		return nil
+	}
+	for _, p := range percentiles {
+		if !(p >= 0 && p <= 100) {
+			return nil
+		}
+	}
+
+	df := make([]float64, m)
+	documentFrequencies(mat, df, 0)
+	sort.Float64s(df)
+
+	values := make([]int, len(percentiles))
+	for i, p := range percentiles {
+		rank := int(math.Ceil(p / 100 * float64(m)))
+		values[i] = int(df[max(rank, 1)-1])
+	}
+	return values
+}
+
+// MutualInformation returns the mutual information between the presence of each term
+// (row) of the supplied term document matrix and the class labels of the documents
+// (columns), where labels[j] is the class of document j.  Terms are treated as present in
+// a document if their value is non zero.  The mutual information (in nats) measures how
+// much knowing whether a term occurs in a document reduces uncertainty about the
+// document's class and so may be used to rank terms for feature selection, retaining the
+// most informative terms.  Terms occuring in every document or in none have a score of 0.
+// If the length of labels differs from the number of documents then nil is returned.
+func MutualInformation(mat mat64.Matrix, labels []int) []float64 {
+	m, n := mat.Dims()
+	if len(labels) != n {
+		return nil
+	}
+
+	docClass, classDocs := classIndices(labels)
+
+	// count the documents of each class containing each term
+	present := make([][]float64, m)
+	for i := range present {
+		present[i] = make([]float64, len(classDocs))
+	}
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			present[i][docClass[j]]++
+		})
+	} else {
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				if mat.At(i, j) != 0 {
+					present[i][docClass[j]]++
+				}
+			}
+		}
+	}
+
+	scores := make([]float64, m)
+	total := float64(n)
+	for i, counts := range present {
+		var df float64
+		for _, count := range counts {
+			df += count
+		}
+		for c, count := range counts {
+			scores[i] += miTerm(count, df, classDocs[c], total)
+			scores[i] += miTerm(classDocs[c]-count, total-df, classDocs[c], total)
+		}
+	}
+	return scores
+}
+
+// miTerm returns the contribution to the mutual information of the joint event occuring
+// in joint of total documents, where the marginal events occur in a and b documents.
+func miTerm(joint, a, b, total float64) float64 {
+	if joint == 0 {
+		return 0
+	}
+	return (joint / total) * math.Log(joint*total/(a*b))
+}
+
+// classIndices maps the supplied class labels to contiguous class indices, returning the
+// class index of each document and the number of documents within each class.
+func classIndices(labels []int) ([]int, []float64) {
+	classes := make(map[int]int)
+	docClass := make([]int, len(labels))
+	var classDocs []float64
+	for j, label := range labels {
+		c, ok := classes[label]
+		if !ok {
+			c = len(classes)
+			classes[label] = c
+			classDocs = append(classDocs, 0)
+		}
+		docClass[j] = c
+		classDocs[c]++
+	}
+	return docClass, classDocs
+}
+
+// ChiSquared returns the chi-squared statistic between each term (row) of the supplied term
+// document matrix and the class labels of the documents (columns), where labels[j] is the
+// class of document j.  Consistent with the chi-squared feature selection of scikit-learn,
+// the observed frequency of a term within a class is the sum of its values across the
+// documents of that class and the expected frequency is the total of its values across all
+// documents scaled by the proportion of documents within the class.  Higher scores indicate
+// terms whose distribution depends more strongly upon the class and so may be used to rank
+// terms for feature selection.  Values should be non negative e.g. counts or tf-idf
+// weights.  Classes with no documents and terms that never occur contribute nothing to the
+// scores.  If the length of labels differs from the number of documents then nil is
+// returned.
+func ChiSquared(mat mat64.Matrix, labels []int) []float64 {
+	m, n := mat.Dims()
+	if len(labels) != n {
+		return nil
+	}
+
+	docClass, classDocs := classIndices(labels)
+
+	// sum the values of each term across the documents of each class
+	observed := make([][]float64, m)
+	for i := range observed {
+		observed[i] = make([]float64, len(classDocs))
+	}
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			observed[i][docClass[j]] += v
+		})
+	} else {
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				observed[i][docClass[j]] += mat.At(i, j)
+			}
+		}
+	}
+
+	scores := make([]float64, m)
+	for i, counts := range observed {
+		var total float64
+		for _, count := range counts {
+			total += count
+		}
+		for c, count := range counts {
+			expected := total * classDocs[c] / float64(n)
+			if expected == 0 {
+				continue
+			}
+			diff := count - expected
+			scores[i] += diff * diff / expected
+		}
+	}
+	return scores
+}
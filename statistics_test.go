@@ -0,0 +1,175 @@
+package nlp
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestRowColSums(t *testing.T) {
+	dense := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 3, 0, 0,
+		4, 1, 1, 1,
+	})
+
+	var tests = []struct {
+		name  string
+		input mat64.Matrix
+	}{
+		{"Dense", dense},
+		{"Sparse", NewSparseMatrixFrom(dense)},
+	}
+
+	for _, test := range tests {
+		if sums := RowSums(test.input); !reflect.DeepEqual(sums, []float64{3, 3, 7}) {
+			t.Errorf("%s: Expected row sums [3 3 7] but found %v", test.name, sums)
+		}
+		if sums := ColSums(test.input); !reflect.DeepEqual(sums, []float64{5, 4, 3, 1}) {
+			t.Errorf("%s: Expected column sums [5 4 3 1] but found %v", test.name, sums)
+		}
+	}
+}
+
+func TestMostFrequentTerms(t *testing.T) {
+	mat := mat64.NewDense(4, 2, []float64{
+		1, 0,
+		2, 3,
+		0, 1,
+		1, 0,
+	})
+	vocabulary := map[string]int{"fox": 0, "the": 1, "dog": 2, "cat": 3}
+
+	var tests = []struct {
+		k        int
+		expected []TermFrequency
+	}{
+		{2, []TermFrequency{{"the", 5}, {"cat", 1}}},
+		{0, []TermFrequency{}},
+		{10, []TermFrequency{{"the", 5}, {"cat", 1}, {"dog", 1}, {"fox", 1}}},
+	}
+
+	for _, test := range tests {
+		if terms := MostFrequentTerms(mat, vocabulary, test.k); !reflect.DeepEqual(terms, test.expected) {
+			t.Errorf("k = %d: Expected %v but found %v", test.k, test.expected, terms)
+		}
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	counts, _ := vectoriser.FitTransform("the quick fox", "the lazy dog", "the fox")
+	terms := MostFrequentTerms(counts, vectoriser.Vocabulary, 2)
+	if len(terms) != 2 || terms[0] != (TermFrequency{"the", 3}) || terms[1] != (TermFrequency{"fox", 2}) {
+		t.Errorf("Expected [{the 3} {fox 2}] but found %v", terms)
+	}
+}
+
+func TestDocFreqPercentiles(t *testing.T) {
+	// 10 terms with document frequencies of 1 to 10 (in reverse order) across 10 documents
+	mat := mat64.NewDense(10, 10, nil)
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10-i; j++ {
+			mat.Set(i, j, float64(j+1))
+		}
+	}
+
+	var tests = []struct {
+		percentiles []float64
+		expected    []int
+	}{
+		{percentiles: []float64{0, 10, 25, 50, 90, 100}, expected: []int{1, 1, 3, 5, 9, 10}},
+		{percentiles: []float64{99.9, 0.1}, expected: []int{10, 1}},
+		{percentiles: []float64{}, expected: []int{}},
+		{percentiles: []float64{50, 101}, expected: nil},
+		{percentiles: []float64{-1}, expected: nil},
+		{percentiles: []float64{math.NaN()}, expected: nil},
+	}
+
+	for _, test := range tests {
+		for _, input := range []mat64.Matrix{mat, NewSparseMatrixFrom(mat)} {
+			if result := DocFreqPercentiles(input, test.percentiles); !reflect.DeepEqual(test.expected, result) {
+				t.Errorf("Expected document frequencies %v at percentiles %v but found %v", test.expected, test.percentiles, result)
+			}
+		}
+	}
+}
+
+func TestMutualInformation(t *testing.T) {
+	mat := mat64.NewDense(4, 4, []float64{
+		// perfectly predicts the label
+		2, 1, 0, 0,
+		// occurs in every document
+		1, 1, 3, 1,
+		// independent of the label
+		1, 0, 1, 0,
+		// occurs in a single document
+		0, 0, 0, 4,
+	})
+	labels := []int{7, 7, 3, 3}
+
+	// H(C) - H(C|X) where the single document term leaves 1 of 3 documents uncertain
+	single := math.Log(2) - 0.75*(-(1/3.0)*math.Log(1/3.0)-(2/3.0)*math.Log(2/3.0))
+	expected := []float64{math.Log(2), 0, 0, single}
+
+	for _, input := range []mat64.Matrix{mat, NewSparseMatrixFrom(mat)} {
+		scores := MutualInformation(input, labels)
+		if len(scores) != len(expected) {
+			t.Errorf("Expected %d scores but found %v", len(expected), scores)
+			continue
+		}
+		for i, score := range scores {
+			if math.Abs(score-expected[i]) > 0.000001 {
+				t.Errorf("Expected score %f for term %d but found %f", expected[i], i, score)
+			}
+		}
+		for i := 1; i < len(scores); i++ {
+			if scores[i] >= scores[0] {
+				t.Errorf("Expected the perfectly predictive term to score highest but found %v", scores)
+			}
+		}
+	}
+
+	if scores := MutualInformation(mat, labels[:3]); scores != nil {
+		t.Errorf("Expected nil for mismatched labels but found %v", scores)
+	}
+}
+
+func TestChiSquared(t *testing.T) {
+	mat := mat64.NewDense(5, 4, []float64{
+		// strongly associated with the first class
+		2, 1, 0, 0,
+		// occurs more frequently in the second class
+		1, 1, 3, 1,
+		// independent of the label
+		1, 0, 1, 0,
+		// occurs in a single document
+		0, 0, 0, 1,
+		// never occurs
+		0, 0, 0, 0,
+	})
+	labels := []int{7, 7, 3, 3}
+	expected := []float64{3, 2 / 3.0, 0, 1, 0}
+
+	for _, input := range []mat64.Matrix{mat, NewSparseMatrixFrom(mat)} {
+		scores := ChiSquared(input, labels)
+		if len(scores) != len(expected) {
+			t.Errorf("Expected %d scores but found %v", len(expected), scores)
+			continue
+		}
+		for i, score := range scores {
+			if math.Abs(score-expected[i]) > 0.000001 {
+				t.Errorf("Expected score %f for term %d but found %f", expected[i], i, score)
+			}
+		}
+		for i := 1; i < len(scores); i++ {
+			if scores[i] >= scores[0] {
+				t.Errorf("Expected the strongly associated term to score highest but found %v", scores)
+			}
+		}
+	}
+
+	if scores := ChiSquared(mat, labels[:3]); scores != nil {
+		t.Errorf("Expected nil for mismatched labels but found %v", scores)
+	}
+}
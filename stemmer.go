@@ -0,0 +1,325 @@
+package nlp
+
+// PorterStem reduces the supplied (lower case, English) word to its stem using the Porter
+// stemming algorithm e.g. "running", "runs" and "run" are all reduced to "run".  Stemming
+// allows words sharing a common root to be treated as the same term.  Stems are not
+// necessarily valid words themselves e.g. "happy" is reduced to "happi".  Words of 2 or fewer
+// characters, or containing characters other than the lower case letters a-z, are returned
+// unchanged.  PorterStem may be assigned to the Stemmer field of a vectoriser to stem terms
+// during vectorisation.
+//
+// This is an implementation of the algorithm described in: Porter, M.F. An algorithm for
+// suffix stripping. Program 14(3), 1980, following the reference implementation published
+// by the author.
+func PorterStem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	z := &porterStemmer{b: []byte(word), k: len(word) - 1}
+	z.step1ab()
+	if z.k > 0 {
+		z.step1c()
+		z.step2()
+		z.step3()
+		z.step4()
+		z.step5()
+	}
+	return string(z.b[:z.k+1])
+}
+
+// porterStemmer holds the working state of a word being stemmed where b[0:k+1] is the
+// current word and j is a general offset into the word marking the end of the stem
+// preceding a matched suffix.
+type porterStemmer struct {
+	b    []byte
+	k, j int
+}
+
+// cons returns true if b[i] is a consonant
+func (z *porterStemmer) cons(i int) bool {
+	switch z.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == 0 {
+			return true
+		}
+		return !z.cons(i - 1)
+	}
+	return true
+}
+
+// m measures the number of consonant sequences in b[0:j+1].  If c is a consonant sequence
+// and v a vowel sequence, and <..> indicates arbitrary presence,
+//
+//	<c><v>       gives 0
+//	<c>vc<v>     gives 1
+//	<c>vcvc<v>   gives 2
+//	<c>vcvcvc<v> gives 3
+//	....
+func (z *porterStemmer) m() int {
+	n, i := 0, 0
+	for {
+		if i > z.j {
+			return n
+		}
+		if !z.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > z.j {
+				return n
+			}
+			if z.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > z.j {
+				return n
+			}
+			if !z.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem returns true if b[0:j+1] contains a vowel
+func (z *porterStemmer) vowelInStem() bool {
+	for i := 0; i <= z.j; i++ {
+		if !z.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doublec returns true if b[j-1:j+1] is a double consonant
+func (z *porterStemmer) doublec(j int) bool {
+	if j < 1 || z.b[j] != z.b[j-1] {
+		return false
+	}
+	return z.cons(j)
+}
+
+// cvc returns true if b[i-2:i+1] is consonant - vowel - consonant and the second consonant
+// is not w, x or y.  This is used when trying to restore an e at the end of a short word
+// e.g. cav(e), lov(e), hop(e), crim(e), but snow, box, tray.
+func (z *porterStemmer) cvc(i int) bool {
+	if i < 2 || !z.cons(i) || z.cons(i-1) || !z.cons(i-2) {
+		return false
+	}
+	switch z.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends returns true if b[0:k+1] ends with the string s, setting j to the end of the
+// preceding stem
+func (z *porterStemmer) ends(s string) bool {
+	l := len(s)
+	if l > z.k+1 || string(z.b[z.k-l+1:z.k+1]) != s {
+		return false
+	}
+	z.j = z.k - l
+	return true
+}
+
+// setto replaces b[j+1:k+1] with the string s, readjusting k
+func (z *porterStemmer) setto(s string) {
+	z.b = append(z.b[:z.j+1], s...)
+	z.k = z.j + len(s)
+}
+
+// r replaces the matched suffix with s if the preceding stem has a measure greater than 0
+func (z *porterStemmer) r(s string) {
+	if z.m() > 0 {
+		z.setto(s)
+	}
+}
+
+// replaceFirst finds the first of the suffixes (pairs of suffix and replacement) matching
+// the end of the word and replaces it using r
+func (z *porterStemmer) replaceFirst(suffixes ...string) {
+	for i := 0; i < len(suffixes); i += 2 {
+		if z.ends(suffixes[i]) {
+			z.r(suffixes[i+1])
+			return
+		}
+	}
+}
+
+// step1ab removes plurals and -ed or -ing e.g.
+//
+//	caresses  ->  caress
+//	ponies    ->  poni
+//	ties      ->  ti
+//	caress    ->  caress
+//	cats      ->  cat
+//
+//	feed      ->  feed
+//	agreed    ->  agree
+//	disabled  ->  disable
+//
+//	matting   ->  mat
+//	mating    ->  mate
+//	meeting   ->  meet
+//	milling   ->  mill
+//	messing   ->  mess
+//
+//	meetings  ->  meet
+func (z *porterStemmer) step1ab() {
+	if z.b[z.k] == 's' {
+		if z.ends("sses") {
+			z.k -= 2
+		} else if z.ends("ies") {
+			z.setto("i")
+		} else if z.b[z.k-1] != 's' {
+			z.k--
+		}
+	}
+	if z.ends("eed") {
+		if z.m() > 0 {
+			z.k--
+		}
+	} else if (z.ends("ed") || z.ends("ing")) && z.vowelInStem() {
+		z.k = z.j
+		if z.ends("at") {
+			z.setto("ate")
+		} else if z.ends("bl") {
+			z.setto("ble")
+		} else if z.ends("iz") {
+			z.setto("ize")
+		} else if z.doublec(z.k) {
+			z.k--
+			switch z.b[z.k] {
+			case 'l', 's', 'z':
+				z.k++
+			}
+		} else if z.m() == 1 && z.cvc(z.k) {
+			z.setto("e")
+		}
+	}
+}
+
+// step1c turns terminal y to i when there is another vowel in the stem
+func (z *porterStemmer) step1c() {
+	if z.ends("y") && z.vowelInStem() {
+		z.b[z.k] = 'i'
+	}
+}
+
+// step2 maps double suffixes to single ones e.g. -ization ( = -ize plus -ation) maps to
+// -ize.  The stem preceding the suffix must have a measure greater than 0.
+func (z *porterStemmer) step2() {
+	switch z.b[z.k-1] {
+	case 'a':
+		z.replaceFirst("ational", "ate", "tional", "tion")
+	case 'c':
+		z.replaceFirst("enci", "ence", "anci", "ance")
+	case 'e':
+		z.replaceFirst("izer", "ize")
+	case 'l':
+		z.replaceFirst("bli", "ble", "alli", "al", "entli", "ent", "eli", "e", "ousli", "ous")
+	case 'o':
+		z.replaceFirst("ization", "ize", "ation", "ate", "ator", "ate")
+	case 's':
+		z.replaceFirst("alism", "al", "iveness", "ive", "fulness", "ful", "ousness", "ous")
+	case 't':
+		z.replaceFirst("aliti", "al", "iviti", "ive", "biliti", "ble")
+	case 'g':
+		z.replaceFirst("logi", "log")
+	}
+}
+
+// step3 deals with -ic-, -full, -ness etc. using a similar strategy to step2
+func (z *porterStemmer) step3() {
+	switch z.b[z.k] {
+	case 'e':
+		z.replaceFirst("icate", "ic", "ative", "", "alize", "al")
+	case 'i':
+		z.replaceFirst("iciti", "ic")
+	case 'l':
+		z.replaceFirst("ical", "ic", "ful", "")
+	case 's':
+		z.replaceFirst("ness", "")
+	}
+}
+
+// step4 removes -ant, -ence etc. where the preceding stem has a measure greater than 1
+func (z *porterStemmer) step4() {
+	var suffixes []string
+	switch z.b[z.k-1] {
+	case 'a':
+		suffixes = []string{"al"}
+	case 'c':
+		suffixes = []string{"ance", "ence"}
+	case 'e':
+		suffixes = []string{"er"}
+	case 'i':
+		suffixes = []string{"ic"}
+	case 'l':
+		suffixes = []string{"able", "ible"}
+	case 'n':
+		suffixes = []string{"ant", "ement", "ment", "ent"}
+	case 'o':
+		suffixes = []string{"ion", "ou"}
+	case 's':
+		suffixes = []string{"ism"}
+	case 't':
+		suffixes = []string{"ate", "iti"}
+	case 'u':
+		suffixes = []string{"ous"}
+	case 'v':
+		suffixes = []string{"ive"}
+	case 'z':
+		suffixes = []string{"ize"}
+	}
+
+	for _, suffix := range suffixes {
+		if !z.ends(suffix) {
+			continue
+		}
+		// -ion is only removed when preceded by s or t
+		if suffix == "ion" && (z.j < 0 || (z.b[z.j] != 's' && z.b[z.j] != 't')) {
+			continue
+		}
+		if z.m() > 1 {
+			z.k = z.j
+		}
+		return
+	}
+}
+
+// step5 removes a final -e if the measure is greater than 1 (or 1 and not preceded by cvc)
+// and changes -ll to -l if the measure is greater than 1
+func (z *porterStemmer) step5() {
+	z.j = z.k
+	if z.b[z.k] == 'e' {
+		a := z.m()
+		if a > 1 || a == 1 && !z.cvc(z.k-1) {
+			z.k--
+		}
+	}
+	if z.b[z.k] == 'l' && z.doublec(z.k) && z.m() > 1 {
+		z.k--
+	}
+}
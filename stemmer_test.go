@@ -0,0 +1,124 @@
+package nlp
+
+import "testing"
+
+func TestPorterStem(t *testing.T) {
+	var tests = []struct {
+		word string
+		stem string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"ties", "ti"},
+		{"caress", "caress"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"tanned", "tan"},
+		{"falling", "fall"},
+		{"hissing", "hiss"},
+		{"fizzed", "fizz"},
+		{"failing", "fail"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"digitizer", "digit"},
+		{"generalization", "gener"},
+		{"hopefulness", "hope"},
+		{"electrical", "electr"},
+		{"adjustment", "adjust"},
+		{"adoption", "adopt"},
+		{"controll", "control"},
+		{"generate", "gener"},
+		{"is", "is"},
+		{"go2", "go2"},
+	}
+
+	for _, test := range tests {
+		if stem := PorterStem(test.word); stem != test.stem {
+			t.Errorf("Expected stem '%s' for word '%s' but found '%s'", test.stem, test.word, stem)
+		}
+	}
+}
+
+func TestPorterStemWordFamilies(t *testing.T) {
+	var families = [][]string{
+		{"connect", "connected", "connecting", "connection", "connections"},
+		{"run", "runs", "running"},
+		{"generalize", "generalization", "generalizations"},
+	}
+
+	for _, family := range families {
+		stem := PorterStem(family[0])
+		for _, word := range family[1:] {
+			if s := PorterStem(word); s != stem {
+				t.Errorf("Expected '%s' to have the same stem as '%s' ('%s') but found '%s'", word, family[0], stem, s)
+			}
+		}
+	}
+}
+
+func TestCountVectoriserStemming(t *testing.T) {
+	docs := []string{
+		"the connection connected",
+		"connecting the runners running",
+		"runs",
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.Fit(docs...)
+	unstemmed := len(vectoriser.Vocabulary)
+
+	vectoriser.Stemmer = PorterStem
+	mat, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+
+	// the, connect, runner, run
+	if len(vectoriser.Vocabulary) != 4 || len(vectoriser.Vocabulary) >= unstemmed {
+		t.Errorf("Expected stemmed vocabulary of 4 terms (smaller than %d unstemmed) but found %v",
+			unstemmed, vectoriser.Vocabulary)
+	}
+
+	var tests = []struct {
+		term   string
+		counts []float64
+	}{
+		{"connect", []float64{2, 1, 0}},
+		{"run", []float64{0, 1, 1}},
+		{"runner", []float64{0, 1, 0}},
+	}
+
+	for _, test := range tests {
+		i, ok := vectoriser.Vocabulary[test.term]
+		if !ok {
+			t.Errorf("Expected term '%s' in vocabulary %v", test.term, vectoriser.Vocabulary)
+			continue
+		}
+		for j, count := range test.counts {
+			if mat.At(i, j) != count {
+				t.Errorf("Expected count %f for term '%s' in document %d but found %f",
+					count, test.term, j, mat.At(i, j))
+			}
+		}
+	}
+
+	// stop words are removed before stemming so "others" is removed rather than stemmed
+	// to "other"
+	vectoriser = NewCountVectoriserWithStopWords("others")
+	vectoriser.Stemmer = PorterStem
+	vectoriser.Fit("others running")
+	if _, ok := vectoriser.Vocabulary["other"]; ok || len(vectoriser.Vocabulary) != 1 {
+		t.Errorf("Expected stop words to be removed before stemming but found %v", vectoriser.Vocabulary)
+	}
+}
@@ -0,0 +1,231 @@
+package nlp
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Tokeniser is the interface for types that split text into a sequence of tokens (typically
+// words).  Vectorisers use a Tokeniser to extract terms from documents and custom
+// implementations may be supplied to support different languages or domains e.g. retaining
+// hashtags.
+type Tokeniser interface {
+	// Tokenise splits the supplied text into tokens
+	Tokenise(text string) []string
+}
+
+// RegExpTokeniser tokenises text by extracting all the (non overlapping) matches of a
+// regular expression from the text.
+type RegExpTokeniser struct {
+	pattern *regexp.Regexp
+}
+
+// NewRegExpTokeniser creates a new RegExpTokeniser extracting tokens matching the specified
+// regular expression pattern e.g. `\w+` will extract words (sequences of letters, digits
+// and underscores) discarding punctuation and whitespace.  An error is returned if the
+// pattern is not a valid regular expression.
+func NewRegExpTokeniser(pattern string) (*RegExpTokeniser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegExpTokeniser{pattern: re}, nil
+}
+
+// Tokenise splits the supplied text into tokens matching the tokeniser's regular expression
+func (t *RegExpTokeniser) Tokenise(text string) []string {
+	return t.pattern.FindAllString(text, -1)
+}
+
+// WhitespaceTokeniser tokenises text by splitting it around each sequence of one or more
+// whitespace characters.  Punctuation is retained as part of the tokens.
+type WhitespaceTokeniser struct{}
+
+// NewWhitespaceTokeniser creates a new WhitespaceTokeniser
+func NewWhitespaceTokeniser() *WhitespaceTokeniser {
+	return &WhitespaceTokeniser{}
+}
+
+// Tokenise splits the supplied text into tokens separated by whitespace
+func (t *WhitespaceTokeniser) Tokenise(text string) []string {
+	return strings.Fields(text)
+}
+
+// UnicodeTokeniser tokenises text into words for any script, using the unicode package to
+// classify characters rather than assuming ASCII.  A word is a maximal sequence of letters,
+// decimal digits, combining marks and underscores so that accented letters, whether
+// precomposed (e.g. "é") or formed from a letter followed by combining marks (e.g. "e\u0301"),
+// remain part of their words and non-Latin words (e.g. Greek, Cyrillic or Arabic) are
+// extracted intact.  All other characters, including whitespace and punctuation of any width
+// (e.g. the full-width "，" and "。" used in CJK text), separate words and are discarded.
+// Apostrophes and hyphens are punctuation so "l'été" yields "l" and "été".  Scripts written
+// without spaces between words (e.g. Chinese and Japanese) cannot be segmented this way and
+// each run of such characters forms a single token so, for these languages, the
+// CharAnalyser or CharWordBoundaryAnalyser should be used instead.  For ASCII text the
+// tokens are the same as those of the regular expression `\w+`.
+type UnicodeTokeniser struct{}
+
+// NewUnicodeTokeniser creates a new UnicodeTokeniser
+func NewUnicodeTokeniser() *UnicodeTokeniser {
+	return &UnicodeTokeniser{}
+}
+
+// Tokenise splits the supplied text into words discarding whitespace and punctuation
+func (t *UnicodeTokeniser) Tokenise(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// isWordRune returns true if r may form part of a word i.e. it is a letter, decimal digit,
+// combining mark or underscore.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// WeightedToken is a token along with the weight with which it should contribute to the term
+// counts of a document e.g. to weight words appearing within the title of a document more
+// heavily than those in the body.
+type WeightedToken struct {
+	Term   string
+	Weight float64
+}
+
+// WeightedTokeniser is a Tokeniser that may also weight the tokens it produces.  When the
+// Tokeniser of a CountVectoriser implements WeightedTokeniser, Transform() adds the weight
+// of each token to its count rather than 1.  TokeniseWeighted must produce the same tokens,
+// in the same order, as Tokenise.
+type WeightedTokeniser interface {
+	Tokeniser
+
+	// TokeniseWeighted splits the supplied text into weighted tokens
+	TokeniseWeighted(text string) []WeightedToken
+}
+
+// PositionalTokeniser is a WeightedTokeniser that wraps another Tokeniser, weighting tokens
+// according to their position within the text.  The first Leading tokens are weighted by
+// Weight and all subsequent tokens by 1 so that, for example, documents formed by
+// prepending a title of a known number of words to the body text may emphasise the words
+// of the title.  Positions are those of the tokens produced by the wrapped Tokeniser, prior
+// to stop word removal.
+type PositionalTokeniser struct {
+	Tokeniser Tokeniser
+	Leading   int
+	Weight    float64
+}
+
+// NewPositionalTokeniser creates a new PositionalTokeniser weighting the first leading of the
+// tokens produced by tokeniser (or the default tokeniser used by vectorisers if nil) by
+// weight e.g.
+//
+//	vectoriser := NewCountVectoriser(true)
+//	vectoriser.Tokeniser = NewPositionalTokeniser(nil, 10, 3)
+func NewPositionalTokeniser(tokeniser Tokeniser, leading int, weight float64) *PositionalTokeniser {
+	if tokeniser == nil {
+		tokeniser = newDefaultTokeniser()
+	}
+	return &PositionalTokeniser{Tokeniser: tokeniser, Leading: leading, Weight: weight}
+}
+
+// Tokenise splits the supplied text into tokens using the wrapped Tokeniser
+func (t *PositionalTokeniser) Tokenise(text string) []string {
+	return t.Tokeniser.Tokenise(text)
+}
+
+// TokeniseWeighted splits the supplied text into tokens using the wrapped Tokeniser,
+// weighting the first Leading tokens by Weight and the remainder by 1
+func (t *PositionalTokeniser) TokeniseWeighted(text string) []WeightedToken {
+	tokens := t.Tokeniser.Tokenise(text)
+	weighted := make([]WeightedToken, len(tokens))
+	for i, token := range tokens {
+		weighted[i] = WeightedToken{Term: token, Weight: 1}
+		if i < t.Leading {
+			weighted[i].Weight = t.Weight
+		}
+	}
+	return weighted
+}
+
+// newDefaultTokeniser creates the Tokeniser used by vectorisers by default, extracting words
+// of any script and discarding punctuation and whitespace.
+func newDefaultTokeniser() Tokeniser {
+	return NewUnicodeTokeniser()
+}
+
+var (
+	negationWords = []string{"not", "no", "never", "nor", "cannot", "without", "nothing", "nobody", "none", "neither"}
+
+	// clauseBoundaries are the punctuation marks ending the scope of a negation
+	clauseBoundaries = regexp.MustCompile(`[.,;:!?]+`)
+)
+
+// EnglishNegationWords returns a list of common English negation words (e.g. "not",
+// "never") for use with NegationTokeniser.  The returned slice is a copy and may be
+// modified/extended by the caller.
+func EnglishNegationWords() []string {
+	words := make([]string, len(negationWords))
+	copy(words, negationWords)
+	return words
+}
+
+// NegationTokeniser is a Tokeniser filter that wraps another Tokeniser to mark the scope of
+// negations in text.  Each token following a negation word (e.g. "not") is prefixed with
+// "not_" up until the next punctuation mark (one of . , ; : ! ?) so that, for example,
+// "this is not good" is tokenised as "this", "is", "not", "not_good".  This preserves the
+// reversal of meaning caused by negations which would otherwise be lost e.g. for sentiment
+// analysis.  Words ending in "n't" (e.g. "don't") are also treated as negations although
+// the wrapped Tokeniser must retain apostrophes for them to be recognised.  As the text is
+// split on punctuation before it is passed to the wrapped Tokeniser, the wrapped Tokeniser
+// will not see any of the punctuation marks ending negation scopes.
+type NegationTokeniser struct {
+	tokeniser Tokeniser
+	negations map[string]struct{}
+}
+
+// NewNegationTokeniser creates a new NegationTokeniser marking negations within the tokens
+// produced by tokeniser (or the default tokeniser used by vectorisers if nil).  Tokens
+// matching any of the specified negation words (compared in lower case) begin a negation
+// scope.  If no negation words are specified, EnglishNegationWords() are used e.g.
+//
+//	vectoriser := NewCountVectoriser(true)
+//	vectoriser.Tokeniser = NewNegationTokeniser(nil)
+func NewNegationTokeniser(tokeniser Tokeniser, negations ...string) *NegationTokeniser {
+	if tokeniser == nil {
+		tokeniser = newDefaultTokeniser()
+	}
+	if len(negations) == 0 {
+		negations = negationWords
+	}
+	return &NegationTokeniser{tokeniser: tokeniser, negations: stopWordSet(negations)}
+}
+
+// Tokenise splits the supplied text into tokens using the wrapped Tokeniser, prefixing
+// tokens within the scope of a negation with "not_"
+func (t *NegationTokeniser) Tokenise(text string) []string {
+	var tokens []string
+	for _, clause := range clauseBoundaries.Split(text, -1) {
+		negated := false
+		for _, token := range t.tokeniser.Tokenise(clause) {
+			if t.isNegation(token) {
+				negated = true
+				tokens = append(tokens, token)
+				continue
+			}
+			if negated {
+				token = "not_" + token
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// isNegation returns true if the supplied token is a negation word
+func (t *NegationTokeniser) isNegation(token string) bool {
+	token = strings.ToLower(token)
+	if _, ok := t.negations[token]; ok {
+		return true
+	}
+	return strings.HasSuffix(token, "n't") || strings.HasSuffix(token, "n’t")
+}
@@ -0,0 +1,146 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenisers(t *testing.T) {
+	regexpTokeniser, err := NewRegExpTokeniser(`\w+`)
+	if err != nil {
+		t.Fatalf("Failed to create tokeniser caused by %v", err)
+	}
+	hashtagTokeniser, err := NewRegExpTokeniser(`#?\w+`)
+	if err != nil {
+		t.Fatalf("Failed to create tokeniser caused by %v", err)
+	}
+
+	var tests = []struct {
+		tokeniser Tokeniser
+		text      string
+		tokens    []string
+	}{
+		{regexpTokeniser, "The quick, brown fox.", []string{"The", "quick", "brown", "fox"}},
+		{regexpTokeniser, "#golang is great", []string{"golang", "is", "great"}},
+		{hashtagTokeniser, "#golang is great", []string{"#golang", "is", "great"}},
+		{NewWhitespaceTokeniser(), "The quick,  brown\tfox.", []string{"The", "quick,", "brown", "fox."}},
+		{NewWhitespaceTokeniser(), "   ", []string{}},
+	}
+
+	for _, test := range tests {
+		tokens := test.tokeniser.Tokenise(test.text)
+		if len(tokens) != len(test.tokens) || (len(tokens) > 0 && !reflect.DeepEqual(tokens, test.tokens)) {
+			t.Errorf("Expected tokens %v for '%s' but found %v", test.tokens, test.text, tokens)
+		}
+	}
+
+	if _, err := NewRegExpTokeniser(`(`); err == nil {
+		t.Errorf("Expected error creating tokeniser with invalid pattern but found none")
+	}
+}
+
+func TestUnicodeTokeniser(t *testing.T) {
+	tokeniser := NewUnicodeTokeniser()
+
+	var tests = []struct {
+		text   string
+		tokens []string
+	}{
+		{"The quick, brown fox_1.", []string{"The", "quick", "brown", "fox_1"}},
+		{"L'été à Noël, c'est déjà très froid!", []string{"L", "été", "à", "Noël", "c", "est", "déjà", "très", "froid"}},
+		// decomposed accents (letter followed by a combining mark) remain within the word
+		{"cafe\u0301 cre\u0300me", []string{"cafe\u0301", "cre\u0300me"}},
+		{"Москва and Αθήνα: 2020", []string{"Москва", "and", "Αθήνα", "2020"}},
+		// full-width punctuation separates words and unspaced CJK runs form single tokens
+		{"東京，大阪。Tokyo", []string{"東京", "大阪", "Tokyo"}},
+		{"Ｈｅｌｌｏ！ｗｏｒｌｄ", []string{"Ｈｅｌｌｏ", "ｗｏｒｌｄ"}},
+		{" \t…—", []string{}},
+	}
+
+	for _, test := range tests {
+		tokens := tokeniser.Tokenise(test.text)
+		if len(tokens) != len(test.tokens) || (len(tokens) > 0 && !reflect.DeepEqual(tokens, test.tokens)) {
+			t.Errorf("Expected tokens %q for '%s' but found %q", test.tokens, test.text, tokens)
+		}
+	}
+}
+
+func TestPositionalTokeniser(t *testing.T) {
+	tokeniser := NewPositionalTokeniser(nil, 2, 3)
+
+	expected := []WeightedToken{{"Title", 3}, {"words", 3}, {"body", 1}, {"text", 1}}
+	if tokens := tokeniser.TokeniseWeighted("Title words: body text"); !reflect.DeepEqual(expected, tokens) {
+		t.Errorf("Expected weighted tokens %v but found %v", expected, tokens)
+	}
+	if tokens := tokeniser.Tokenise("Title words: body text"); !reflect.DeepEqual([]string{"Title", "words", "body", "text"}, tokens) {
+		t.Errorf("Expected tokens to match the wrapped tokeniser but found %v", tokens)
+	}
+	if tokens := tokeniser.TokeniseWeighted(""); len(tokens) != 0 {
+		t.Errorf("Expected no tokens but found %v", tokens)
+	}
+}
+
+func TestNegationTokeniser(t *testing.T) {
+	var tests = []struct {
+		tokeniser Tokeniser
+		text      string
+		tokens    []string
+	}{
+		{NewNegationTokeniser(nil), "this is not good", []string{"this", "is", "not", "not_good"}},
+		{NewNegationTokeniser(nil), "Not good, but great", []string{"Not", "not_good", "but", "great"}},
+		{NewNegationTokeniser(nil), "never again. I loved it!", []string{"never", "not_again", "I", "loved", "it"}},
+		{NewNegationTokeniser(nil), "no no way", []string{"no", "no", "not_way"}},
+		{NewNegationTokeniser(nil), "good", []string{"good"}},
+		{NewNegationTokeniser(nil), "", nil},
+		{NewNegationTokeniser(NewWhitespaceTokeniser()), "I don't like it; really", []string{"I", "don't", "not_like", "not_it", "really"}},
+		{NewNegationTokeniser(nil, "hardly"), "hardly good not bad", []string{"hardly", "not_good", "not_not", "not_bad"}},
+	}
+
+	for _, test := range tests {
+		tokens := test.tokeniser.Tokenise(test.text)
+		if !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("Expected tokens %v for '%s' but found %v", test.tokens, test.text, tokens)
+		}
+	}
+
+	vectoriser := NewCountVectoriser(true)
+	vectoriser.Tokeniser = NewNegationTokeniser(nil)
+	vectoriser.Fit("this is not good", "this is good")
+	for _, term := range []string{"good", "not_good"} {
+		if _, ok := vectoriser.Vocabulary[term]; !ok {
+			t.Errorf("Expected term '%s' in vocabulary %v", term, vectoriser.Vocabulary)
+		}
+	}
+}
+
+func TestCountVectoriserCustomTokeniser(t *testing.T) {
+	docs := []string{
+		"Loving #golang and #NLP",
+		"golang is fun",
+	}
+
+	tokeniser, _ := NewRegExpTokeniser(`#?\w+`)
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.Tokeniser = tokeniser
+
+	mat, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+
+	for _, tag := range []string{"#golang", "#nlp"} {
+		i, ok := vectoriser.Vocabulary[tag]
+		if !ok {
+			t.Errorf("Expected tag '%s' to be preserved in vocabulary %v", tag, vectoriser.Vocabulary)
+			continue
+		}
+		if mat.At(i, 0) != 1 || mat.At(i, 1) != 0 {
+			t.Errorf("Expected tag '%s' to occur once in the first document only", tag)
+		}
+	}
+
+	// the tag and plain word should be treated as distinct terms
+	if i, ok := vectoriser.Vocabulary["golang"]; !ok || mat.At(i, 0) != 0 || mat.At(i, 1) != 1 {
+		t.Errorf("Expected 'golang' to occur once in the second document only: %v", vectoriser.Vocabulary)
+	}
+}
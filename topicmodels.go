@@ -0,0 +1,376 @@
+package nlp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// LatentDirichletAllocation (LDA) is a probabilistic topic model that represents each
+// document within a corpus as a mixture of K topics where each topic is itself a
+// probability distribution over the terms in the vocabulary.  Unlike LSA (TruncatedSVD),
+// which is a linear projection, LDA is a generative model producing a probability
+// distribution over topics for each document.  The model is fitted using collapsed Gibbs
+// sampling over the term document matrix.  The elements of the term document matrix are
+// treated as term counts and so are rounded to the nearest whole number.
+type LatentDirichletAllocation struct {
+	// K is the number of topics
+	K int
+
+	// Alpha is the Dirichlet prior on the per document topic distributions.  Lower values
+	// result in documents being represented by fewer topics.
+	Alpha float64
+
+	// Beta is the Dirichlet prior on the per topic term distributions.  Lower values result
+	// in topics being represented by fewer terms.
+	Beta float64
+
+	// MaxIter is the maximum number of Gibbs sampling iterations (sweeps through every term
+	// occurance within the corpus) performed by Fit() and the number performed by
+	// Transform().
+	MaxIter int
+
+	// Tol is the convergence tolerance for Fit().  If greater than 0, fitting stops early
+	// once the relative change in the log likelihood of the corpus between successive
+	// iterations falls below Tol.  As Gibbs sampling is stochastic, the log likelihood
+	// fluctuates around its converged value so Tol should not be too small (e.g. 0.001).  A
+	// value of 0 (the default) runs all MaxIter iterations.
+	Tol float64
+
+	// Progress, if set, is called following each iteration of Fit() with the log likelihood
+	// of the corpus.  Calculating the log likelihood adds to the cost of each iteration.
+	Progress ProgressFunc
+
+	// Seed is used to seed the random number generator used for sampling so that results
+	// are reproducible.
+	Seed int64
+
+	// Source, if set, is used as the source of random numbers in place of a source seeded
+	// with Seed e.g. to share a single source across multiple randomised transformers.  As
+	// the state of the source advances with use, refitting with the same Source will
+	// produce different results.
+	Source rand.Source
+
+	// topicTerm holds the number of times each term is assigned to each topic (K x m) and
+	// topics the total number of terms assigned to each topic
+	topicTerm  [][]int
+	topics     []int
+	terms      int
+	iterations int
+}
+
+// NewLatentDirichletAllocation creates a new LatentDirichletAllocation transformer
+// extracting k topics.  Alpha and Beta are initialised to 0.1 and 0.01 respectively,
+// MaxIter to 500.
+func NewLatentDirichletAllocation(k int) *LatentDirichletAllocation {
+	return &LatentDirichletAllocation{
+		K:       k,
+		Alpha:   0.1,
+		Beta:    0.01,
+		MaxIter: 500,
+	}
+}
+
+// Fit fits the model to the supplied training term document matrix, learning the topic
+// term distributions for use in subsequent calls to Transform().  If K is less than 1 the
+// model is left unfitted.
+func (l *LatentDirichletAllocation) Fit(mat mat64.Matrix) Transformer {
+	l.FitTransform(mat)
+	return l
+}
+
+// Transform projects the supplied term document matrix into topic space using the topic
+// term distributions learned during Fit().  The output matrix is K rows by n columns, where
+// n is the number of documents (columns) in the input matrix, with each column being the
+// probability distribution over topics for the corresponding document.  An error is
+// returned if K is less than 1, the model has not been fitted or the number of rows (terms)
+// in the matrix differs from the number of terms the model was fitted to.
+func (l *LatentDirichletAllocation) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if l.K < 1 {
+		return nil, fmt.Errorf("%w: K (%d) must be at least 1", ErrInvalidArgument, l.K)
+	}
+	if l.topicTerm == nil {
+		return nil, fmt.Errorf("%w: LatentDirichletAllocation", ErrNotFitted)
+	}
+	if m != l.terms {
+		return nil, fmt.Errorf("%w: matrix has %d rows but model was fitted on %d terms", ErrDimensionMismatch, m, l.terms)
+	}
+	rnd := newRand(l.Source, l.Seed)
+	phi := l.Components()
+
+	docs, assignments, docTopic := l.initialise(mat, rnd)
+
+	p := make([]float64, l.K)
+	for it := 0; it < l.MaxIter; it++ {
+		for d, words := range docs {
+			for i, w := range words {
+				k := assignments[d][i]
+				docTopic[d][k]--
+
+				for k := range p {
+					p[k] = (float64(docTopic[d][k]) + l.Alpha) * phi.At(k, w)
+				}
+				k = sample(rnd, p)
+
+				assignments[d][i] = k
+				docTopic[d][k]++
+			}
+		}
+	}
+
+	return l.docTopicDistributions(docs, docTopic, n), nil
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a useful shortcut where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.  The topic
+// distributions for the documents are those learned during fitting.  An error is returned
+// (and the model left unfitted) if K is less than 1.
+func (l *LatentDirichletAllocation) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if l.K < 1 {
+		l.terms = 0
+		l.topicTerm = nil
+		l.topics = nil
+		l.iterations = 0
+		return nil, fmt.Errorf("%w: K (%d) must be at least 1", ErrInvalidArgument, l.K)
+	}
+	rnd := newRand(l.Source, l.Seed)
+
+	l.terms = m
+	l.topicTerm = make([][]int, l.K)
+	for k := range l.topicTerm {
+		l.topicTerm[k] = make([]int, m)
+	}
+	l.topics = make([]int, l.K)
+
+	docs, assignments, docTopic := l.initialise(mat, rnd)
+	for d, words := range docs {
+		for i, w := range words {
+			k := assignments[d][i]
+			l.topicTerm[k][w]++
+			l.topics[k]++
+		}
+	}
+
+	mBeta := float64(m) * l.Beta
+	p := make([]float64, l.K)
+	likelihood := l.logLikelihood()
+	l.iterations = 0
+	for l.iterations < l.MaxIter {
+		l.iterations++
+		for d, words := range docs {
+			for i, w := range words {
+				k := assignments[d][i]
+				docTopic[d][k]--
+				l.topicTerm[k][w]--
+				l.topics[k]--
+
+				for k := range p {
+					p[k] = (float64(docTopic[d][k]) + l.Alpha) *
+						(float64(l.topicTerm[k][w]) + l.Beta) /
+						(float64(l.topics[k]) + mBeta)
+				}
+				k = sample(rnd, p)
+
+				assignments[d][i] = k
+				docTopic[d][k]++
+				l.topicTerm[k][w]++
+				l.topics[k]++
+			}
+		}
+
+		if l.Tol > 0 || l.Progress != nil {
+			previous := likelihood
+			likelihood = l.logLikelihood()
+			if l.Progress != nil {
+				l.Progress(l.iterations, likelihood)
+			}
+			if l.Tol > 0 && math.Abs(likelihood-previous) < l.Tol*math.Abs(previous) {
+				break
+			}
+		}
+	}
+
+	return l.docTopicDistributions(docs, docTopic, n), nil
+}
+
+// Clone returns a deep copy of the transformer including its fitted topic term counts.  The
+// copy shares any configured Source with the original.
+func (l *LatentDirichletAllocation) Clone() Transformer {
+	c := *l
+	if l.topicTerm != nil {
+		c.topicTerm = make([][]int, len(l.topicTerm))
+		for k, counts := range l.topicTerm {
+			c.topicTerm[k] = make([]int, len(counts))
+			copy(c.topicTerm[k], counts)
+		}
+	}
+	if l.topics != nil {
+		c.topics = make([]int, len(l.topics))
+		copy(c.topics, l.topics)
+	}
+	return &c
+}
+
+// Iterations returns the number of Gibbs sampling iterations performed during the last call
+// to Fit() or FitTransform().  This will be less than MaxIter if fitting converged early
+// (see Tol).
+func (l *LatentDirichletAllocation) Iterations() int {
+	return l.iterations
+}
+
+// logLikelihood calculates the log likelihood of the term occurances within the corpus
+// given their current topic assignments, log p(w|z), integrating out the topic term
+// distributions.
+func (l *LatentDirichletAllocation) logLikelihood() float64 {
+	mBeta := float64(l.terms) * l.Beta
+	lgBeta, _ := math.Lgamma(l.Beta)
+	lgMBeta, _ := math.Lgamma(mBeta)
+
+	var ll float64
+	for k, counts := range l.topicTerm {
+		lg, _ := math.Lgamma(float64(l.topics[k]) + mBeta)
+		ll += lgMBeta - lg
+		for _, c := range counts {
+			if c > 0 {
+				lg, _ := math.Lgamma(float64(c) + l.Beta)
+				ll += lg - lgBeta
+			}
+		}
+	}
+	return ll
+}
+
+// Components returns the topic term distributions learned during Fit() as a K x m matrix,
+// where m is the number of terms, with each row being the probability distribution over
+// terms for the corresponding topic.  The most probable terms for each topic may be used
+// to label or interpret the topic.
+func (l *LatentDirichletAllocation) Components() *mat64.Dense {
+	phi := mat64.NewDense(l.K, l.terms, nil)
+	mBeta := float64(l.terms) * l.Beta
+	for k := 0; k < l.K; k++ {
+		for w := 0; w < l.terms; w++ {
+			phi.Set(k, w, (float64(l.topicTerm[k][w])+l.Beta)/(float64(l.topics[k])+mBeta))
+		}
+	}
+	return phi
+}
+
+// Coherence returns the UMass coherence of each topic, measuring how often the topN most
+// probable terms of the topic co-occur within the documents (columns) of the supplied term
+// document matrix, typically the training matrix.  The coherence of a topic is the sum,
+// over each pair of its top terms wi and wj (where wj is more probable than wi), of
+// log((D(wi, wj) + 1) / D(wj)) where D(wj) is the number of documents containing wj and
+// D(wi, wj) the number of documents containing both.  Higher scores indicate more coherent
+// (and typically more interpretable) topics so the mean coherence may be used to compare
+// models fitted with different values of K.  Terms not occuring within the matrix are
+// ignored.  If the model has not been fitted, the number of rows in the matrix differs from
+// the number of terms the model was fitted to or topN is less than 1 then nil is returned.
+func (l *LatentDirichletAllocation) Coherence(mat mat64.Matrix, topN int) []float64 {
+	m, n := mat.Dims()
+	if l.topicTerm == nil || m != l.terms || topN < 1 {
+		return nil
+	}
+	topN = min(topN, m)
+
+	scores := make([]float64, l.K)
+	for k, counts := range l.topicTerm {
+		top := make([]int, m)
+		for w := range top {
+			top[w] = w
+		}
+		sort.SliceStable(top, func(i, j int) bool {
+			return counts[top[i]] > counts[top[j]]
+		})
+		top = top[:topN]
+
+		// the documents containing each of the top terms
+		occurs := make([][]bool, topN)
+		df := make([]float64, topN)
+		for i, w := range top {
+			occurs[i] = make([]bool, n)
+			for d := 0; d < n; d++ {
+				if mat.At(w, d) != 0 {
+					occurs[i][d] = true
+					df[i]++
+				}
+			}
+		}
+
+		for i := 1; i < topN; i++ {
+			for j := 0; j < i; j++ {
+				if df[j] == 0 {
+					continue
+				}
+				var both float64
+				for d := 0; d < n; d++ {
+					if occurs[i][d] && occurs[j][d] {
+						both++
+					}
+				}
+				scores[k] += math.Log((both + 1) / df[j])
+			}
+		}
+	}
+	return scores
+}
+
+// initialise expands each document (column) of the matrix into a list of term occurances
+// and randomly assigns each occurance to a topic.  It returns the term occurances per
+// document, their topic assignments and the count of occurances assigned to each topic per
+// document.
+func (l *LatentDirichletAllocation) initialise(mat mat64.Matrix, rnd *rand.Rand) ([][]int, [][]int, [][]int) {
+	m, n := mat.Dims()
+
+	docs := make([][]int, n)
+	assignments := make([][]int, n)
+	docTopic := make([][]int, n)
+
+	for d := 0; d < n; d++ {
+		docTopic[d] = make([]int, l.K)
+		for w := 0; w < m && w < l.terms; w++ {
+			count := int(math.Floor(mat.At(w, d) + 0.5))
+			for c := 0; c < count; c++ {
+				k := rnd.Intn(l.K)
+				docs[d] = append(docs[d], w)
+				assignments[d] = append(assignments[d], k)
+				docTopic[d][k]++
+			}
+		}
+	}
+	return docs, assignments, docTopic
+}
+
+// docTopicDistributions calculates the per document topic distributions as a K x n matrix
+func (l *LatentDirichletAllocation) docTopicDistributions(docs [][]int, docTopic [][]int, n int) *mat64.Dense {
+	theta := mat64.NewDense(l.K, n, nil)
+	kAlpha := float64(l.K) * l.Alpha
+	for d := 0; d < n; d++ {
+		for k := 0; k < l.K; k++ {
+			theta.Set(k, d, (float64(docTopic[d][k])+l.Alpha)/(float64(len(docs[d]))+kAlpha))
+		}
+	}
+	return theta
+}
+
+// sample randomly samples an index from the unnormalised discrete probability distribution p
+func sample(rnd *rand.Rand, p []float64) int {
+	var total float64
+	for _, v := range p {
+		total += v
+	}
+
+	u := rnd.Float64() * total
+	for i, v := range p {
+		u -= v
+		if u < 0 {
+			return i
+		}
+	}
+	return len(p) - 1
+}
@@ -0,0 +1,269 @@
+package nlp
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// topicCorpus is a synthetic term document matrix with 2 clearly separated topics.  Terms
+// 0-2 only occur within documents 0-3 and terms 3-5 only within documents 4-7.
+var topicCorpus = mat64.NewDense(6, 8, []float64{
+	3, 2, 4, 1, 0, 0, 0, 0,
+	2, 3, 1, 4, 0, 0, 0, 0,
+	1, 2, 3, 2, 0, 0, 0, 0,
+	0, 0, 0, 0, 3, 1, 2, 4,
+	0, 0, 0, 0, 2, 4, 3, 1,
+	0, 0, 0, 0, 1, 2, 2, 3,
+})
+
+func argmax(v *mat64.Vector) int {
+	max := 0
+	for i := 1; i < v.Len(); i++ {
+		if v.At(i, 0) > v.At(max, 0) {
+			max = i
+		}
+	}
+	return max
+}
+
+func TestLatentDirichletAllocationFitTransform(t *testing.T) {
+	lda := NewLatentDirichletAllocation(2)
+	lda.Seed = 42
+	lda.MaxIter = 200
+
+	theta, err := lda.FitTransform(topicCorpus)
+	if err != nil {
+		t.Fatalf("Failed LDA fit transform caused by %v", err)
+	}
+
+	r, c := theta.Dims()
+	if r != 2 || c != 8 {
+		t.Fatalf("Expected matrix 2 x 8 but found %d x %d", r, c)
+	}
+
+	// each column should be a probability distribution
+	for j := 0; j < c; j++ {
+		sum := mat64.Sum(theta.ColView(j))
+		if sum < 0.999999 || sum > 1.000001 {
+			t.Errorf("Expected topic distribution for document %d to sum to 1 but found %f", j, sum)
+		}
+	}
+
+	// documents 0-3 and 4-7 should be assigned to different dominant topics
+	first := argmax(theta.ColView(0))
+	for j := 0; j < c; j++ {
+		topic := argmax(theta.ColView(j))
+		if (j < 4 && topic != first) || (j >= 4 && topic == first) {
+			t.Errorf("Expected document %d to be assigned to topic %d but found %d", j, first, topic)
+		}
+	}
+
+	// the topic term distributions should be similarly separated
+	phi := lda.Components()
+	r, c = phi.Dims()
+	if r != 2 || c != 6 {
+		t.Fatalf("Expected topic term matrix 2 x 6 but found %d x %d", r, c)
+	}
+	for w := 0; w < 6; w++ {
+		topic := first
+		if w >= 3 {
+			topic = 1 - first
+		}
+		if phi.At(topic, w) <= phi.At(1-topic, w) {
+			t.Errorf("Expected term %d to be most probable in topic %d but found %v", w, topic, mat64.Formatted(phi))
+		}
+	}
+
+	// new documents should be projected into the same topic space
+	test := mat64.NewDense(6, 2, []float64{
+		2, 0,
+		1, 0,
+		3, 0,
+		0, 2,
+		0, 3,
+		0, 1,
+	})
+	projected, err := lda.Transform(test)
+	if err != nil {
+		t.Fatalf("Failed LDA transform caused by %v", err)
+	}
+	if argmax(projected.ColView(0)) != first || argmax(projected.ColView(1)) == first {
+		t.Errorf("Expected test documents to be assigned to corresponding topics but found \n%v\n",
+			mat64.Formatted(projected))
+	}
+
+	// fitting with the same seed should reproduce identical results
+	lda2 := NewLatentDirichletAllocation(2)
+	lda2.Seed = 42
+	lda2.MaxIter = 200
+	theta2, _ := lda2.FitTransform(topicCorpus)
+	if !mat64.Equal(theta, theta2) {
+		t.Errorf("Expected identical results for the same seed but found \n%v\n and \n%v\n",
+			mat64.Formatted(theta), mat64.Formatted(theta2))
+	}
+}
+
+func TestLatentDirichletAllocationInvalidK(t *testing.T) {
+	for _, k := range []int{0, -1} {
+		lda := NewLatentDirichletAllocation(k)
+		if _, err := lda.FitTransform(topicCorpus); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Expected error wrapping '%v' fitting with K %d but found '%v'", ErrInvalidArgument, k, err)
+		}
+
+		// a model fitted before K was invalidated should be left unfitted by Fit()
+		lda.K = 2
+		lda.MaxIter = 10
+		lda.Fit(topicCorpus)
+		lda.K = k
+		lda.Fit(topicCorpus)
+		if _, err := lda.Transform(topicCorpus); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Expected error wrapping '%v' transforming with K %d but found '%v'", ErrInvalidArgument, k, err)
+		}
+		lda.K = 2
+		if _, err := lda.Transform(topicCorpus); !errors.Is(err, ErrNotFitted) {
+			t.Errorf("Expected error wrapping '%v' after fitting with K %d but found '%v'", ErrNotFitted, k, err)
+		}
+	}
+}
+
+func TestLatentDirichletAllocationSource(t *testing.T) {
+	seeded := NewLatentDirichletAllocation(2)
+	seeded.Seed = 7
+	seeded.MaxIter = 20
+	expected, _ := seeded.FitTransform(topicCorpus)
+
+	lda := NewLatentDirichletAllocation(2)
+	lda.Source = rand.NewSource(7)
+	lda.MaxIter = 20
+	theta, err := lda.FitTransform(topicCorpus)
+	if err != nil {
+		t.Fatalf("Failed LDA fit transform caused by %v", err)
+	}
+	if !mat64.Equal(expected, theta) {
+		t.Errorf("Expected identical results for a source with the same seed but found \n%v\n and \n%v\n",
+			mat64.Formatted(expected), mat64.Formatted(theta))
+	}
+
+	// refitting a seeded model reproduces the same topics
+	refit, _ := seeded.FitTransform(topicCorpus)
+	if !mat64.Equal(expected, refit) {
+		t.Errorf("Expected identical results when refitting with the same seed")
+	}
+
+	// models are reproducible by default, as for the other randomised types
+	first := NewLatentDirichletAllocation(2)
+	first.MaxIter = 20
+	second := NewLatentDirichletAllocation(2)
+	second.MaxIter = 20
+	a, _ := first.FitTransform(topicCorpus)
+	b, _ := second.FitTransform(topicCorpus)
+	if !mat64.Equal(a, b) {
+		t.Errorf("Expected identical results for models using the default seed but found \n%v\n and \n%v\n",
+			mat64.Formatted(a), mat64.Formatted(b))
+	}
+}
+
+func TestLatentDirichletAllocationTol(t *testing.T) {
+	lda := NewLatentDirichletAllocation(2)
+	lda.Seed = 42
+	lda.MaxIter = 50
+	lda.FitTransform(topicCorpus)
+	if lda.Iterations() != 50 {
+		t.Errorf("Expected all 50 iterations to run without a tolerance but found %d", lda.Iterations())
+	}
+
+	lda.Tol = 0.01
+	theta, err := lda.FitTransform(topicCorpus)
+	if err != nil {
+		t.Fatalf("Failed LDA fit transform caused by %v", err)
+	}
+	if it := lda.Iterations(); it < 1 || it >= 50 {
+		t.Errorf("Expected fitting to stop early but found %d iterations", it)
+	}
+
+	// the easy problem should still be solved on stopping
+	first := argmax(theta.ColView(0))
+	_, c := theta.Dims()
+	for j := 0; j < c; j++ {
+		topic := argmax(theta.ColView(j))
+		if (j < 4 && topic != first) || (j >= 4 && topic == first) {
+			t.Errorf("Expected document %d to be assigned to topic %d but found %d", j, first, topic)
+		}
+	}
+}
+
+func TestLatentDirichletAllocationProgress(t *testing.T) {
+	var calls []int
+	var likelihoods []float64
+	lda := NewLatentDirichletAllocation(2)
+	lda.Seed = 42
+	lda.MaxIter = 50
+	lda.Progress = func(iteration int, metric float64) {
+		calls = append(calls, iteration)
+		likelihoods = append(likelihoods, metric)
+	}
+
+	for _, tol := range []float64{0, 0.01} {
+		calls, likelihoods = nil, nil
+		lda.Tol = tol
+		lda.FitTransform(topicCorpus)
+
+		if len(calls) != lda.Iterations() {
+			t.Errorf("Tol %f: Expected %d progress callbacks but found %d", tol, lda.Iterations(), len(calls))
+		}
+		for i, iteration := range calls {
+			if iteration != i+1 {
+				t.Errorf("Tol %f: Expected callback %d for iteration %d but found %d", tol, i, i+1, iteration)
+			}
+		}
+	}
+
+	// the log likelihood should improve from the random initialisation
+	if likelihoods[len(likelihoods)-1] <= likelihoods[0] {
+		t.Errorf("Expected log likelihood to increase but found %v", likelihoods)
+	}
+}
+
+func TestLatentDirichletAllocationCoherence(t *testing.T) {
+	// terms 0-2 always occur together whereas terms 3-5 never do
+	mat := mat64.NewDense(6, 7, []float64{
+		1, 2, 1, 1, 0, 0, 0,
+		2, 1, 1, 3, 0, 0, 0,
+		1, 1, 2, 1, 0, 0, 0,
+		0, 0, 0, 0, 2, 0, 0,
+		0, 0, 0, 0, 0, 1, 0,
+		0, 0, 0, 0, 0, 0, 3,
+	})
+
+	lda := &LatentDirichletAllocation{K: 2, Alpha: 0.1, Beta: 0.01, MaxIter: 50, Seed: 2}
+	if scores := lda.Coherence(mat, 3); scores != nil {
+		t.Errorf("Expected nil coherence for unfitted model but found %v", scores)
+	}
+
+	// one topic should be dominated by the co-occurring terms and the other by the rest
+	if _, err := lda.FitTransform(mat); err != nil {
+		t.Fatalf("Failed LDA fit transform caused by %v", err)
+	}
+	scores := lda.Coherence(mat, 3)
+	if len(scores) != 2 {
+		t.Fatalf("Expected 2 coherence scores but found %v", scores)
+	}
+	coherent, incoherent := scores[0], scores[1]
+	if coherent < incoherent {
+		coherent, incoherent = incoherent, coherent
+	}
+	if expected := 3 * math.Log(5/4.0); math.Abs(coherent-expected) > 0.000001 {
+		t.Errorf("Expected coherence %f for the coherent topic but found %v", expected, scores)
+	}
+	if math.Abs(incoherent) > 0.000001 {
+		t.Errorf("Expected coherence 0 for the incoherent topic but found %v", scores)
+	}
+
+	if scores := lda.Coherence(mat64.NewDense(5, 7, nil), 3); scores != nil {
+		t.Errorf("Expected nil coherence for mismatched matrix but found %v", scores)
+	}
+}
@@ -1,15 +1,180 @@
 package nlp
 
-import "github.com/gonum/matrix/mat64"
-
-// CosineSimilarity calculates the distance between the angles of 2 vectors i.e. how
-// similar they are.  Possible values range up to 1 (exact match)
-func CosineSimilarity(a, b *mat64.Vector) float64 {
-	// Cosine angle between two vectors is equal to their dot product divided by
-	// the product of their norms
-	dotProduct := mat64.Dot(a, b)
-	norma := mat64.Norm(a, 2.0)
-	normb := mat64.Norm(b, 2.0)
-
-	return (dotProduct / (norma * normb))
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// NormType specifies the type of normalisation applied to document feature vectors
+type NormType int
+
+const (
+	// NoNorm applies no normalisation
+	NoNorm NormType = iota
+
+	// L1Norm scales each vector so that the sum of the absolute values of its elements is 1
+	L1Norm
+
+	// L2Norm scales each vector to unit Euclidean length
+	L2Norm
+
+	// MaxNorm scales each vector so that the largest absolute value of its elements is 1
+	MaxNorm
+)
+
+// p returns the order of the vector norm represented by the NormType (as accepted by
+// mat64.Norm()) and whether any normalisation should be applied.
+func (norm NormType) p() (float64, bool) {
+	switch norm {
+	case L1Norm:
+		return 1, true
+	case L2Norm:
+		return 2, true
+	case MaxNorm:
+		return math.Inf(1), true
+	}
+	return 0, false
+}
+
+// NonFinitePolicy specifies how NaN and infinite values within input matrices are handled
+type NonFinitePolicy int
+
+const (
+	// PropagateNonFinite applies no special handling so NaN and infinite values propagate
+	// through to the output
+	PropagateNonFinite NonFinitePolicy = iota
+
+	// RejectNonFinite returns an error identifying the first NaN or infinite value found
+	RejectNonFinite
+
+	// ZeroNonFinite replaces NaN and infinite values with 0
+	ZeroNonFinite
+)
+
+// isNonFinite returns true if v is NaN or infinite
+func isNonFinite(v float64) bool {
+	return math.IsNaN(v) || math.IsInf(v, 0)
+}
+
+// checkFinite returns an error identifying the first (in column order) NaN or infinite
+// value within the matrix, if any.  If the matrix is a *SparseMatrix, only its non zero
+// elements are visited.
+func checkFinite(mat mat64.Matrix) error {
+	if s, ok := mat.(*SparseMatrix); ok {
+		for j := 0; j < s.c; j++ {
+			for k := s.indptr[j]; k < s.indptr[j+1]; k++ {
+				if isNonFinite(s.data[k]) {
+					return fmt.Errorf("%w: matrix contains %f at (%d, %d)", ErrNonFinite, s.data[k], s.ind[k], j)
+				}
+			}
+		}
+		return nil
+	}
+
+	m, n := mat.Dims()
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			if v := mat.At(i, j); isNonFinite(v) {
+				return fmt.Errorf("%w: matrix contains %f at (%d, %d)", ErrNonFinite, v, i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// normaliseColumns scales each column of mat, in place, by its norm of the specified type.
+// Columns with a norm of zero are left unchanged to avoid division by zero.
+func normaliseColumns(mat *mat64.Dense, norm NormType) {
+	p, ok := norm.p()
+	if !ok {
+		return
+	}
+
+	_, n := mat.Dims()
+	for j := 0; j < n; j++ {
+		col := mat.ColView(j)
+		length := mat64.Norm(col, p)
+		if length != 0 {
+			col.ScaleVec(1/length, col)
+		}
+	}
+}
+
+// ProgressFunc is called by iterative models following each iteration of fitting with the
+// (1 based) iteration number and the current value of the objective being optimised (e.g.
+// the log likelihood) so that progress may be logged or displayed.
+type ProgressFunc func(iteration int, metric float64)
+
+// newRand returns a random number generator using src as its source of random numbers or,
+// if src is nil, a new source seeded with seed.  All randomised types (e.g. TruncatedSVD,
+// RandomProjection, LatentDirichletAllocation and KMeans) obtain their random numbers this
+// way from their Source and Seed fields so that, unless a Source is supplied, results are
+// reproducible by default, with an unset Seed of 0 being a valid seed like any other.
+// Callers wanting different results for each run should set Seed explicitly e.g. from the
+// current time.
+func newRand(src rand.Source, seed int64) *rand.Rand {
+	if src == nil {
+		src = rand.NewSource(seed)
+	}
+	return rand.New(src)
+}
+
+// copyFloats returns a copy of the supplied slice or nil if the slice is nil
+func copyFloats(s []float64) []float64 {
+	if s == nil {
+		return nil
+	}
+	c := make([]float64, len(s))
+	copy(c, s)
+	return c
+}
+
+// copyDense returns a copy of the supplied matrix or nil if the matrix is nil
+func copyDense(m *mat64.Dense) *mat64.Dense {
+	if m == nil {
+		return nil
+	}
+	return mat64.DenseCopyOf(m)
+}
+
+// Hstack combines the feature matrices output by multiple vectorisers (or transformers) for
+// the same documents into a single matrix, e.g. to combine word n-gram and character n-gram
+// features in the same way as scikit-learn's FeatureUnion.  The name follows scikit-learn
+// where documents are rows and so features are stacked horizontally.  As documents are
+// columns within this package, the rows (features) of each matrix are stacked vertically,
+// in the order supplied, so that column j of the output represents document j of every
+// input.  An error is returned if no matrices are supplied or they do not all have the
+// same number of columns (documents).
+func Hstack(mats ...*mat64.Dense) (*mat64.Dense, error) {
+	if len(mats) == 0 {
+		return nil, fmt.Errorf("%w: no matrices supplied to stack", ErrInvalidArgument)
+	}
+
+	var rows int
+	_, n := mats[0].Dims()
+	for i, mat := range mats {
+		r, c := mat.Dims()
+		if c != n {
+			return nil, fmt.Errorf("%w: matrix %d has %d columns but matrix 0 has %d", ErrDimensionMismatch, i, c, n)
+		}
+		rows += r
+	}
+	if rows == 0 || n == 0 {
+		return nil, fmt.Errorf("%w: matrices to stack are empty", ErrInvalidArgument)
+	}
+
+	stacked := mat64.NewDense(rows, n, nil)
+	var offset int
+	for _, mat := range mats {
+		r, _ := mat.Dims()
+		if r == 0 {
+			continue
+		}
+		stacked.View(offset, 0, r, n).(*mat64.Dense).Copy(mat)
+		offset += r
+	}
+	return stacked, nil
 }
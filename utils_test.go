@@ -0,0 +1,58 @@
+package nlp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestHstack(t *testing.T) {
+	words := mat64.NewDense(2, 3, []float64{
+		1, 0, 2,
+		0, 3, 1,
+	})
+	chars := mat64.NewDense(3, 3, []float64{
+		4, 5, 6,
+		0, 0, 1,
+		7, 0, 0,
+	})
+
+	stacked, err := Hstack(words, chars)
+	if err != nil {
+		t.Fatalf("Failed to stack matrices caused by %v", err)
+	}
+	expected := mat64.NewDense(5, 3, []float64{
+		1, 0, 2,
+		0, 3, 1,
+		4, 5, 6,
+		0, 0, 1,
+		7, 0, 0,
+	})
+	if !mat64.Equal(expected, stacked) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(stacked))
+		t.Fail()
+	}
+
+	// the inputs should be copied rather than shared
+	stacked.Set(0, 0, 9)
+	if words.At(0, 0) != 1 {
+		t.Errorf("Expected input matrix to be unchanged by modifying the stacked matrix")
+	}
+
+	var invalid = []struct {
+		mats     []*mat64.Dense
+		expected error
+	}{
+		{mats: nil, expected: ErrInvalidArgument},
+		{mats: []*mat64.Dense{words, mat64.NewDense(2, 2, nil)}, expected: ErrDimensionMismatch},
+		{mats: []*mat64.Dense{{}}, expected: ErrInvalidArgument},
+	}
+	for _, test := range invalid {
+		if _, err := Hstack(test.mats...); !errors.Is(err, test.expected) {
+			t.Errorf("Expected error wrapping '%v' but found '%v'", test.expected, err)
+		}
+	}
+}
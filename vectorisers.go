@@ -1,8 +1,17 @@
 package nlp
 
 import (
-	"regexp"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/gonum/matrix/mat64"
 )
@@ -11,6 +20,40 @@ var (
 	stopWords = []string{"a", "about", "above", "above", "across", "after", "afterwards", "again", "against", "all", "almost", "alone", "along", "already", "also", "although", "always", "am", "among", "amongst", "amoungst", "amount", "an", "and", "another", "any", "anyhow", "anyone", "anything", "anyway", "anywhere", "are", "around", "as", "at", "back", "be", "became", "because", "become", "becomes", "becoming", "been", "before", "beforehand", "behind", "being", "below", "beside", "besides", "between", "beyond", "bill", "both", "bottom", "but", "by", "call", "can", "cannot", "cant", "co", "con", "could", "couldnt", "cry", "de", "describe", "detail", "do", "done", "down", "due", "during", "each", "eg", "eight", "either", "eleven", "else", "elsewhere", "empty", "enough", "etc", "even", "ever", "every", "everyone", "everything", "everywhere", "except", "few", "fifteen", "fify", "fill", "find", "fire", "first", "five", "for", "former", "formerly", "forty", "found", "four", "from", "front", "full", "further", "get", "give", "go", "had", "has", "hasnt", "have", "he", "hence", "her", "here", "hereafter", "hereby", "herein", "hereupon", "hers", "herself", "him", "himself", "his", "how", "however", "hundred", "ie", "if", "in", "inc", "indeed", "interest", "into", "is", "it", "its", "itself", "keep", "last", "latter", "latterly", "least", "less", "ltd", "made", "many", "may", "me", "meanwhile", "might", "mill", "mine", "more", "moreover", "most", "mostly", "move", "much", "must", "my", "myself", "name", "namely", "neither", "never", "nevertheless", "next", "nine", "no", "nobody", "none", "noone", "nor", "not", "nothing", "now", "nowhere", "of", "off", "often", "on", "once", "one", "only", "onto", "or", "other", "others", "otherwise", "our", "ours", "ourselves", "out", "over", "own", "part", "per", "perhaps", "please", "put", "rather", "re", "same", "see", "seem", "seemed", "seeming", "seems", "serious", "several", "she", "should", "show", "side", "since", "sincere", "six", "sixty", "so", "some", "somehow", "someone", "something", "sometime", "sometimes", "somewhere", "still", "such", "system", "take", "ten", "than", "that", "the", "their", "them", "themselves", "then", "thence", "there", "thereafter", "thereby", "therefore", "therein", "thereupon", "these", "they", "thickv", "thin", "third", "this", "those", "though", "three", "through", "throughout", "thru", "thus", "to", "together", "too", "top", "toward", "towards", "twelve", "twenty", "two", "un", "under", "until", "up", "upon", "us", "very", "via", "was", "we", "well", "were", "what", "whatever", "when", "whence", "whenever", "where", "whereafter", "whereas", "whereby", "wherein", "whereupon", "wherever", "whether", "which", "while", "whither", "who", "whoever", "whole", "whom", "whose", "why", "will", "with", "within", "without", "would", "yet", "you", "your", "yours", "yourself", "yourselves"}
 )
 
+// Vectoriser encodes text documents into term document matrices where each column
+// represents a document and each row a term (or feature).  Implementations may need to be
+// fitted to training documents (e.g. to learn a vocabulary) before the documents may be
+// transformed.
+type Vectoriser interface {
+	Fit(train ...string) Vectoriser
+	Transform(docs ...string) (*mat64.Dense, error)
+	FitTransform(docs ...string) (*mat64.Dense, error)
+}
+
+// Analyser specifies how documents are split into the terms represented by the rows of
+// term document matrices.
+type Analyser int
+
+const (
+	// WordAnalyser extracts n-grams of words as terms.  Documents are split into words using
+	// the vectoriser's Tokeniser with stop words removed and stemming applied if configured.
+	WordAnalyser Analyser = iota
+
+	// CharAnalyser extracts n-grams of characters as terms from the whole document (with
+	// runs of whitespace collapsed into a single space) so n-grams may span adjacent words.
+	// Character n-grams are language agnostic and robust to typos and noisy text making them
+	// well suited to short texts and language identification.  The Tokeniser, stop words and
+	// Stemmer are not used.
+	CharAnalyser
+
+	// CharWordBoundaryAnalyser extracts n-grams of characters as terms in the same way as
+	// CharAnalyser except that n-grams are only extracted from within words (split on
+	// whitespace), with each word padded with a space at either end so that n-grams at the
+	// start and end of words are distinguished.  Words shorter than the n-gram size (once
+	// padded) are extracted whole.
+	CharWordBoundaryAnalyser
+)
+
 // CountVectoriser can be used to encode one or more text documents into a term document
 // matrix where each column represents a document within the corpus and each row represents
 // a term present in the training data set.  Each element represents the frequency the
@@ -24,91 +67,1262 @@ type CountVectoriser struct {
 	// Transform(), any words found in the test data set that were not present in the
 	// training data set supplied to Fit() will not have an entry in the Vocabulary
 	// and will be ignored.
-	Vocabulary    map[string]int
-	wordTokeniser *regexp.Regexp
-	stopWords     *regexp.Regexp
+	Vocabulary map[string]int
+
+	// FixedVocabulary, if true, treats the Vocabulary as predefined (e.g. to match the
+	// feature indices of another system) rather than learned.  Fit() and PartialFit() leave
+	// the Vocabulary unchanged and FitTransform() only validates it, returning an error if it
+	// is empty or its indices are not the contiguous row indices 0 to len(Vocabulary)-1.
+	// Terms not present in the Vocabulary are ignored by Transform().  See
+	// NewCountVectoriserWithVocabulary().
+	FixedVocabulary bool
+
+	// MinNGram and MaxNGram specify the range of n-gram sizes to extract from documents as
+	// terms.  Each contiguous sequence of n words (following stop word removal), for each n
+	// in the range MinNGram <= n <= MaxNGram, is treated as a distinct term with the words
+	// joined by a single space e.g. setting MinNGram to 1 and MaxNGram to 2 will extract
+	// both unigrams ("quick", "brown") and bigrams ("quick brown").  Documents with fewer
+	// than n words produce no n-grams of size n.  Values less than 1 are treated as 1 so,
+	// by default, only unigrams (single words) are extracted.
+	MinNGram int
+	MaxNGram int
+
+	// NGramWeights optionally maps n-gram sizes to multipliers applied to the values of terms
+	// of that size in the matrices output by Transform() e.g. setting NGramWeights[2] to 2
+	// doubles the counts of bigrams relative to unigrams to reward phrase matches.  Terms
+	// whose n-gram size has no entry are not scaled.  For the character analysers, sizes are
+	// measured in characters.  NGramWeights are ignored if Binary is true.  Document
+	// frequencies (and so the weights fitted by TfidfTransformer) are unaffected.  The n-gram size of each term in the Vocabulary is
+	// available from NGramSizes().
+	NGramWeights map[int]float64
+
+	// MinDF and MaxDF specify the range of document frequencies (the number of documents in
+	// which a term occurs) of terms to retain in the Vocabulary during Fit().  Terms whose
+	// document frequency falls outside the range are dropped which is useful for removing
+	// rare terms (e.g. typos) and ubiquitous terms that add noise.  Values between 0 and 1
+	// (exclusive) are interpreted as proportions of the training documents e.g. a MaxDF of
+	// 0.9 drops terms occuring in more than 90% of the documents, values of 1 and above are
+	// interpreted as absolute document counts e.g. a MinDF of 2 drops terms occuring in
+	// only a single document.  A value of 0 (the default) disables the threshold.
+	MinDF float64
+	MaxDF float64
+
+	// DropUbiquitous, if true, drops terms occuring in every training document from the
+	// Vocabulary during Fit(), in addition to any MinDF and MaxDF pruning.  Such terms carry
+	// no information for distinguishing documents (their smoothed inverse document frequency
+	// is 0 so TfidfTransformer would zero them) and dropping them reduces the number of rows
+	// in the term document matrices output by Transform() and so the dimensions expected by
+	// subsequent transformers.  Note that fitting to a single document will drop every term.
+	DropUbiquitous bool
+
+	// MaxFeatures limits the size of the Vocabulary built during Fit() to the MaxFeatures
+	// terms occuring most frequently (in total) across the training documents, applied after
+	// MinDF and MaxDF pruning.  Terms with equal frequencies are ordered alphabetically so
+	// that the terms retained are deterministic.  A value of 0 (the default) means the
+	// Vocabulary size is unlimited.
+	MaxFeatures int
+
+	// Binary, if true, records only the presence (1) or absence (0) of each term within each
+	// document in the matrices output by Transform() rather than the number of occurances.
+	// This is useful where whether a term appears matters more than how often e.g. for some
+	// classifiers.  Document frequencies (and so MinDF, MaxDF and the weights fitted by
+	// TfidfTransformer) are unaffected as they already only count presence.
+	Binary bool
+
+	// Preprocessor is applied to each document before it is tokenised (or, for the character
+	// analysers, before character n-grams are extracted) to normalise the text.  By default
+	// (or if nil), documents are converted to lower case.  The policy may be changed e.g. to
+	// preserve case (and so distinguish proper nouns) by supplying a function returning the
+	// document unchanged, or to apply Unicode normalisation.  Stop words are always compared
+	// in lower case so, if case is preserved, capitalised stop words will not be removed.
+	Preprocessor func(string) string
+
+	// Tokeniser is used to split documents into words following preprocessing.  By default,
+	// words are extracted as sequences of letters, digits, combining marks and underscores
+	// of any script with punctuation and whitespace discarded (see UnicodeTokeniser).  If
+	// the Tokeniser implements WeightedTokeniser (e.g. PositionalTokeniser) and the Analyser
+	// is WordAnalyser, Transform() adds the weight of each word to the count of its term
+	// rather than 1 (with each n-gram weighted by the mean weight of its words).
+	Tokeniser Tokeniser
+
+	// MaxTokens, if greater than 0, truncates each document to its first MaxTokens tokens
+	// (as produced by the Tokeniser, prior to stop word removal) during both Fit() and
+	// Transform() to bound the memory and computation required for very long documents.
+	// For the character analysers, documents are truncated to their first MaxTokens words
+	// (split on whitespace).  A value of 0 (the default) means documents are not truncated.
+	MaxTokens int
+
+	// Stemmer, if set, is applied to each word following stop word removal (and before
+	// n-gram extraction) to reduce it to its stem so that related words (e.g. "connect",
+	// "connected" and "connection") are treated as the same term.  PorterStem may be used
+	// for English text.  By default, no stemming is performed.
+	Stemmer func(string) string
+
+	// Analyser specifies whether terms are n-grams of words (the default, WordAnalyser) or of
+	// characters (CharAnalyser or CharWordBoundaryAnalyser).  For character analysers,
+	// MinNGram and MaxNGram specify the range of n-gram sizes in characters.
+	Analyser Analyser
+
+	stopWords map[string]struct{}
+	ngrams    []int
+
+	// fitErr records why the last call to Fit() produced an empty Vocabulary so that it may
+	// be returned by subsequent calls to Transform().
+	fitErr error
 }
 
 // NewCountVectoriser creates a new CountVectoriser.  If removeStopwords is true then english stop words will be removed.
 func NewCountVectoriser(removeStopwords bool) *CountVectoriser {
-	var stop *regexp.Regexp
-
 	if removeStopwords {
-		reStr := "\\A("
+		return NewCountVectoriserWithStopWords(stopWords...)
+	}
+	return NewCountVectoriserWithStopWords()
+}
 
-		for i, word := range stopWords {
-			if i != 0 {
-				reStr += `|`
-			}
-			reStr += `\Q` + word + `\E`
+// NewCountVectoriserWithStopWords creates a new CountVectoriser that removes the specified
+// stop words.  Stop words are removed during Fit() so they do not appear in the Vocabulary
+// and so will not be represented in the term document matrices output by Transform().
+// EnglishStopWords() returns a list of common English stop words that may be supplied
+// as is or extended e.g.
+//
+//	NewCountVectoriserWithStopWords(append(EnglishStopWords(), "foo", "bar")...)
+func NewCountVectoriserWithStopWords(stopWords ...string) *CountVectoriser {
+	return &CountVectoriser{
+		Vocabulary:   make(map[string]int),
+		Preprocessor: strings.ToLower,
+		Tokeniser:    newDefaultTokeniser(),
+		stopWords:    stopWordSet(stopWords),
+	}
+}
+
+// NewCountVectoriserWithVocabulary creates a new CountVectoriser using the supplied,
+// predefined, vocabulary (see FixedVocabulary) mapping terms to their row indices rather
+// than learning a vocabulary from training data.  The vectoriser may be used to Transform()
+// documents immediately without calling Fit() and the row indices of the output matrices
+// match the supplied vocabulary.  Terms should be supplied in the form extracted from
+// documents e.g. in lower case by default.  Any specified stop words are removed from
+// documents.  The vocabulary is copied.  An error is returned if the vocabulary is empty or
+// its indices are not the contiguous row indices 0 to len(vocabulary)-1.
+func NewCountVectoriserWithVocabulary(vocabulary map[string]int, stopWords ...string) (*CountVectoriser, error) {
+	if err := validateVocabulary(vocabulary); err != nil {
+		return nil, err
+	}
+	v := NewCountVectoriserWithStopWords(stopWords...)
+	for term, i := range vocabulary {
+		v.Vocabulary[term] = i
+	}
+	v.FixedVocabulary = true
+	v.updateNGramSizes()
+	return v, nil
+}
+
+// validateVocabulary returns an error if the supplied vocabulary is empty or its indices are
+// not the contiguous row indices 0 to len(vocabulary)-1.
+func validateVocabulary(vocabulary map[string]int) error {
+	if len(vocabulary) == 0 {
+		return fmt.Errorf("%w: vocabulary is empty", ErrEmptyVocabulary)
+	}
+	seen := make([]bool, len(vocabulary))
+	for term, i := range vocabulary {
+		if i < 0 || i >= len(vocabulary) || seen[i] {
+			return fmt.Errorf("%w: index %d of term '%s' is duplicated or outside the range 0 to %d", ErrInvalidArgument, i, term, len(vocabulary)-1)
 		}
-		reStr += ")\\z"
-		stop = regexp.MustCompile(reStr)
+		seen[i] = true
+	}
+	return nil
+}
+
+// stopWordSet returns a set of the supplied stop words (converted to lower case) or nil if
+// no stop words are supplied.
+func stopWordSet(stopWords []string) map[string]struct{} {
+	if len(stopWords) == 0 {
+		return nil
+	}
+	stop := make(map[string]struct{}, len(stopWords))
+	for _, word := range stopWords {
+		stop[strings.ToLower(word)] = struct{}{}
 	}
-	return &CountVectoriser{Vocabulary: make(map[string]int), wordTokeniser: regexp.MustCompile("\\w+"), stopWords: stop}
+	return stop
+}
+
+// EnglishStopWords returns a list of commonly occuring English words (e.g. "the", "and",
+// "of") that typically carry little meaning for the purposes of analysis.  The returned
+// slice is a copy and may be modified/extended by the caller.
+func EnglishStopWords() []string {
+	words := make([]string, len(stopWords))
+	copy(words, stopWords)
+	return words
 }
 
 // Fit processes the supplied training data (a variable number of strings representing
 // documents).  Each word appearing inside the training data will be added to the
-// Vocabulary
-func (v *CountVectoriser) Fit(train ...string) *CountVectoriser {
-	i := 0
+// Vocabulary (replacing any existing Vocabulary) subject to the MinDF and MaxDF document
+// frequency thresholds, DropUbiquitous and the MaxFeatures limit.  Terms are assigned
+// contiguous row indices in the order they first occur within the training data.  If
+// FixedVocabulary is true, the Vocabulary is left unchanged.  If no documents are supplied
+// or no terms remain once stop words and pruned terms are removed, the Vocabulary is empty
+// and subsequent calls to Transform() return an error wrapping ErrEmptyCorpus or
+// ErrEmptyVocabulary respectively.
+func (v *CountVectoriser) Fit(train ...string) Vectoriser {
+	if v.FixedVocabulary {
+		return v
+	}
+
+	var order []string
+	df := make(map[string]int)
+	tf := make(map[string]int)
+
 	for _, doc := range train {
-		words := v.tokenise(doc)
-
-		for _, word := range words {
-			_, exists := v.Vocabulary[word]
-			if !exists {
-				// if enabled, remove stop words
-				if v.stopWords != nil {
-					if v.stopWords.MatchString(word) {
-						continue
-					}
-				}
-				v.Vocabulary[word] = i
-				i++
+		seen := make(map[string]struct{})
+		for _, term := range v.terms(doc) {
+			tf[term]++
+			if _, exists := seen[term]; exists {
+				continue
+			}
+			seen[term] = struct{}{}
+			if df[term] == 0 {
+				order = append(order, term)
 			}
+			df[term]++
 		}
 	}
 
+	min := dfThreshold(v.MinDF, len(train))
+	max := dfThreshold(v.MaxDF, len(train))
+
+	retained := order[:0]
+	for _, term := range order {
+		freq := float64(df[term])
+		if (v.MinDF != 0 && freq < min) || (v.MaxDF != 0 && freq > max) {
+			continue
+		}
+		if v.DropUbiquitous && df[term] == len(train) {
+			continue
+		}
+		retained = append(retained, term)
+	}
+
+	if v.MaxFeatures > 0 && len(retained) > v.MaxFeatures {
+		retained = mostFrequent(retained, tf, v.MaxFeatures)
+	}
+
+	v.Vocabulary = make(map[string]int)
+	v.ngrams = make([]int, len(retained))
+	for _, term := range retained {
+		v.ngrams[len(v.Vocabulary)] = ngramSize(v.Analyser, term)
+		v.Vocabulary[term] = len(v.Vocabulary)
+	}
+
+	switch {
+	case len(train) == 0:
+		v.fitErr = fmt.Errorf("%w: no documents supplied", ErrEmptyCorpus)
+	case len(order) == 0:
+		v.fitErr = fmt.Errorf("%w: documents contain no terms once stop words are removed",
+			ErrEmptyVocabulary)
+	case len(retained) == 0:
+		v.fitErr = fmt.Errorf("%w: all %d terms were pruned by the document frequency thresholds",
+			ErrEmptyVocabulary, len(order))
+	default:
+		v.fitErr = nil
+	}
+
 	return v
 }
 
+// PartialFit incrementally extends the Vocabulary with any terms occuring within the
+// supplied batch of training documents that are not already present, assigning them the
+// next available row indices in the order they first occur.  Existing terms retain their
+// row indices so the Vocabulary (and the number of rows in the matrices output by
+// Transform()) grows as new terms are encountered, making it suitable for streaming
+// corpora.  Calling PartialFit() on an unfitted vectoriser is equivalent to calling Fit()
+// with no pruning.  The MinDF, MaxDF, DropUbiquitous and MaxFeatures options are not
+// applied as they require document frequencies across the whole corpus.  Note that any
+// transformers fitted to matrices output before the Vocabulary grew will expect fewer rows
+// and so may need to be refitted (or, for TfidfTransformer, updated with PartialFit()).
+// If FixedVocabulary is true, the Vocabulary is left unchanged.
+func (v *CountVectoriser) PartialFit(train ...string) Vectoriser {
+	if v.FixedVocabulary {
+		return v
+	}
+	if v.Vocabulary == nil {
+		v.Vocabulary = make(map[string]int)
+	}
+	// ensure the n-gram sizes reflect the existing Vocabulary before extending it
+	v.updateNGramSizes()
+
+	for _, doc := range train {
+		for _, term := range v.terms(doc) {
+			if _, exists := v.Vocabulary[term]; !exists {
+				v.Vocabulary[term] = len(v.Vocabulary)
+				v.ngrams = append(v.ngrams, ngramSize(v.Analyser, term))
+			}
+		}
+	}
+	if len(v.Vocabulary) > 0 {
+		v.fitErr = nil
+	}
+
+	return v
+}
+
+// NGramSizes returns a copy of the n-gram size of each term in the Vocabulary, indexed by
+// the term's row i.e. the number of words for WordAnalyser or characters for the character
+// analysers.  The sizes are recorded whenever the Vocabulary is built (by Fit(),
+// PartialFit(), NewCountVectoriserWithVocabulary() or UnmarshalBinary()) and so do not
+// reflect changes made by assigning or modifying the Vocabulary directly.
+func (v *CountVectoriser) NGramSizes() []int {
+	sizes := make([]int, len(v.ngrams))
+	copy(sizes, v.ngrams)
+	return sizes
+}
+
+// updateNGramSizes records the n-gram size of each term in the Vocabulary for NGramSizes().
+func (v *CountVectoriser) updateNGramSizes() {
+	v.ngrams = make([]int, len(v.Vocabulary))
+	for term, i := range v.Vocabulary {
+		if i >= 0 && i < len(v.ngrams) {
+			v.ngrams[i] = ngramSize(v.Analyser, term)
+		}
+	}
+}
+
+// Coverage reports how well the Vocabulary covers the supplied documents e.g. to detect
+// drift between the training data and the documents a fitted vectoriser is being applied
+// to.  The documents are analysed into terms exactly as for Transform() (so stop words are
+// excluded and, if configured, terms are stemmed n-grams) and each term occurance counted
+// as either present in the Vocabulary (inVocab) or out of vocabulary (oov), to be ignored
+// by Transform().  rate is the out of vocabulary rate, oov / (inVocab + oov), or 0 if the
+// documents contain no terms.
+func (v *CountVectoriser) Coverage(docs []string) (inVocab, oov int, rate float64) {
+	for _, doc := range docs {
+		for _, term := range v.terms(doc) {
+			if _, exists := v.Vocabulary[term]; exists {
+				inVocab++
+			} else {
+				oov++
+			}
+		}
+	}
+	if total := inVocab + oov; total > 0 {
+		rate = float64(oov) / float64(total)
+	}
+	return inVocab, oov, rate
+}
+
+// ngramSize returns the n-gram size of the supplied term extracted by analyser.
+func ngramSize(analyser Analyser, term string) int {
+	if analyser == WordAnalyser {
+		return strings.Count(term, " ") + 1
+	}
+	return utf8.RuneCountInString(term)
+}
+
+// mostFrequent returns the n terms with the highest frequencies (breaking ties
+// alphabetically), preserving the relative order in which they appear in terms.
+func mostFrequent(terms []string, freq map[string]int, n int) []string {
+	ranked := make([]string, len(terms))
+	copy(ranked, terms)
+	sort.Slice(ranked, func(i, j int) bool {
+		if freq[ranked[i]] != freq[ranked[j]] {
+			return freq[ranked[i]] > freq[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	keep := make(map[string]struct{}, n)
+	for _, term := range ranked[:n] {
+		keep[term] = struct{}{}
+	}
+
+	top := terms[:0]
+	for _, term := range terms {
+		if _, ok := keep[term]; ok {
+			top = append(top, term)
+		}
+	}
+	return top
+}
+
+// dfThreshold converts the document frequency threshold t into an absolute number of
+// documents where values of t less than 1 are treated as a proportion of the n documents.
+func dfThreshold(t float64, n int) float64 {
+	if t < 1 {
+		return t * float64(n)
+	}
+	return t
+}
+
 // Transform transforms the supplied documents into a term document matrix where each
 // column is a feature vector representing one of the supplied documents.  Each element
 // represents the frequency with which the associated term for that row occured within
-// that document.
+// that document.  An error is returned if the Vocabulary is empty.
 func (v *CountVectoriser) Transform(docs ...string) (*mat64.Dense, error) {
-	mat := mat64.NewDense(len(v.Vocabulary), len(docs), nil)
+	if err := v.checkFitted(); err != nil {
+		return nil, err
+	}
+	return denseTermDocMatrix(len(v.Vocabulary), docs, v.count, false), nil
+}
 
-	for d, doc := range docs {
-		words := v.tokenise(doc)
+// TransformInto is equivalent to Transform() but writes the term document matrix into the
+// supplied destination matrix, dst, rather than allocating a new matrix.  This allows the
+// same destination matrix to be reused across repeated calls e.g. by Pipeline.  dst must
+// have NumFeatures() rows and a column for each document.  Any existing values in dst are
+// overwritten.
+func (v *CountVectoriser) TransformInto(dst *mat64.Dense, docs ...string) error {
+	if err := v.checkFitted(); err != nil {
+		return err
+	}
+	return fillTermDocMatrix(dst, len(v.Vocabulary), docs, v.count)
+}
 
-		for _, word := range words {
-			i, exists := v.Vocabulary[word]
+// NumFeatures returns the number of features (rows) in the term document matrices produced
+// by the CountVectoriser i.e. the number of terms in the Vocabulary.
+func (v *CountVectoriser) NumFeatures() int {
+	return len(v.Vocabulary)
+}
 
-			if exists {
-				mat.Set(i, d, mat.At(i, d)+1)
-			}
+// TransformOne transforms a single document into a feature vector with an element for
+// each term in the Vocabulary.  This is equivalent to calling Transform() with the single
+// document and taking the only column of the output.  An error is returned if the
+// Vocabulary is empty.
+func (v *CountVectoriser) TransformOne(doc string) (*mat64.Vector, error) {
+	mat, err := v.Transform(doc)
+	if err != nil {
+		return nil, err
+	}
+	return mat.ColView(0), nil
+}
+
+// VectoriseQuery builds a feature vector, aligned to the supplied vocabulary (e.g. the
+// Vocabulary of a fitted CountVectoriser), from a query already expressed as a map of words
+// to their counts.  Each count is placed at the row of its word within the vocabulary and
+// all other elements are 0.  Words not present in the vocabulary (or whose index lies outside
+// it) are skipped.  The resulting vector may be supplied directly to the Transform() method
+// of a fitted transformer.  VectoriseQuery returns nil if the vocabulary is empty.
+func VectoriseQuery(counts map[string]int, vocabulary map[string]int) *mat64.Vector {
+	if len(vocabulary) == 0 {
+		return nil
+	}
+	vec := mat64.NewVector(len(vocabulary), nil)
+	for word, count := range counts {
+		if i, ok := vocabulary[word]; ok && i >= 0 && i < len(vocabulary) {
+			vec.SetVec(i, float64(count))
 		}
 	}
-	return mat, nil
+	return vec
+}
+
+// TransformT is equivalent to Transform() but produces the transpose of the term document
+// matrix i.e. a document term matrix where each row, rather than each column, is a
+// feature vector representing one of the supplied documents.  Many classifiers and other
+// libraries expect samples (documents) to be rows and so TransformT avoids the need to
+// transpose (and copy) the output of Transform().  Note that the transformers within this
+// package expect documents to be columns.
+func (v *CountVectoriser) TransformT(docs ...string) (*mat64.Dense, error) {
+	if err := v.checkFitted(); err != nil {
+		return nil, err
+	}
+	return denseTermDocMatrix(len(v.Vocabulary), docs, v.count, true), nil
 }
 
 // FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
 // same matrix.  This is a convenience where separate trianing data is not being
-// used to fit the model i.e. the model is fitted on the fly to the test data.
+// used to fit the model i.e. the model is fitted on the fly to the test data.  An error
+// wrapping ErrEmptyCorpus is returned if no documents are supplied or ErrEmptyVocabulary if
+// the documents contain no terms once stop words and pruned terms are removed.
 func (v *CountVectoriser) FitTransform(docs ...string) (*mat64.Dense, error) {
-	v.Fit(docs...)
+	if err := v.fitCorpus(docs); err != nil {
+		return nil, err
+	}
 	return v.Transform(docs...)
 }
 
-func (v *CountVectoriser) tokenise(text string) []string {
-	// convert content to lower case
-	c := strings.ToLower(text)
+// FitTransformString is equivalent to FitTransform() but accepts the documents joined into
+// a single string, corpus, where each document is separated by sep e.g. "\n\n".  The corpus
+// is split on sep with a trailing separator ignored rather than producing an empty final
+// document (other empty documents, e.g. from consecutive separators, are retained so that
+// column indices match the positions of the documents within the corpus).  An error is
+// returned if sep is empty.
+func (v *CountVectoriser) FitTransformString(corpus, sep string) (*mat64.Dense, error) {
+	docs, err := splitCorpus(corpus, sep)
+	if err != nil {
+		return nil, err
+	}
+	return v.FitTransform(docs...)
+}
 
-	// match whole words, removing any punctuation/whitespace
-	words := v.wordTokeniser.FindAllString(c, -1)
+// TransformSparse is equivalent to Transform() but produces a sparse output matrix rather
+// than a dense one.  A dense term document matrix requires memory proportional to the size
+// of the vocabulary multiplied by the number of documents (8 bytes per element) e.g. a
+// corpus of 10,000 documents with a vocabulary of 100,000 terms would require 8GB.  As
+// each document typically contains only a tiny fraction of the vocabulary, the sparse
+// matrix requires memory proportional only to the number of distinct terms within each
+// document.  The sparse matrix may be supplied, as a mat64.Matrix, to the transformers
+// for further processing.
+func (v *CountVectoriser) TransformSparse(docs ...string) (*SparseMatrix, error) {
+	if err := v.checkFitted(); err != nil {
+		return nil, err
+	}
+
+	return sparseTermDocMatrix(len(v.Vocabulary), docs, v.count), nil
+}
+
+// FitTransformSparse is exactly equivalent to calling Fit() followed by TransformSparse()
+// on the same documents, returning the same errors as FitTransform() for empty corpora.
+func (v *CountVectoriser) FitTransformSparse(docs ...string) (*SparseMatrix, error) {
+	if err := v.fitCorpus(docs); err != nil {
+		return nil, err
+	}
+	return v.TransformSparse(docs...)
+}
+
+// fitCorpus fits the vectoriser to the supplied documents returning an error if there are
+// no documents or the fitted Vocabulary is empty (or, if FixedVocabulary, invalid).
+func (v *CountVectoriser) fitCorpus(docs []string) error {
+	v.Fit(docs...)
+	if len(docs) == 0 {
+		return fmt.Errorf("%w: no documents supplied", ErrEmptyCorpus)
+	}
+	if v.FixedVocabulary {
+		return validateVocabulary(v.Vocabulary)
+	}
+	return v.fitErr
+}
 
+// count adds the frequency of each term of the Vocabulary occuring within the document to
+// the row for the term within counts scaled by NGramWeights (or sets it to 1 if Binary).
+func (v *CountVectoriser) count(doc string, counts map[int]float64) {
+	terms, weights := v.weightedTerms(doc)
+
+	for k, term := range terms {
+		i, exists := v.Vocabulary[term]
+		if !exists {
+			continue
+		}
+		if v.Binary {
+			counts[i] = 1
+			continue
+		}
+		weight := v.ngramWeight(term)
+		if weights != nil {
+			weight *= weights[k]
+		}
+		counts[i] += weight
+	}
+}
+
+// ngramWeight returns the multiplier from NGramWeights for the n-gram size of the term or 1
+// if there is none.  The size is derived from the term itself so counting never depends
+// upon (or modifies) state recorded when the Vocabulary was built.
+func (v *CountVectoriser) ngramWeight(term string) float64 {
+	if len(v.NGramWeights) == 0 {
+		return 1
+	}
+	if weight, ok := v.NGramWeights[ngramSize(v.Analyser, term)]; ok {
+		return weight
+	}
+	return 1
+}
+
+// splitCorpus splits the supplied corpus into documents separated by sep, ignoring a
+// trailing separator.  An empty corpus contains no documents.
+func splitCorpus(corpus, sep string) ([]string, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("%w: document separator must not be empty", ErrInvalidArgument)
+	}
+	corpus = strings.TrimSuffix(corpus, sep)
+	if corpus == "" {
+		return nil, nil
+	}
+	return strings.Split(corpus, sep), nil
+}
+
+// checkFitted returns an error if the Vocabulary is empty.
+func (v *CountVectoriser) checkFitted() error {
+	if len(v.Vocabulary) == 0 && v.fitErr != nil {
+		return fmt.Errorf("CountVectoriser vocabulary is empty caused by %w", v.fitErr)
+	}
+	if len(v.Vocabulary) == 0 {
+		return fmt.Errorf("%w: CountVectoriser vocabulary is empty, either it has not been fitted or all terms were pruned", ErrNotFitted)
+	}
+	return nil
+}
+
+// countVectoriserEncodingVersion is the version of the binary encoding of CountVectoriser
+// written by MarshalBinary()
+const countVectoriserEncodingVersion = 1
+
+// countVectoriserState is the serialisable state of a CountVectoriser.  Fields may be added
+// in future versions but existing fields should not be removed or changed.
+type countVectoriserState struct {
+	Vocabulary      map[string]int
+	FixedVocabulary bool
+	MinNGram        int
+	MaxNGram        int
+	NGramWeights    map[int]float64
+	MinDF           float64
+	MaxDF           float64
+	DropUbiquitous  bool
+	MaxFeatures     int
+	Binary          bool
+	MaxTokens       int
+	Analyser        Analyser
+	StopWords       []string
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface, encoding the fitted
+// Vocabulary, stop words and configuration of the vectoriser so that it may be persisted
+// and later restored with UnmarshalBinary().  The Preprocessor, Tokeniser and Stemmer are
+// functions and so are not encoded.  The encoding begins with a version number to allow
+// the format to evolve.
+func (v *CountVectoriser) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(countVectoriserEncodingVersion)
+
+	state := countVectoriserState{
+		Vocabulary:      v.Vocabulary,
+		FixedVocabulary: v.FixedVocabulary,
+		MinNGram:        v.MinNGram,
+		MaxNGram:        v.MaxNGram,
+		NGramWeights:    v.NGramWeights,
+		MinDF:           v.MinDF,
+		MaxDF:           v.MaxDF,
+		DropUbiquitous:  v.DropUbiquitous,
+		MaxFeatures:     v.MaxFeatures,
+		Binary:          v.Binary,
+		MaxTokens:       v.MaxTokens,
+		Analyser:        v.Analyser,
+		StopWords:       stopWordList(v.stopWords),
+	}
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, fmt.Errorf("Failed to encode CountVectoriser caused by %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface, restoring the state
+// of a vectoriser previously encoded with MarshalBinary().  Any existing Vocabulary, stop
+// words and configuration are replaced but the Preprocessor, Tokeniser and Stemmer of the
+// vectoriser are retained and so should be configured as they were for the encoded
+// vectoriser (e.g. by unmarshalling into a vectoriser created with NewCountVectoriser()
+// where the defaults were used).
+func (v *CountVectoriser) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("Failed to decode CountVectoriser: no data")
+	}
+	if version := data[0]; version != countVectoriserEncodingVersion {
+		return fmt.Errorf("Failed to decode CountVectoriser: unsupported encoding version %d", version)
+	}
+
+	var state countVectoriserState
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&state); err != nil {
+		return fmt.Errorf("Failed to decode CountVectoriser caused by %w", err)
+	}
+
+	v.Vocabulary = state.Vocabulary
+	if v.Vocabulary == nil {
+		v.Vocabulary = make(map[string]int)
+	}
+	v.FixedVocabulary = state.FixedVocabulary
+	v.MinNGram = state.MinNGram
+	v.MaxNGram = state.MaxNGram
+	v.NGramWeights = state.NGramWeights
+	v.MinDF = state.MinDF
+	v.MaxDF = state.MaxDF
+	v.DropUbiquitous = state.DropUbiquitous
+	v.MaxFeatures = state.MaxFeatures
+	v.Binary = state.Binary
+	v.MaxTokens = state.MaxTokens
+	v.Analyser = state.Analyser
+	v.stopWords = stopWordSet(state.StopWords)
+	v.updateNGramSizes()
+	v.fitErr = nil
+
+	return nil
+}
+
+// stopWordList returns the stop words of the supplied set in alphabetical order
+func stopWordList(stopWords map[string]struct{}) []string {
+	if len(stopWords) == 0 {
+		return nil
+	}
+	words := make([]string, 0, len(stopWords))
+	for word := range stopWords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
 	return words
 }
+
+// denseTermDocMatrix constructs an r x len(docs) dense term document matrix, or its
+// len(docs) x r transpose if transpose is true, where count is called for each document to
+// populate a map of row indices to values for the corresponding column (or row if
+// transposed).
+func denseTermDocMatrix(r int, docs []string, count func(doc string, counts map[int]float64), transpose bool) *mat64.Dense {
+	if !transpose {
+		mat := mat64.NewDense(r, len(docs), nil)
+		// the dimensions of mat always match so fillTermDocMatrix cannot fail
+		fillTermDocMatrix(mat, r, docs, count)
+		return mat
+	}
+
+	mat := mat64.NewDense(len(docs), r, nil)
+	for d, doc := range docs {
+		counts := make(map[int]float64)
+		count(doc, counts)
+		for i, val := range counts {
+			mat.Set(d, i, val)
+		}
+	}
+	return mat
+}
+
+// fillTermDocMatrix populates the r x len(docs) destination matrix, dst, as a term
+// document matrix in the same way as denseTermDocMatrix, overwriting any existing values.
+// An error is returned if the dimensions of dst differ.
+func fillTermDocMatrix(dst *mat64.Dense, r int, docs []string, count func(doc string, counts map[int]float64)) error {
+	if m, n := dst.Dims(); m != r || n != len(docs) {
+		return fmt.Errorf("%w: destination matrix is %d x %d but term document matrix is %d x %d", ErrDimensionMismatch, m, n, r, len(docs))
+	}
+	for i := 0; i < r; i++ {
+		row := dst.RawRowView(i)
+		for j := range row {
+			row[j] = 0
+		}
+	}
+
+	for d, doc := range docs {
+		counts := make(map[int]float64)
+		count(doc, counts)
+		for i, val := range counts {
+			dst.Set(i, d, val)
+		}
+	}
+	return nil
+}
+
+// sparseTermDocMatrix constructs an r x len(docs) sparse term document matrix where count
+// is called for each document to populate a map of row indices to values for the
+// corresponding column.  Zero values are not stored.
+func sparseTermDocMatrix(r int, docs []string, count func(doc string, counts map[int]float64)) *SparseMatrix {
+	mat := &SparseMatrix{r: r, indptr: make([]int, 1, len(docs)+1)}
+
+	for _, doc := range docs {
+		counts := make(map[int]float64)
+		count(doc, counts)
+		mat.appendColumn(counts)
+	}
+	return mat
+}
+
+// terms extracts the terms from the supplied document using the configured Analyser.
+func (v *CountVectoriser) terms(doc string) []string {
+	terms, _ := v.weightedTerms(doc)
+	return terms
+}
+
+// weightedTerms extracts the terms from the supplied document in the same way as terms()
+// returning the weight of each term alongside it if the Tokeniser implements
+// WeightedTokeniser (otherwise the weights are nil).
+func (v *CountVectoriser) weightedTerms(doc string) ([]string, []float64) {
+	return analyse(v.Analyser, doc, v.Preprocessor, v.Tokeniser, v.MaxTokens, v.stopWords, v.Stemmer, v.MinNGram, v.MaxNGram)
+}
+
+// analyse extracts the terms from the supplied document following preprocessing (lower
+// casing if preprocessor is nil).  For WordAnalyser the document is tokenised into words,
+// truncated to maxTokens words (if greater than 0), stop words removed, the words stemmed and
+// then n-grams of between min and max words extracted.  If the tokeniser implements
+// WeightedTokeniser, the weight of each term is also returned where the weight of an n-gram
+// is the mean of the weights of its words.  For the character analysers the document is
+// truncated to maxTokens whitespace separated words (if greater than 0), n-grams of between
+// min and max characters are extracted and the weights are always nil.
+func analyse(analyser Analyser, doc string, preprocessor func(string) string, tokeniser Tokeniser, maxTokens int, stopWords map[string]struct{}, stemmer func(string) string, min, max int) ([]string, []float64) {
+	if preprocessor == nil {
+		preprocessor = strings.ToLower
+	}
+	doc = preprocessor(doc)
+
+	if analyser == CharAnalyser || analyser == CharWordBoundaryAnalyser {
+		if maxTokens > 0 {
+			if words := strings.Fields(doc); len(words) > maxTokens {
+				doc = strings.Join(words[:maxTokens], " ")
+			}
+		}
+		return charNGrams(doc, min, max, analyser == CharWordBoundaryAnalyser), nil
+	}
+
+	var words []string
+	var weights []float64
+	if weighted, ok := tokeniser.(WeightedTokeniser); ok {
+		tokens := weighted.TokeniseWeighted(doc)
+		words = make([]string, len(tokens))
+		weights = make([]float64, len(tokens))
+		for i, token := range tokens {
+			words[i], weights[i] = token.Term, token.Weight
+		}
+	} else {
+		words = tokeniser.Tokenise(doc)
+	}
+	if maxTokens > 0 && len(words) > maxTokens {
+		words = words[:maxTokens]
+		if weights != nil {
+			weights = weights[:maxTokens]
+		}
+	}
+	return extractTerms(words, weights, stopWords, stemmer, min, max)
+}
+
+// extractTerms removes any of the specified stop words from the supplied words, stems the
+// remaining words using stemmer (if not nil) and then extracts n-grams of between min and
+// max words.  If weights is not nil it holds the weight of each word and the weight of each
+// extracted n-gram is returned alongside it.
+func extractTerms(words []string, weights []float64, stopWords map[string]struct{}, stemmer func(string) string, min, max int) ([]string, []float64) {
+	// if enabled, remove stop words
+	if stopWords != nil {
+		filtered := words[:0]
+		var filteredWeights []float64
+		if weights != nil {
+			filteredWeights = weights[:0]
+		}
+		for i, word := range words {
+			if _, stop := stopWords[word]; !stop {
+				filtered = append(filtered, word)
+				if weights != nil {
+					filteredWeights = append(filteredWeights, weights[i])
+				}
+			}
+		}
+		words, weights = filtered, filteredWeights
+	}
+
+	if stemmer != nil {
+		for i, word := range words {
+			words[i] = stemmer(word)
+		}
+	}
+
+	return ngrams(words, min, max), ngramWeights(weights, min, max)
+}
+
+// ngrams returns all contiguous sequences of between min and max (inclusive) tokens from
+// the supplied tokens, joined by a single space.  Values of min or max less than 1 are
+// treated as 1.
+func ngrams(tokens []string, min, max int) []string {
+	min, max = ngramRange(min, max)
+	if min == 1 && max == 1 {
+		return tokens
+	}
+
+	var grams []string
+	for n := min; n <= max; n++ {
+		for i := 0; i+n <= len(tokens); i++ {
+			grams = append(grams, strings.Join(tokens[i:i+n], " "))
+		}
+	}
+	return grams
+}
+
+// ngramWeights returns the weight of each n-gram returned by ngrams() for tokens with the
+// supplied weights, being the mean of the weights of its tokens, or nil if weights is nil.
+func ngramWeights(weights []float64, min, max int) []float64 {
+	min, max = ngramRange(min, max)
+	if weights == nil || (min == 1 && max == 1) {
+		return weights
+	}
+
+	var grams []float64
+	for n := min; n <= max; n++ {
+		for i := 0; i+n <= len(weights); i++ {
+			var sum float64
+			for _, w := range weights[i : i+n] {
+				sum += w
+			}
+			grams = append(grams, sum/float64(n))
+		}
+	}
+	return grams
+}
+
+// charNGrams returns all contiguous sequences of between min and max (inclusive) characters
+// from the supplied text with runs of whitespace collapsed into a single space.  If
+// wordBoundaries is true, n-grams are only extracted from within each word, padded with a
+// space at either end, and words shorter than min characters (once padded) are returned
+// whole.  Values of min or max less than 1 are treated as 1.
+func charNGrams(text string, min, max int, wordBoundaries bool) []string {
+	min, max = ngramRange(min, max)
+	words := strings.Fields(text)
+
+	if !wordBoundaries {
+		return runeNGrams([]rune(strings.Join(words, " ")), min, max, nil)
+	}
+
+	var grams []string
+	for _, word := range words {
+		padded := []rune(" " + word + " ")
+		if len(padded) < min {
+			grams = append(grams, string(padded))
+			continue
+		}
+		grams = runeNGrams(padded, min, max, grams)
+	}
+	return grams
+}
+
+// runeNGrams appends all contiguous sequences of between min and max (inclusive) runes to
+// grams.
+func runeNGrams(runes []rune, min, max int, grams []string) []string {
+	for n := min; n <= max; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			grams = append(grams, string(runes[i:i+n]))
+		}
+	}
+	return grams
+}
+
+// ngramRange returns the range of n-gram sizes with values of min or max less than 1
+// treated as 1 and max no less than min.
+func ngramRange(min, max int) (int, int) {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+// HashingVectoriser can be used to encode one or more text documents into a term document
+// matrix in the same way as CountVectoriser except that, rather than learning a vocabulary
+// of terms from training data, each term is mapped to a row of the matrix by applying a
+// hash function to it (modulo the number of features/rows).  This is commonly referred to
+// as 'the hashing trick'.  As no vocabulary needs to be stored, HashingVectoriser requires
+// no fitting and uses significantly less memory than CountVectoriser making it suitable for
+// very large or streaming corpora.  The trade off is that multiple terms may hash to the
+// same row (particularly if the number of features is small relative to the number of
+// distinct terms) in which case their counts are combined.  Such collisions are expected and,
+// with a sufficiently large number of features, have little effect on subsequent analysis.
+// It is also not possible to map rows back to their original terms.
+type HashingVectoriser struct {
+	// Signed, if true, alternates the sign of the value added to the matrix for each term
+	// based upon a bit of the term's hash.  This causes collisions to tend to cancel each
+	// other out rather than accumulate, reducing the bias introduced by collisions.
+	Signed bool
+
+	// NumHashes, if greater than 1, hashes each term with NumHashes independent hash
+	// functions, adding 1/NumHashes of the term's value to each of the rows it hashes to
+	// rather than the whole value to a single row.  Two terms colliding under one hash
+	// function are unlikely to collide under the others and so collisions only partially
+	// merge terms, statistically reducing their effect on subsequent analysis at the cost of
+	// more non zero elements per term.  The default, 0, (or 1) uses a single hash function.
+	NumHashes int
+
+	// Seed, if non zero, salts the hash of each term so that different seeds map terms to
+	// different rows e.g. to produce independent hashed representations of the same
+	// documents.  Hashing uses FNV, which is fully specified and independent of Go version,
+	// platform and process, so the same Seed always maps a term to the same row.  The
+	// default, 0, applies no salt.
+	Seed uint32
+
+	// MinNGram and MaxNGram specify the range of n-gram sizes to extract from documents as
+	// terms in the same way as CountVectoriser.  By default only unigrams are extracted.
+	MinNGram int
+	MaxNGram int
+
+	// Preprocessor is applied to each document before it is tokenised in the same way as
+	// CountVectoriser.  By default (or if nil), documents are converted to lower case.
+	Preprocessor func(string) string
+
+	// Tokeniser is used to split documents into words in the same way as CountVectoriser.
+	Tokeniser Tokeniser
+
+	// MaxTokens, if greater than 0, truncates each document to its first MaxTokens tokens in
+	// the same way as CountVectoriser.
+	MaxTokens int
+
+	// Stemmer, if set, is applied to each word following stop word removal in the same
+	// way as CountVectoriser.
+	Stemmer func(string) string
+
+	// Analyser specifies whether terms are n-grams of words or characters in the same way as
+	// CountVectoriser.
+	Analyser Analyser
+
+	numFeatures int
+	stopWords   map[string]struct{}
+}
+
+// NewHashingVectoriser creates a new HashingVectoriser that produces term document matrices
+// with numFeatures rows.  Any specified stop words will be removed from documents.
+// numFeatures must be positive, otherwise the transform methods of the vectoriser return an
+// error wrapping ErrInvalidArgument.
+func NewHashingVectoriser(numFeatures int, stopWords ...string) *HashingVectoriser {
+	return &HashingVectoriser{
+		Preprocessor: strings.ToLower,
+		Tokeniser:    newDefaultTokeniser(),
+		numFeatures:  numFeatures,
+		stopWords:    stopWordSet(stopWords),
+	}
+}
+
+// NumFeatures returns the number of features (rows) in the term document matrices produced
+// by the HashingVectoriser.
+func (v *HashingVectoriser) NumFeatures() int {
+	return v.numFeatures
+}
+
+// Fit does nothing as HashingVectoriser does not require fitting.  It is provided to
+// implement the Vectoriser interface.
+func (v *HashingVectoriser) Fit(train ...string) Vectoriser {
+	return v
+}
+
+// Transform transforms the supplied documents into a term document matrix where each
+// column is a feature vector representing one of the supplied documents.  Each element
+// represents the frequency with which the terms hashing to that row occured within that
+// document.  The same term will always hash to the same row, even across separate calls.
+func (v *HashingVectoriser) Transform(docs ...string) (*mat64.Dense, error) {
+	if err := v.checkFeatures(); err != nil {
+		return nil, err
+	}
+	return denseTermDocMatrix(v.numFeatures, docs, v.count, false), nil
+}
+
+// checkFeatures returns an error if the number of features is not positive, in which case
+// terms cannot be hashed to rows.
+func (v *HashingVectoriser) checkFeatures() error {
+	if v.numFeatures <= 0 {
+		return fmt.Errorf("%w: HashingVectoriser number of features (%d) must be positive", ErrInvalidArgument, v.numFeatures)
+	}
+	return nil
+}
+
+// TransformInto is equivalent to Transform() but writes the term document matrix into the
+// supplied destination matrix, dst, rather than allocating a new matrix.  This allows the
+// same destination matrix to be reused across repeated calls e.g. by Pipeline.  dst must
+// have NumFeatures() rows and a column for each document.  Any existing values in dst are
+// overwritten.
+func (v *HashingVectoriser) TransformInto(dst *mat64.Dense, docs ...string) error {
+	if err := v.checkFeatures(); err != nil {
+		return err
+	}
+	return fillTermDocMatrix(dst, v.numFeatures, docs, v.count)
+}
+
+// TransformOne transforms a single document into a feature vector with NumFeatures()
+// elements.  This is equivalent to calling Transform() with the single document and taking
+// the only column of the output.
+func (v *HashingVectoriser) TransformOne(doc string) (*mat64.Vector, error) {
+	mat, err := v.Transform(doc)
+	if err != nil {
+		return nil, err
+	}
+	return mat.ColView(0), nil
+}
+
+// TransformT is equivalent to Transform() but produces a document term matrix where each
+// row, rather than each column, represents one of the supplied documents.  See
+// CountVectoriser.TransformT() for details.
+func (v *HashingVectoriser) TransformT(docs ...string) (*mat64.Dense, error) {
+	if err := v.checkFeatures(); err != nil {
+		return nil, err
+	}
+	return denseTermDocMatrix(v.numFeatures, docs, v.count, true), nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (v *HashingVectoriser) FitTransform(docs ...string) (*mat64.Dense, error) {
+	return v.Fit(docs...).Transform(docs...)
+}
+
+// FitTransformString is equivalent to FitTransform() but accepts the documents joined into
+// a single string separated by sep.  See CountVectoriser.FitTransformString() for details.
+func (v *HashingVectoriser) FitTransformString(corpus, sep string) (*mat64.Dense, error) {
+	docs, err := splitCorpus(corpus, sep)
+	if err != nil {
+		return nil, err
+	}
+	return v.FitTransform(docs...)
+}
+
+// TransformSparse is equivalent to Transform() but produces a sparse output matrix rather
+// than a dense one, requiring memory proportional only to the number of distinct terms
+// within each document rather than the number of features.  See
+// CountVectoriser.TransformSparse() for details.
+func (v *HashingVectoriser) TransformSparse(docs ...string) (*SparseMatrix, error) {
+	if err := v.checkFeatures(); err != nil {
+		return nil, err
+	}
+	return sparseTermDocMatrix(v.numFeatures, docs, v.count), nil
+}
+
+// TransformReader is equivalent to TransformSparse() but reads the documents from the
+// supplied reader, where each document is terminated by the specified delimiter e.g. '\n'
+// for one document per line.  Documents are read and vectorised one at a time, appending a
+// column to the output matrix for each, so the documents never need to be held in memory
+// together.  The final document need not be terminated by the delimiter.  Empty documents
+// (e.g. blank lines) produce columns of zeros.
+func (v *HashingVectoriser) TransformReader(r io.Reader, delim byte) (*SparseMatrix, error) {
+	if err := v.checkFeatures(); err != nil {
+		return nil, err
+	}
+	mat := &SparseMatrix{r: v.numFeatures, indptr: []int{0}}
+	reader := bufio.NewReader(r)
+
+	for {
+		doc, err := reader.ReadString(delim)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Failed to read document %d caused by %w", mat.c, err)
+		}
+		if err == io.EOF && len(doc) == 0 {
+			break
+		}
+
+		counts := make(map[int]float64)
+		v.count(strings.TrimSuffix(doc, string(delim)), counts)
+		mat.appendColumn(counts)
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return mat, nil
+}
+
+// count adds the value for each occurance of each term within the document to the row(s)
+// the term hashes to within counts.
+func (v *HashingVectoriser) count(doc string, counts map[int]float64) {
+	if v.NumHashes <= 1 {
+		for _, term := range v.terms(doc) {
+			i, sign := v.hash(term)
+			counts[i] += sign
+		}
+		return
+	}
+
+	value := 1 / float64(v.NumHashes)
+	for _, term := range v.terms(doc) {
+		for k := 0; k < v.NumHashes; k++ {
+			i, sign := v.hashK(term, k)
+			counts[i] += sign * value
+		}
+	}
+}
+
+// terms extracts the terms from the supplied document using the configured Analyser.
+func (v *HashingVectoriser) terms(doc string) []string {
+	terms, _ := analyse(v.Analyser, doc, v.Preprocessor, v.Tokeniser, v.MaxTokens, v.stopWords, v.Stemmer, v.MinNGram, v.MaxNGram)
+	return terms
+}
+
+// hash returns the row index for the term along with the value (1 or -1 if Signed) to add
+// to the matrix for each occurance.
+func (v *HashingVectoriser) hash(term string) (int, float64) {
+	return v.bucket(v.sum(fnv.New32a(), term))
+}
+
+// hashK returns the row index and value for the term under the kth of NumHashes hash
+// functions.  The hash functions are derived from 2 independent hashes of the term by double
+// hashing i.e. h1 + k*h2 so that the first (k = 0) is identical to hash().
+func (v *HashingVectoriser) hashK(term string, k int) (int, float64) {
+	h1 := v.sum(fnv.New32a(), term)
+	h2 := v.sum(fnv.New32(), term)
+
+	// h2 must be odd so that successive hash functions do not repeat
+	return v.bucket(h1 + uint32(k)*(h2|1))
+}
+
+// sum returns the hash of the term, salted with Seed (if non zero), using the supplied hash
+// function.
+func (v *HashingVectoriser) sum(h hash.Hash32, term string) uint32 {
+	if v.Seed != 0 {
+		var salt [4]byte
+		binary.LittleEndian.PutUint32(salt[:], v.Seed)
+		h.Write(salt[:])
+	}
+	h.Write([]byte(term))
+	return h.Sum32()
+}
+
+// bucket returns the row index for the supplied hash value along with the value (1 or -1 if
+// Signed) to add to the matrix.
+func (v *HashingVectoriser) bucket(sum uint32) (int, float64) {
+	sign := 1.0
+	if v.Signed && sum&(1<<31) != 0 {
+		sign = -1.0
+	}
+	return int(sum % uint32(v.numFeatures)), sign
+}
+
+// hashingVectoriserEncodingVersion is the version of the binary encoding of
+// HashingVectoriser written by MarshalBinary()
+const hashingVectoriserEncodingVersion = 1
+
+// hashingVectoriserState is the serialisable state of a HashingVectoriser.  Fields may be
+// added in future versions but existing fields should not be removed or changed.
+type hashingVectoriserState struct {
+	NumFeatures int
+	Signed      bool
+	NumHashes   int
+	Seed        uint32
+	MinNGram    int
+	MaxNGram    int
+	MaxTokens   int
+	Analyser    Analyser
+	StopWords   []string
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface, encoding the number of
+// features, stop words and configuration of the vectoriser so that it may be persisted and
+// later restored with UnmarshalBinary() in the same way as CountVectoriser.  The
+// Preprocessor, Tokeniser and Stemmer are not encoded.
+func (v *HashingVectoriser) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(hashingVectoriserEncodingVersion)
+
+	state := hashingVectoriserState{
+		NumFeatures: v.numFeatures,
+		Signed:      v.Signed,
+		NumHashes:   v.NumHashes,
+		Seed:        v.Seed,
+		MinNGram:    v.MinNGram,
+		MaxNGram:    v.MaxNGram,
+		MaxTokens:   v.MaxTokens,
+		Analyser:    v.Analyser,
+		StopWords:   stopWordList(v.stopWords),
+	}
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, fmt.Errorf("Failed to encode HashingVectoriser caused by %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface, restoring the state
+// of a vectoriser previously encoded with MarshalBinary().  As for CountVectoriser, the
+// Preprocessor, Tokeniser and Stemmer of the vectoriser are retained.
+func (v *HashingVectoriser) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("Failed to decode HashingVectoriser: no data")
+	}
+	if version := data[0]; version != hashingVectoriserEncodingVersion {
+		return fmt.Errorf("Failed to decode HashingVectoriser: unsupported encoding version %d", version)
+	}
+
+	var state hashingVectoriserState
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&state); err != nil {
+		return fmt.Errorf("Failed to decode HashingVectoriser caused by %w", err)
+	}
+
+	v.numFeatures = state.NumFeatures
+	v.Signed = state.Signed
+	v.NumHashes = state.NumHashes
+	v.Seed = state.Seed
+	v.MinNGram = state.MinNGram
+	v.MaxNGram = state.MaxNGram
+	v.MaxTokens = state.MaxTokens
+	v.Analyser = state.Analyser
+	v.stopWords = stopWordSet(state.StopWords)
+
+	return nil
+}
@@ -1,6 +1,17 @@
 package nlp
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
 
 var trainSet = []string{
 	"The quick brown fox jumped over the. Lazy dog",
@@ -44,6 +55,41 @@ func TestCountVectoriserFit(t *testing.T) {
 	}
 }
 
+func TestCountVectoriserDeterministicVocabulary(t *testing.T) {
+	docs := []string{"the quick brown fox", "the lazy dog", "a quick dog jumps"}
+
+	configure := []func(v *CountVectoriser){
+		func(v *CountVectoriser) {},
+		func(v *CountVectoriser) { v.MaxNGram = 2 },
+		func(v *CountVectoriser) { v.MaxFeatures = 4 },
+		func(v *CountVectoriser) { v.MinDF = 2 },
+	}
+
+	for c, config := range configure {
+		first := NewCountVectoriser(false)
+		config(first)
+		expected, _ := first.FitTransform(docs...)
+
+		for run := 0; run < 10; run++ {
+			v := NewCountVectoriser(false)
+			config(v)
+			mat, _ := v.FitTransform(docs...)
+			if !reflect.DeepEqual(first.Vocabulary, v.Vocabulary) || !mat64.Equal(expected, mat) {
+				t.Fatalf("Config %d: Expected identical vocabulary and matrix for each fit but found %v and %v",
+					c, first.Vocabulary, v.Vocabulary)
+			}
+		}
+	}
+
+	// terms are indexed in order of first occurance
+	v := NewCountVectoriser(false)
+	v.Fit(docs...)
+	expected := map[string]int{"the": 0, "quick": 1, "brown": 2, "fox": 3, "lazy": 4, "dog": 5, "a": 6, "jumps": 7}
+	if !reflect.DeepEqual(expected, v.Vocabulary) {
+		t.Errorf("Expected vocabulary %v but found %v", expected, v.Vocabulary)
+	}
+}
+
 func TestCountVectoriserTransform(t *testing.T) {
 	var tests = []struct {
 		train     []string
@@ -75,3 +121,1338 @@ func TestCountVectoriserTransform(t *testing.T) {
 		}
 	}
 }
+
+func TestCountVectoriserCounts(t *testing.T) {
+	docs := []string{
+		"the dog chased the cat",
+		"The cat sat",
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	mat, err := vectoriser.FitTransform(docs...)
+
+	if err != nil {
+		t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+	}
+
+	m, n := mat.Dims()
+	if m != len(vectoriser.Vocabulary) || n != len(docs) {
+		t.Fatalf("Expected terms as rows and documents as columns (%d x %d) but found %d x %d",
+			len(vectoriser.Vocabulary), len(docs), m, n)
+	}
+
+	var tests = []struct {
+		term   string
+		counts []float64
+	}{
+		{"the", []float64{2, 1}},
+		{"dog", []float64{1, 0}},
+		{"chased", []float64{1, 0}},
+		{"cat", []float64{1, 1}},
+		{"sat", []float64{0, 1}},
+	}
+
+	for _, test := range tests {
+		i, ok := vectoriser.Vocabulary[test.term]
+		if !ok {
+			t.Errorf("Expected term '%s' in vocabulary %v", test.term, vectoriser.Vocabulary)
+			continue
+		}
+		for j, count := range test.counts {
+			if mat.At(i, j) != count {
+				t.Errorf("Expected count %f for term '%s' in document %d but found %f",
+					count, test.term, j, mat.At(i, j))
+			}
+		}
+	}
+}
+
+func TestCountVectoriserStopWords(t *testing.T) {
+	var tests = []struct {
+		stop      []string
+		vocabSize int
+		removed   []string
+	}{
+		{nil, 26, nil},
+		{EnglishStopWords(), 18, []string{"the", "over", "on", "to", "see", "such", "around", "and"}},
+		{[]string{"The", "dog", "cow"}, 23, []string{"the", "dog", "cow"}},
+		{append(EnglishStopWords(), "dog", "cow"), 16, []string{"the", "and", "dog", "cow"}},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriserWithStopWords(test.stop...)
+		vectoriser.Fit(trainSet...)
+
+		if len(vectoriser.Vocabulary) != test.vocabSize {
+			t.Errorf("Expected vocabulary of size %d but found %d: %v",
+				test.vocabSize, len(vectoriser.Vocabulary), vectoriser.Vocabulary)
+		}
+
+		for _, word := range test.removed {
+			if _, ok := vectoriser.Vocabulary[word]; ok {
+				t.Errorf("Expected stop word '%s' to be absent from vocabulary %v", word, vectoriser.Vocabulary)
+			}
+		}
+
+		// indices should be contiguous
+		for _, i := range vectoriser.Vocabulary {
+			if i < 0 || i >= len(vectoriser.Vocabulary) {
+				t.Errorf("Expected contiguous vocabulary indices but found index %d in vocabulary of size %d",
+					i, len(vectoriser.Vocabulary))
+			}
+		}
+	}
+
+	words := EnglishStopWords()
+	words[0] = "modified"
+	if EnglishStopWords()[0] == "modified" {
+		t.Errorf("Expected EnglishStopWords() to return a copy")
+	}
+}
+
+func TestCountVectoriserNGrams(t *testing.T) {
+	docs := []string{
+		"the quick brown fox",
+		"quick brown dog",
+	}
+
+	var tests = []struct {
+		min, max  int
+		vocabSize int
+		counts    map[string][]float64
+		absent    []string
+	}{
+		{
+			min: 1, max: 1,
+			vocabSize: 5,
+			counts: map[string][]float64{
+				"quick": {1, 1},
+				"dog":   {0, 1},
+			},
+			absent: []string{"quick brown"},
+		},
+		{
+			min: 1, max: 2,
+			vocabSize: 9,
+			counts: map[string][]float64{
+				"quick":       {1, 1},
+				"the quick":   {1, 0},
+				"quick brown": {1, 1},
+				"brown fox":   {1, 0},
+				"brown dog":   {0, 1},
+			},
+		},
+		{
+			min: 2, max: 2,
+			vocabSize: 4,
+			counts: map[string][]float64{
+				"quick brown": {1, 1},
+			},
+			absent: []string{"quick", "brown"},
+		},
+		{
+			// the second document is too short to produce any 4-grams
+			min: 4, max: 4,
+			vocabSize: 1,
+			counts: map[string][]float64{
+				"the quick brown fox": {1, 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriser(false)
+		vectoriser.MinNGram = test.min
+		vectoriser.MaxNGram = test.max
+
+		mat, err := vectoriser.FitTransform(docs...)
+		if err != nil {
+			t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+		}
+
+		if len(vectoriser.Vocabulary) != test.vocabSize {
+			t.Errorf("Expected vocabulary of size %d for n-gram range (%d, %d) but found %d: %v",
+				test.vocabSize, test.min, test.max, len(vectoriser.Vocabulary), vectoriser.Vocabulary)
+		}
+
+		for term, counts := range test.counts {
+			i, ok := vectoriser.Vocabulary[term]
+			if !ok {
+				t.Errorf("Expected term '%s' in vocabulary %v", term, vectoriser.Vocabulary)
+				continue
+			}
+			for j, count := range counts {
+				if mat.At(i, j) != count {
+					t.Errorf("Expected count %f for term '%s' in document %d but found %f",
+						count, term, j, mat.At(i, j))
+				}
+			}
+		}
+
+		for _, term := range test.absent {
+			if _, ok := vectoriser.Vocabulary[term]; ok {
+				t.Errorf("Expected term '%s' to be absent from vocabulary %v", term, vectoriser.Vocabulary)
+			}
+		}
+	}
+}
+
+func TestCountVectoriserNGramWeights(t *testing.T) {
+	docs := []string{
+		"the quick brown fox",
+		"quick brown dog quick brown",
+	}
+
+	var tests = []struct {
+		weights map[int]float64
+		binary  bool
+		sparse  bool
+	}{
+		{weights: nil},
+		{weights: map[int]float64{2: 3}},
+		{weights: map[int]float64{1: 0.5, 2: 2}},
+		{weights: map[int]float64{2: 3}, binary: true},
+		{weights: map[int]float64{2: 3}, sparse: true},
+	}
+
+	for ti, test := range tests {
+		unweighted := NewCountVectoriser(false)
+		unweighted.MinNGram, unweighted.MaxNGram = 1, 2
+		unweighted.Binary = test.binary
+		expected, _ := unweighted.FitTransform(docs...)
+
+		vectoriser := NewCountVectoriser(false)
+		vectoriser.MinNGram, vectoriser.MaxNGram = 1, 2
+		vectoriser.Binary = test.binary
+		vectoriser.NGramWeights = test.weights
+
+		var mat mat64.Matrix
+		var err error
+		if test.sparse {
+			mat, err = vectoriser.FitTransformSparse(docs...)
+		} else {
+			mat, err = vectoriser.FitTransform(docs...)
+		}
+		if err != nil {
+			t.Errorf("Test %d: Error fitting and applying vectoriser caused by %v", ti, err)
+			continue
+		}
+
+		sizes := vectoriser.NGramSizes()
+		for term, i := range vectoriser.Vocabulary {
+			n := strings.Count(term, " ") + 1
+			if sizes[i] != n {
+				t.Errorf("Test %d: Expected n-gram size %d for term '%s' but found %d", ti, n, term, sizes[i])
+			}
+			// binary output records presence only so is never weighted
+			weight, ok := test.weights[n]
+			if !ok || test.binary {
+				weight = 1
+			}
+			for j := range docs {
+				if mat.At(i, j) != expected.At(i, j)*weight {
+					t.Errorf("Test %d: Expected %f for term '%s' in document %d but found %f",
+						ti, expected.At(i, j)*weight, term, j, mat.At(i, j))
+				}
+			}
+		}
+	}
+
+	// n-gram sizes are recorded for predefined vocabularies and returned as copies
+	vectoriser, err := NewCountVectoriserWithVocabulary(map[string]int{"quick brown": 0, "fox": 1})
+	if err != nil {
+		t.Fatalf("Failed to create vectoriser caused by %v", err)
+	}
+	sizes := vectoriser.NGramSizes()
+	if !reflect.DeepEqual(sizes, []int{2, 1}) {
+		t.Errorf("Expected n-gram sizes [2 1] but found %v", sizes)
+	}
+	sizes[0] = 5
+	if sizes := vectoriser.NGramSizes(); !reflect.DeepEqual(sizes, []int{2, 1}) {
+		t.Errorf("Expected n-gram sizes to be unaffected by modifying the copy but found %v", sizes)
+	}
+
+	// weights are applied according to the terms themselves, even for a vocabulary
+	// assigned directly
+	vectoriser = NewCountVectoriser(false)
+	vectoriser.MaxNGram = 2
+	vectoriser.NGramWeights = map[int]float64{2: 3}
+	vectoriser.Vocabulary = map[string]int{"fox": 0, "quick brown": 1}
+	if mat, err := vectoriser.Transform("quick brown fox"); err != nil || mat.At(0, 0) != 1 || mat.At(1, 0) != 3 {
+		t.Errorf("Expected weighted counts [1 3] but found %v (%v)", mat, err)
+	}
+
+	// Transform only reads the vectoriser and so may be called concurrently
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := vectoriser.Transform("quick brown fox"); err != nil {
+				t.Errorf("Failed to transform caused by %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// character analysers measure n-gram size in characters
+	vectoriser = NewCountVectoriser(false)
+	vectoriser.Analyser = CharAnalyser
+	vectoriser.MinNGram, vectoriser.MaxNGram = 2, 3
+	vectoriser.Fit("abc")
+	if sizes := vectoriser.NGramSizes(); !reflect.DeepEqual(sizes, []int{2, 2, 3}) {
+		t.Errorf("Expected n-gram sizes [2 2 3] but found %v", sizes)
+	}
+}
+
+func TestVectoriserFitTransformString(t *testing.T) {
+	docs := []string{"the quick brown fox", "jumped over the lazy dog"}
+
+	var tests = []struct {
+		corpus string
+		docs   []string
+	}{
+		{corpus: "the quick brown fox\n\njumped over the lazy dog", docs: docs},
+		{corpus: "the quick brown fox\n\njumped over the lazy dog\n\n", docs: docs},
+		{corpus: "the quick brown fox\n\n\n\njumped over the lazy dog", docs: []string{docs[0], "", docs[1]}},
+	}
+
+	for _, test := range tests {
+		for _, vectoriser := range []interface {
+			Vectoriser
+			FitTransformString(corpus, sep string) (*mat64.Dense, error)
+		}{NewCountVectoriser(false), NewHashingVectoriser(100)} {
+			expected, _ := vectoriser.FitTransform(test.docs...)
+			mat, err := vectoriser.FitTransformString(test.corpus, "\n\n")
+			if err != nil {
+				t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+				continue
+			}
+			if !mat64.Equal(expected, mat) {
+				t.Logf("%T: Expected matrix for corpus %q: \n%v\n but found: \n%v\n",
+					vectoriser, test.corpus, mat64.Formatted(expected), mat64.Formatted(mat))
+				t.Fail()
+			}
+		}
+	}
+
+	if _, err := NewCountVectoriser(false).FitTransformString("the fox", ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' for empty separator but found '%v'", ErrInvalidArgument, err)
+	}
+}
+
+func TestHashingVectoriserTransform(t *testing.T) {
+	var tests = []struct {
+		numFeatures int
+		signed      bool
+		stop        []string
+		test        []string
+	}{
+		{260, false, nil, testSet},
+		{10, false, nil, testSet},
+		{260, true, nil, testSet},
+		{260, false, EnglishStopWords(), testSet},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewHashingVectoriser(test.numFeatures, test.stop...)
+		vectoriser.Signed = test.signed
+
+		if vectoriser.NumFeatures() != test.numFeatures {
+			t.Errorf("Expected %d features but found %d", test.numFeatures, vectoriser.NumFeatures())
+		}
+
+		vec, err := vectoriser.FitTransform(test.test...)
+		if err != nil {
+			t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+		}
+
+		m, n := vec.Dims()
+		if m != test.numFeatures || n != len(test.test) {
+			t.Errorf("Expected matrix %d x %d but found %d x %d", test.numFeatures, len(test.test), m, n)
+		}
+
+		// transforming the same documents (in any order) should produce identical columns
+		vec2, err := vectoriser.Transform(test.test[1], test.test[0])
+		if err != nil {
+			t.Errorf("Error applying vectoriser caused by %v", err)
+		}
+		for i := 0; i < m; i++ {
+			if vec.At(i, 0) != vec2.At(i, 1) || vec.At(i, 1) != vec2.At(i, 0) {
+				t.Errorf("Expected deterministic hashing but row %d differed between calls", i)
+				break
+			}
+		}
+
+		// unsigned counts should total the number of terms in each document
+		if !test.signed {
+			for j, doc := range test.test {
+				terms := vectoriser.terms(doc)
+				var total float64
+				for i := 0; i < m; i++ {
+					total += vec.At(i, j)
+				}
+				if total != float64(len(terms)) {
+					t.Errorf("Expected counts for document %d to total %d but found %f", j, len(terms), total)
+				}
+			}
+		}
+	}
+}
+
+func TestVectoriserMarshalBinary(t *testing.T) {
+	count := NewCountVectoriserWithStopWords("the", "over")
+	count.MaxNGram = 2
+	count.Binary = true
+	count.Fit(trainSet...)
+	hashing := NewHashingVectoriser(128, "the")
+	hashing.Signed = true
+	hashing.Seed = 7
+
+	var tests = []struct {
+		vectoriser interface {
+			Vectoriser
+			MarshalBinary() ([]byte, error)
+		}
+		restored interface {
+			Vectoriser
+			UnmarshalBinary([]byte) error
+		}
+	}{
+		{vectoriser: count, restored: NewCountVectoriser(false)},
+		{vectoriser: hashing, restored: NewHashingVectoriser(0)},
+	}
+
+	for _, test := range tests {
+		expected, err := test.vectoriser.Transform(testSet...)
+		if err != nil {
+			t.Fatalf("%T: Failed to transform documents caused by %v", test.vectoriser, err)
+		}
+
+		data, err := test.vectoriser.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%T: Failed to marshal vectoriser caused by %v", test.vectoriser, err)
+		}
+		if err := test.restored.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%T: Failed to unmarshal vectoriser caused by %v", test.vectoriser, err)
+		}
+
+		result, err := test.restored.Transform(testSet...)
+		if err != nil {
+			t.Fatalf("%T: Failed to transform documents caused by %v", test.vectoriser, err)
+		}
+		if !mat64.Equal(expected, result) {
+			t.Logf("%T: Expected matrix: \n%v\n but found: \n%v\n", test.vectoriser,
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+
+		data[0] = 0
+		if err := test.restored.UnmarshalBinary(data); err == nil {
+			t.Errorf("%T: Expected error unmarshalling unsupported version but found none", test.vectoriser)
+		}
+	}
+}
+
+func TestHashingVectoriserInvalidFeatures(t *testing.T) {
+	for _, numFeatures := range []int{0, -1} {
+		vectoriser := NewHashingVectoriser(numFeatures)
+
+		var tests = []struct {
+			name      string
+			transform func() error
+		}{
+			{"Transform", func() error { _, err := vectoriser.Transform("a b"); return err }},
+			{"TransformT", func() error { _, err := vectoriser.TransformT("a b"); return err }},
+			{"TransformSparse", func() error { _, err := vectoriser.TransformSparse("a b"); return err }},
+			{"TransformInto", func() error { return vectoriser.TransformInto(mat64.NewDense(1, 1, nil), "a b") }},
+			{"TransformReader", func() error { _, err := vectoriser.TransformReader(strings.NewReader("a b"), '\n'); return err }},
+		}
+		for _, test := range tests {
+			if err := test.transform(); !errors.Is(err, ErrInvalidArgument) {
+				t.Errorf("%s with %d features: Expected error wrapping '%v' but found '%v'", test.name, numFeatures, ErrInvalidArgument, err)
+			}
+		}
+	}
+}
+
+func TestHashingVectoriserSeed(t *testing.T) {
+	docs := []string{"the quick brown fox", "the lazy dog", "fox and dog"}
+
+	newVectoriser := func(seed uint32) *HashingVectoriser {
+		v := NewHashingVectoriser(1024)
+		v.Seed = seed
+		return v
+	}
+
+	// rows are pinned so that mappings remain stable across Go versions and platforms
+	var tests = []struct {
+		seed uint32
+		term string
+		row  int
+	}{
+		{seed: 0, term: "fox", row: 846},
+		{seed: 0, term: "dog", row: 265},
+		{seed: 42, term: "fox", row: 268},
+		{seed: 42, term: "dog", row: 663},
+	}
+	for _, test := range tests {
+		if row, _ := newVectoriser(test.seed).hash(test.term); row != test.row {
+			t.Errorf("Expected '%s' to hash to row %d with seed %d but found %d", test.term, test.row, test.seed, row)
+		}
+	}
+
+	a, _ := newVectoriser(42).Transform(docs...)
+	b, _ := newVectoriser(42).Transform(docs...)
+	if !mat64.Equal(a, b) {
+		t.Errorf("Expected identical matrices for the same seed")
+	}
+
+	c, _ := newVectoriser(7).Transform(docs...)
+	if mat64.Equal(a, c) {
+		t.Errorf("Expected different matrices for different seeds")
+	}
+	if mat64.Sum(a) != mat64.Sum(c) {
+		t.Errorf("Expected the same total counts for different seeds but found %f and %f", mat64.Sum(a), mat64.Sum(c))
+	}
+}
+
+func TestHashingVectoriserNumHashes(t *testing.T) {
+	single := NewHashingVectoriser(16)
+	multi := NewHashingVectoriser(16)
+	multi.NumHashes = 3
+
+	// craft a pair of terms colliding under the single hash function
+	var a, b string
+	seen := make(map[int]string)
+	for n := 0; a == ""; n++ {
+		term := fmt.Sprintf("term%d", n)
+		row, _ := single.hash(term)
+		if other, ok := seen[row]; ok {
+			a, b = other, term
+		}
+		seen[row] = term
+	}
+
+	singleMat, _ := single.Transform(a, b)
+	multiMat, _ := multi.Transform(a, b)
+
+	// with a single hash function the colliding terms are indistinguishable
+	singleSim := CosineSimilarity(singleMat.ColView(0), singleMat.ColView(1))
+	if math.Abs(singleSim-1) > 0.000001 {
+		t.Errorf("Expected colliding terms to have similarity 1 but found %f", singleSim)
+	}
+	multiSim := CosineSimilarity(multiMat.ColView(0), multiMat.ColView(1))
+	if multiSim >= singleSim {
+		t.Errorf("Expected multiple hashes to reduce the similarity of colliding terms %q and %q below %f but found %f", a, b, singleSim, multiSim)
+	}
+
+	// each occurance of a term contributes a total of 1 across its rows
+	mat, _ := multi.Transform("the quick brown fox")
+	if sum := mat64.Sum(mat); math.Abs(sum-4) > 0.000001 {
+		t.Errorf("Expected values to total 4 but found %f", sum)
+	}
+	// the first hash function matches the single hash function
+	if row, _ := single.hash(a); mat64.Col(nil, 0, multiMat)[row] == 0 {
+		t.Errorf("Expected the first hash function to match the single hash function")
+	}
+}
+
+func TestCountVectoriserFixedVocabulary(t *testing.T) {
+	vocabulary := map[string]int{"fox": 2, "dog": 0, "quick": 1}
+	vectoriser, err := NewCountVectoriserWithVocabulary(vocabulary)
+	if err != nil {
+		t.Fatalf("Failed to create vectoriser caused by %v", err)
+	}
+
+	mat, err := vectoriser.FitTransform("the quick brown fox", "the lazy dog and the fox", "fox fox")
+	if err != nil {
+		t.Fatalf("Failed to fit and transform documents caused by %v", err)
+	}
+	if !reflect.DeepEqual(vocabulary, vectoriser.Vocabulary) {
+		t.Errorf("Expected vocabulary %v to be unchanged by fitting but found %v", vocabulary, vectoriser.Vocabulary)
+	}
+
+	// unknown words are ignored and rows follow the supplied indices
+	expected := mat64.NewDense(3, 3, []float64{
+		0, 1, 0,
+		1, 0, 0,
+		1, 1, 2,
+	})
+	if !mat64.Equal(expected, mat) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(mat))
+		t.Fail()
+	}
+
+	vectoriser.PartialFit("a zebra")
+	if _, ok := vectoriser.Vocabulary["zebra"]; ok || len(vectoriser.Vocabulary) != 3 {
+		t.Errorf("Expected PartialFit() to leave fixed vocabulary unchanged but found %v", vectoriser.Vocabulary)
+	}
+
+	var invalid = []struct {
+		vocabulary map[string]int
+		expected   error
+	}{
+		{vocabulary: nil, expected: ErrEmptyVocabulary},
+		{vocabulary: map[string]int{"fox": 0, "dog": 0}, expected: ErrInvalidArgument},
+		{vocabulary: map[string]int{"fox": 0, "dog": 2}, expected: ErrInvalidArgument},
+		{vocabulary: map[string]int{"fox": -1}, expected: ErrInvalidArgument},
+	}
+	for _, test := range invalid {
+		if _, err := NewCountVectoriserWithVocabulary(test.vocabulary); !errors.Is(err, test.expected) {
+			t.Errorf("Expected error wrapping '%v' for vocabulary %v but found '%v'", test.expected, test.vocabulary, err)
+		}
+		v := NewCountVectoriser(false)
+		v.Vocabulary = test.vocabulary
+		v.FixedVocabulary = true
+		if _, err := v.FitTransform("the fox"); !errors.Is(err, test.expected) {
+			t.Errorf("Expected error wrapping '%v' fitting vocabulary %v but found '%v'", test.expected, test.vocabulary, err)
+		}
+	}
+}
+
+func TestCountVectoriserDocumentFrequencyPruning(t *testing.T) {
+	docs := []string{
+		"apple banana cherry",
+		"apple banana date",
+		"apple cherry elderberry",
+		"apple banana fig",
+	}
+
+	var tests = []struct {
+		minDF, maxDF float64
+		vocabulary   []string
+	}{
+		{0, 0, []string{"apple", "banana", "cherry", "date", "elderberry", "fig"}},
+		{2, 0, []string{"apple", "banana", "cherry"}},
+		{0, 3, []string{"banana", "cherry", "date", "elderberry", "fig"}},
+		{2, 3, []string{"banana", "cherry"}},
+		{0.5, 0.8, []string{"banana", "cherry"}},
+		{0, 0.5, []string{"cherry", "date", "elderberry", "fig"}},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriser(false)
+		vectoriser.MinDF = test.minDF
+		vectoriser.MaxDF = test.maxDF
+
+		mat, err := vectoriser.FitTransform(docs...)
+		if err != nil {
+			t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+			continue
+		}
+
+		if len(vectoriser.Vocabulary) != len(test.vocabulary) {
+			t.Errorf("Expected vocabulary %v for MinDF %f and MaxDF %f but found %v",
+				test.vocabulary, test.minDF, test.maxDF, vectoriser.Vocabulary)
+			continue
+		}
+
+		// indices should be renumbered contiguously in order of first occurance
+		for i, term := range test.vocabulary {
+			if index, ok := vectoriser.Vocabulary[term]; !ok || index != i {
+				t.Errorf("Expected term '%s' at index %d but found %v", term, i, vectoriser.Vocabulary)
+			}
+		}
+
+		m, _ := mat.Dims()
+		if m != len(test.vocabulary) {
+			t.Errorf("Expected %d rows but found %d", len(test.vocabulary), m)
+		}
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.MinDF = 5
+	if _, err := vectoriser.FitTransform(docs...); err == nil {
+		t.Errorf("Expected error when all terms are pruned but found none")
+	}
+}
+
+func TestCountVectoriserPartialFit(t *testing.T) {
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.MinNGram, vectoriser.MaxNGram = 1, 2
+
+	vectoriser.PartialFit("the quick fox", "the fox")
+	first := map[string]int{"the": 0, "quick": 1, "fox": 2, "the quick": 3, "quick fox": 4, "the fox": 5}
+	if !reflect.DeepEqual(vectoriser.Vocabulary, first) {
+		t.Errorf("Expected vocabulary %v but found %v", first, vectoriser.Vocabulary)
+	}
+
+	vectoriser.PartialFit("the lazy fox")
+	expected := map[string]int{"the": 0, "quick": 1, "fox": 2, "the quick": 3, "quick fox": 4, "the fox": 5,
+		"lazy": 6, "the lazy": 7, "lazy fox": 8}
+	if !reflect.DeepEqual(vectoriser.Vocabulary, expected) {
+		t.Errorf("Expected vocabulary %v but found %v", expected, vectoriser.Vocabulary)
+	}
+	if sizes := vectoriser.NGramSizes(); !reflect.DeepEqual(sizes, []int{1, 1, 1, 2, 2, 2, 1, 2, 2}) {
+		t.Errorf("Expected n-gram sizes for the grown vocabulary but found %v", sizes)
+	}
+
+	mat, err := vectoriser.Transform("lazy fox", "quick")
+	if err != nil {
+		t.Fatalf("Error applying vectoriser caused by %v", err)
+	}
+	if m, n := mat.Dims(); m != 9 || n != 2 {
+		t.Errorf("Expected 9 x 2 matrix sized to the grown vocabulary but found %d x %d", m, n)
+	}
+	for i, count := range []float64{0, 0, 1, 0, 0, 0, 1, 0, 1} {
+		if mat.At(i, 0) != count {
+			t.Errorf("Expected count %f for row %d but found %f", count, i, mat.At(i, 0))
+		}
+	}
+
+	// partially fitting an unfitted zero value vectoriser
+	var empty CountVectoriser
+	empty.Tokeniser = newDefaultTokeniser()
+	empty.PartialFit("fox")
+	if !reflect.DeepEqual(empty.Vocabulary, map[string]int{"fox": 0}) {
+		t.Errorf("Expected vocabulary containing 'fox' but found %v", empty.Vocabulary)
+	}
+}
+
+func TestCountVectoriserDropUbiquitous(t *testing.T) {
+	docs := []string{
+		"apple banana cherry",
+		"apple banana date",
+		"apple cherry",
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.DropUbiquitous = true
+	mat, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+
+	if _, ok := vectoriser.Vocabulary["apple"]; ok || len(vectoriser.Vocabulary) != 3 {
+		t.Errorf("Expected ubiquitous term 'apple' to be dropped from vocabulary but found %v", vectoriser.Vocabulary)
+	}
+	if r, _ := mat.Dims(); r != 3 {
+		t.Errorf("Expected matrix with 3 rows (terms) but found %d", r)
+	}
+
+	// downstream transformers are fitted to the reduced dimensions
+	transformer := NewTfidfTransformer()
+	weighted, err := transformer.FitTransform(mat)
+	if err != nil {
+		t.Fatalf("Failed tfidf transform caused by %v", err)
+	}
+	if r, _ := weighted.Dims(); r != 3 || len(transformer.Weights()) != 3 {
+		t.Errorf("Expected 3 weights and rows but found %d and %d", len(transformer.Weights()), r)
+	}
+}
+
+func TestCountVectoriserMaxFeatures(t *testing.T) {
+	docs := []string{
+		"apple banana cherry apple",
+		"banana apple date",
+		"cherry apple banana elderberry",
+		"fig date",
+	}
+
+	// total frequencies: apple 4, banana 3, cherry 2, date 2, elderberry 1, fig 1
+	var tests = []struct {
+		maxFeatures int
+		vocabulary  []string
+	}{
+		{0, []string{"apple", "banana", "cherry", "date", "elderberry", "fig"}},
+		{2, []string{"apple", "banana"}},
+		// cherry and date tie so cherry is retained alphabetically
+		{3, []string{"apple", "banana", "cherry"}},
+		{5, []string{"apple", "banana", "cherry", "date", "elderberry"}},
+		{10, []string{"apple", "banana", "cherry", "date", "elderberry", "fig"}},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriser(false)
+		vectoriser.MaxFeatures = test.maxFeatures
+		mat, err := vectoriser.FitTransform(docs...)
+		if err != nil {
+			t.Errorf("Error fitting and applying vectoriser caused by %v", err)
+			continue
+		}
+
+		if len(vectoriser.Vocabulary) != len(test.vocabulary) {
+			t.Errorf("Expected vocabulary %v for MaxFeatures %d but found %v",
+				test.vocabulary, test.maxFeatures, vectoriser.Vocabulary)
+			continue
+		}
+		for i, term := range test.vocabulary {
+			if index, ok := vectoriser.Vocabulary[term]; !ok || index != i {
+				t.Errorf("Expected term '%s' at index %d but found %v", term, i, vectoriser.Vocabulary)
+			}
+		}
+
+		if m, _ := mat.Dims(); m != len(test.vocabulary) {
+			t.Errorf("Expected %d rows but found %d", len(test.vocabulary), m)
+		}
+	}
+}
+
+func TestVectoriserMaxTokens(t *testing.T) {
+	long := strings.Repeat("fox ", 5) + "the dog " + strings.Repeat("cat ", 100)
+
+	var tests = []struct {
+		maxTokens int
+		analyser  Analyser
+		counts    map[string]float64
+	}{
+		{maxTokens: 0, counts: map[string]float64{"fox": 5, "dog": 1, "cat": 100}},
+		{maxTokens: 3, counts: map[string]float64{"fox": 3, "dog": 0, "cat": 0}},
+		// stop words are counted towards the limit
+		{maxTokens: 7, counts: map[string]float64{"fox": 5, "dog": 1, "cat": 0}},
+		{maxTokens: 10, counts: map[string]float64{"fox": 5, "dog": 1, "cat": 3}},
+		{maxTokens: 1000, counts: map[string]float64{"fox": 5, "dog": 1, "cat": 100}},
+		{maxTokens: 6, analyser: CharWordBoundaryAnalyser, counts: map[string]float64{" fox ": 5, " the ": 1, " dog ": 0}},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriser(true)
+		vectoriser.MaxTokens = test.maxTokens
+		vectoriser.Analyser = test.analyser
+		if test.analyser != WordAnalyser {
+			vectoriser.MinNGram, vectoriser.MaxNGram = 5, 5
+		}
+		hashing := NewHashingVectoriser(1000, EnglishStopWords()...)
+		hashing.MaxTokens = test.maxTokens
+		hashing.Analyser = vectoriser.Analyser
+		hashing.MinNGram, hashing.MaxNGram = vectoriser.MinNGram, vectoriser.MaxNGram
+
+		// fit to the long document alongside another containing every term so that
+		// truncated terms remain in the vocabulary
+		mat, err := vectoriser.FitTransform(long, "fox the dog cat")
+		if err != nil {
+			t.Errorf("MaxTokens %d: Error fitting and applying vectoriser caused by %v", test.maxTokens, err)
+			continue
+		}
+		hashed, _ := hashing.Transform(long)
+
+		for term, count := range test.counts {
+			if i, ok := vectoriser.Vocabulary[term]; ok && mat.At(i, 0) != count {
+				t.Errorf("MaxTokens %d: Expected count %f for term '%s' but found %f", test.maxTokens, count, term, mat.At(i, 0))
+			} else if !ok && count != 0 {
+				t.Errorf("MaxTokens %d: Expected term '%s' in vocabulary %v", test.maxTokens, term, vectoriser.Vocabulary)
+			}
+			if row, _ := hashing.hash(term); hashed.At(row, 0) != count {
+				t.Errorf("MaxTokens %d: Expected hashed count %f for term '%s' but found %f", test.maxTokens, count, term, hashed.At(row, 0))
+			}
+		}
+	}
+}
+
+func TestCountVectoriserBinary(t *testing.T) {
+	docs := []string{
+		"the dog chased the cat and the cat ran",
+		"the cat sat",
+	}
+
+	vectoriser := NewCountVectoriser(false)
+	counts, _ := vectoriser.FitTransform(docs...)
+
+	vectoriser.Binary = true
+	binary, err := vectoriser.Transform(docs...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+	sparse, _ := vectoriser.TransformSparse(docs...)
+
+	m, n := binary.Dims()
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			v := binary.At(i, j)
+			if v != 0 && v != 1 {
+				t.Errorf("Expected only 0s and 1s but found %f at (%d, %d)", v, i, j)
+			}
+			if (v == 1) != (counts.At(i, j) != 0) {
+				t.Errorf("Expected presence %t at (%d, %d) but found %f", counts.At(i, j) != 0, i, j, v)
+			}
+			if sparse.At(i, j) != v {
+				t.Errorf("Expected sparse value %f at (%d, %d) but found %f", v, i, j, sparse.At(i, j))
+			}
+		}
+	}
+
+	// tf-idf weights fitted to a binary matrix match those fitted to the counts
+	countWeights := NewTfidfTransformer().Fit(counts).(*TfidfTransformer).Weights()
+	binaryWeights := NewTfidfTransformer().Fit(binary).(*TfidfTransformer).Weights()
+	for i := range countWeights {
+		if countWeights[i] != binaryWeights[i] {
+			t.Errorf("Expected idf weight %f for term %d but found %f", countWeights[i], i, binaryWeights[i])
+		}
+	}
+}
+
+func TestVectoriserInterface(t *testing.T) {
+	var tests = []struct {
+		vectoriser Vectoriser
+		rows       int
+	}{
+		{NewCountVectoriser(false), 26},
+		{NewHashingVectoriser(64), 64},
+	}
+
+	for _, test := range tests {
+		// fit on one set of documents and transform another behind the interface
+		mat, err := test.vectoriser.Fit(trainSet...).Transform(testSet...)
+		if err != nil {
+			t.Errorf("Error fitting and applying %T caused by %v", test.vectoriser, err)
+			continue
+		}
+		if r, c := mat.Dims(); r != test.rows || c != len(testSet) {
+			t.Errorf("Expected %T to produce %d x %d matrix but found %d x %d",
+				test.vectoriser, test.rows, len(testSet), r, c)
+		}
+
+		pipeline := NewPipeline(test.vectoriser, NewTfidfTransformer(), NewTruncatedSVD(2))
+		reduced, err := pipeline.FitTransform(trainSet...)
+		if err != nil {
+			t.Errorf("Failed pipeline fit transform with %T caused by %v", test.vectoriser, err)
+			continue
+		}
+		if r, c := reduced.Dims(); r != 2 || c != len(trainSet) {
+			t.Errorf("Expected pipeline with %T to produce 2 x %d matrix but found %d x %d",
+				test.vectoriser, len(trainSet), r, c)
+		}
+	}
+}
+
+func TestCountVectoriserAnalyser(t *testing.T) {
+	docs := []string{"The  cat", "the act"}
+
+	var tests = []struct {
+		analyser   Analyser
+		min, max   int
+		vocabulary []string
+	}{
+		{WordAnalyser, 1, 1, []string{"the", "cat", "act"}},
+		{CharAnalyser, 2, 2, []string{"th", "he", "e ", " c", "ca", "at", " a", "ac", "ct"}},
+		{CharAnalyser, 3, 3, []string{"the", "he ", "e c", " ca", "cat", "e a", " ac", "act"}},
+		{CharWordBoundaryAnalyser, 2, 2, []string{" t", "th", "he", "e ", " c", "ca", "at", "t ", " a", "ac", "ct"}},
+		// padded words shorter than the n-gram size are extracted whole
+		{CharWordBoundaryAnalyser, 6, 6, []string{" the ", " cat ", " act "}},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriser(false)
+		vectoriser.Analyser = test.analyser
+		vectoriser.MinNGram = test.min
+		vectoriser.MaxNGram = test.max
+		vectoriser.Fit(docs...)
+
+		if len(vectoriser.Vocabulary) != len(test.vocabulary) {
+			t.Errorf("Expected vocabulary %q for analyser %d but found %v",
+				test.vocabulary, test.analyser, vectoriser.Vocabulary)
+			continue
+		}
+		for i, term := range test.vocabulary {
+			if index, ok := vectoriser.Vocabulary[term]; !ok || index != i {
+				t.Errorf("Expected term %q at index %d but found %v", term, i, vectoriser.Vocabulary)
+			}
+		}
+	}
+
+	// character n-grams are robust to typos where words are not
+	words := NewCountVectoriser(false)
+	chars := NewCountVectoriser(false)
+	chars.Analyser = CharWordBoundaryAnalyser
+	chars.MinNGram, chars.MaxNGram = 3, 3
+	for _, vectoriser := range []*CountVectoriser{words, chars} {
+		mat, err := vectoriser.FitTransform("vectorisation", "vectorsation")
+		if err != nil {
+			t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+		}
+		similarity := CosineSimilarity(mat.ColView(0), mat.ColView(1))
+		if vectoriser == words && similarity != 0 {
+			t.Errorf("Expected word similarity of 0 but found %f", similarity)
+		}
+		if vectoriser == chars && similarity < 0.5 {
+			t.Errorf("Expected character n-gram similarity of at least 0.5 but found %f", similarity)
+		}
+	}
+
+	hashing := NewHashingVectoriser(64)
+	hashing.Analyser = CharAnalyser
+	hashing.MinNGram, hashing.MaxNGram = 2, 2
+	mat, _ := hashing.Transform(docs...)
+	var total float64
+	for i := 0; i < 64; i++ {
+		total += mat.At(i, 0)
+	}
+	// "the cat" contains 6 character bigrams
+	if total != 6 {
+		t.Errorf("Expected 6 character bigrams but found %f", total)
+	}
+}
+
+func TestCountVectoriserPreprocessor(t *testing.T) {
+	docs := []string{"Paris est la capitale", "paris Paris"}
+
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.Fit(docs...)
+	if _, ok := vectoriser.Vocabulary["Paris"]; ok || len(vectoriser.Vocabulary) != 4 {
+		t.Errorf("Expected documents to be lower cased by default but found %v", vectoriser.Vocabulary)
+	}
+
+	vectoriser = NewCountVectoriser(false)
+	vectoriser.Preprocessor = func(doc string) string { return doc }
+	mat, err := vectoriser.FitTransform(docs...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+	if len(vectoriser.Vocabulary) != 5 {
+		t.Errorf("Expected case to be preserved in vocabulary but found %v", vectoriser.Vocabulary)
+	}
+	var tests = []struct {
+		term   string
+		counts []float64
+	}{
+		{"Paris", []float64{1, 1}},
+		{"paris", []float64{0, 1}},
+	}
+	for _, test := range tests {
+		i, ok := vectoriser.Vocabulary[test.term]
+		if !ok {
+			t.Errorf("Expected term '%s' in vocabulary %v", test.term, vectoriser.Vocabulary)
+			continue
+		}
+		for j, count := range test.counts {
+			if mat.At(i, j) != count {
+				t.Errorf("Expected count %f for term '%s' in document %d but found %f",
+					count, test.term, j, mat.At(i, j))
+			}
+		}
+	}
+
+	// a zero value vectoriser lower cases documents
+	hashing := &HashingVectoriser{Tokeniser: NewWhitespaceTokeniser(), numFeatures: 16}
+	upper, _ := hashing.Transform("PARIS")
+	lower, _ := hashing.Transform("paris")
+	if !mat64.Equal(upper, lower) {
+		t.Errorf("Expected documents to be lower cased with a nil Preprocessor")
+	}
+}
+
+func TestVectoriserTransformSparse(t *testing.T) {
+	count := NewCountVectoriser(true)
+	count.MaxNGram = 2
+	expected, err := count.FitTransform(trainSet...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+	sparse, err := count.FitTransformSparse(trainSet...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying sparse vectoriser caused by %v", err)
+	}
+	if !mat64.Equal(expected, sparse) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(sparse))
+		t.Fail()
+	}
+
+	// unseen terms in test documents are ignored
+	expected, _ = count.Transform(testSet...)
+	sparse, _ = count.TransformSparse(testSet...)
+	if !mat64.Equal(expected, sparse) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(sparse))
+		t.Fail()
+	}
+
+	// the sparse matrix may be consumed directly by the transformers
+	tfidf := NewTfidfTransformer()
+	tfidf.Norm = L2Norm
+	weighted, _ := tfidf.FitTransform(expected)
+	weightedSparse, _ := tfidf.FitTransform(sparse)
+	if !mat64.EqualApprox(weighted, weightedSparse, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(weighted),
+			mat64.Formatted(weightedSparse))
+		t.Fail()
+	}
+
+	if _, err := NewCountVectoriser(false).TransformSparse(testSet...); err == nil {
+		t.Errorf("Expected error transforming with unfitted vectoriser but found none")
+	}
+
+	hashing := NewHashingVectoriser(16)
+	hashing.Signed = true
+	expected, _ = hashing.Transform(trainSet...)
+	sparse, _ = hashing.TransformSparse(trainSet...)
+	if !mat64.Equal(expected, sparse) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(sparse))
+		t.Fail()
+	}
+	// cancelled out collisions should not be stored
+	sparse.DoNonZero(func(i, j int, v float64) {
+		if v == 0 {
+			t.Errorf("Expected only non zero values to be stored but found 0 at (%d, %d)", i, j)
+		}
+	})
+}
+
+func TestVectoriserTransformT(t *testing.T) {
+	count := NewCountVectoriser(true)
+	count.MaxNGram = 2
+	count.Fit(trainSet...)
+	hashing := NewHashingVectoriser(16)
+	hashing.Signed = true
+
+	var tests = []struct {
+		name       string
+		transform  func(docs ...string) (*mat64.Dense, error)
+		transformT func(docs ...string) (*mat64.Dense, error)
+	}{
+		{"CountVectoriser", count.Transform, count.TransformT},
+		{"HashingVectoriser", hashing.Transform, hashing.TransformT},
+	}
+
+	for _, test := range tests {
+		expected, err := test.transform(testSet...)
+		if err != nil {
+			t.Fatalf("%s: Error applying vectoriser caused by %v", test.name, err)
+		}
+		result, err := test.transformT(testSet...)
+		if err != nil {
+			t.Fatalf("%s: Error applying transposed vectoriser caused by %v", test.name, err)
+		}
+
+		if r, c := result.Dims(); r != len(testSet) {
+			t.Errorf("%s: Expected %d rows (documents) but found %d x %d matrix", test.name, len(testSet), r, c)
+		}
+		if !mat64.Equal(expected.T(), result) {
+			t.Logf("%s: Expected matrix: \n%v\n but found: \n%v\n",
+				test.name,
+				mat64.Formatted(expected.T()),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+
+	if _, err := NewCountVectoriser(false).TransformT(testSet...); err == nil {
+		t.Errorf("Expected error transforming with unfitted vectoriser but found none")
+	}
+}
+
+func TestVectoriserTransformOne(t *testing.T) {
+	count := NewCountVectoriser(true)
+	count.Fit(trainSet...)
+	hashing := NewHashingVectoriser(16)
+
+	var tests = []struct {
+		name         string
+		transform    func(docs ...string) (*mat64.Dense, error)
+		transformOne func(doc string) (*mat64.Vector, error)
+	}{
+		{"CountVectoriser", count.Transform, count.TransformOne},
+		{"HashingVectoriser", hashing.Transform, hashing.TransformOne},
+	}
+
+	for _, test := range tests {
+		expected, _ := test.transform(testSet...)
+		m, _ := expected.Dims()
+		for j, doc := range testSet {
+			vec, err := test.transformOne(doc)
+			if err != nil {
+				t.Fatalf("%s: Error applying vectoriser caused by %v", test.name, err)
+			}
+			if vec.Len() != m || !mat64.Equal(expected.ColView(j), vec) {
+				t.Errorf("%s: Expected vector for document %d to equal column %d of batch result", test.name, j, j)
+			}
+		}
+	}
+
+	if _, err := NewCountVectoriser(false).TransformOne(testSet[0]); err == nil {
+		t.Errorf("Expected error transforming with unfitted vectoriser but found none")
+	}
+}
+
+func TestCountVectoriserCoverage(t *testing.T) {
+	vectoriser := NewCountVectoriser(true)
+	vectoriser.Fit("The quick brown fox", "jumped over the lazy dog")
+
+	var tests = []struct {
+		docs    []string
+		inVocab int
+		oov     int
+		rate    float64
+	}{
+		// stop words are excluded
+		{docs: []string{"the quick zebra and the fox yak"}, inVocab: 2, oov: 2, rate: 0.5},
+		{docs: []string{"Quick dog", "lazy fox"}, inVocab: 4, oov: 0, rate: 0},
+		{docs: []string{"zebra", "quick yak gnu"}, inVocab: 1, oov: 3, rate: 0.75},
+		{docs: []string{"", "the and"}, inVocab: 0, oov: 0, rate: 0},
+		{docs: nil, inVocab: 0, oov: 0, rate: 0},
+	}
+
+	for _, test := range tests {
+		inVocab, oov, rate := vectoriser.Coverage(test.docs)
+		if inVocab != test.inVocab || oov != test.oov || math.Abs(rate-test.rate) > 0.000001 {
+			t.Errorf("Expected coverage (%d, %d, %f) for %v but found (%d, %d, %f)",
+				test.inVocab, test.oov, test.rate, test.docs, inVocab, oov, rate)
+		}
+	}
+}
+
+func TestCountVectoriserWeightedTokeniser(t *testing.T) {
+	var tests = []struct {
+		maxNGram  int
+		maxTokens int
+		binary    bool
+		doc       string
+		expected  map[string]float64
+	}{
+		// the leading 2 tokens are weighted by 3
+		{doc: "Fox news: the fox ate the dog", expected: map[string]float64{"fox": 4, "news": 3, "ate": 1, "dog": 1}},
+		// positions are counted before stop word removal
+		{doc: "The fox news", expected: map[string]float64{"fox": 3, "news": 1}},
+		{binary: true, doc: "Fox news: the fox", expected: map[string]float64{"fox": 1, "news": 1}},
+		// n-grams are weighted by the mean weight of their words
+		{maxNGram: 2, doc: "news fox dog", expected: map[string]float64{"news": 3, "fox": 3, "dog": 1, "news fox": 3, "fox dog": 2}},
+		// weights remain aligned with their words once truncated and stop words removed
+		{maxTokens: 4, doc: "the news fox dog ate", expected: map[string]float64{"news": 3, "fox": 1, "dog": 1}},
+	}
+
+	for _, test := range tests {
+		vectoriser := NewCountVectoriser(true)
+		vectoriser.Tokeniser = NewPositionalTokeniser(nil, 2, 3)
+		vectoriser.MaxNGram = test.maxNGram
+		vectoriser.MaxTokens = test.maxTokens
+		vectoriser.Binary = test.binary
+		vectoriser.Fit("news fox dog ate", "news fox dog", "fox ate news")
+
+		vec, err := vectoriser.TransformOne(test.doc)
+		if err != nil {
+			t.Fatalf("Failed to transform document caused by %v", err)
+		}
+		for term, i := range vectoriser.Vocabulary {
+			if expected := test.expected[term]; vec.At(i, 0) != expected {
+				t.Errorf("Expected count %f for term '%s' of '%s' but found %f", expected, term, test.doc, vec.At(i, 0))
+			}
+		}
+	}
+}
+
+func TestVectoriseQuery(t *testing.T) {
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.Fit("the quick brown fox", "the lazy dog")
+
+	vec := VectoriseQuery(map[string]int{"fox": 2, "dog": 1, "zebra": 3}, vectoriser.Vocabulary)
+	if vec == nil || vec.Len() != len(vectoriser.Vocabulary) {
+		t.Fatalf("Expected vector of length %d but found %v", len(vectoriser.Vocabulary), vec)
+	}
+
+	// should be equivalent to vectorising the query text, with "zebra" dropped
+	expected, _ := vectoriser.TransformOne("fox fox dog zebra zebra zebra")
+	if !mat64.Equal(expected, vec) {
+		t.Errorf("Expected vector %v but found %v", expected.RawVector().Data, vec.RawVector().Data)
+	}
+	if sum := mat64.Sum(vec); sum != 3 {
+		t.Errorf("Expected out of vocabulary word to be dropped leaving a total of 3 but found %f", sum)
+	}
+
+	if vec := VectoriseQuery(map[string]int{"fox": 1}, nil); vec != nil {
+		t.Errorf("Expected nil vector for empty vocabulary but found %v", vec)
+	}
+}
+
+func TestHashingVectoriserTransformReader(t *testing.T) {
+	vectoriser := NewHashingVectoriser(32)
+
+	var tests = []struct {
+		input string
+		docs  []string
+	}{
+		{"the quick fox\nthe lazy dog\n", []string{"the quick fox", "the lazy dog"}},
+		// no trailing delimiter
+		{"the quick fox\nthe lazy dog", []string{"the quick fox", "the lazy dog"}},
+		// empty lines produce empty documents
+		{"the quick fox\n\nthe lazy dog\n", []string{"the quick fox", "", "the lazy dog"}},
+		{"\n", []string{""}},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		result, err := vectoriser.TransformReader(strings.NewReader(test.input), '\n')
+		if err != nil {
+			t.Errorf("Error transforming documents from reader caused by %v", err)
+			continue
+		}
+
+		if r, c := result.Dims(); r != 32 || c != len(test.docs) {
+			t.Errorf("Expected 32 x %d matrix for input %q but found %d x %d", len(test.docs), test.input, r, c)
+			continue
+		}
+		if len(test.docs) == 0 {
+			continue
+		}
+
+		expected, _ := vectoriser.Transform(test.docs...)
+		if !mat64.Equal(expected, result) {
+			t.Logf("Expected matrix for input %q: \n%v\n but found: \n%v\n",
+				test.input,
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+
+	// custom delimiter
+	result, _ := vectoriser.TransformReader(strings.NewReader("the quick fox|the lazy dog"), '|')
+	if _, c := result.Dims(); c != 2 {
+		t.Errorf("Expected 2 documents delimited by '|' but found %d", c)
+	}
+}
+
+// randomCorpus generates a corpus of n documents, each of the specified number of words,
+// drawn from a vocabulary of the specified size according to Zipf's law (as is typical of
+// natural language).
+func randomCorpus(n, words int, vocabulary uint64) []string {
+	rnd := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rnd, 1.1, 1, vocabulary-1)
+
+	docs := make([]string, n)
+	doc := make([]string, words)
+	for d := range docs {
+		for w := range doc {
+			doc[w] = fmt.Sprintf("w%d", zipf.Uint64())
+		}
+		docs[d] = strings.Join(doc, " ")
+	}
+	return docs
+}
+
+// The 20 newsgroups training corpus contains approximately 11,000 documents with a
+// vocabulary exceeding 100,000 terms so, as a dense term document matrix of that size would
+// require around 9GB of memory, the dense benchmark uses a reduced number of documents.
+func benchmarkCountVectoriserTransform(n int, sparse bool, b *testing.B) {
+	docs := randomCorpus(n, 200, 100000)
+	vectoriser := NewCountVectoriser(false)
+	vectoriser.Fit(docs...)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if sparse {
+			vectoriser.TransformSparse(docs...)
+		} else {
+			vectoriser.Transform(docs...)
+		}
+	}
+}
+
+func BenchmarkCountVectoriserTransformDense1000(b *testing.B) {
+	benchmarkCountVectoriserTransform(1000, false, b)
+}
+func BenchmarkCountVectoriserTransformSparse1000(b *testing.B) {
+	benchmarkCountVectoriserTransform(1000, true, b)
+}
+func BenchmarkCountVectoriserTransformSparse11000(b *testing.B) {
+	benchmarkCountVectoriserTransform(11000, true, b)
+}
@@ -1,7 +1,13 @@
 package nlp
 
 import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
 	"math"
+	"runtime"
+	"sort"
+	"sync"
 
 	"github.com/gonum/matrix/mat64"
 )
@@ -12,6 +18,18 @@ type Transformer interface {
 	FitTransform(mat mat64.Matrix) (*mat64.Dense, error)
 }
 
+// Cloner is implemented by transformers able to create independent copies of themselves.
+// The copy shares no mutable state with the original (other than any configured rand.Source)
+// so that, for example, copies of a fitted transformer may be further fitted with
+// PartialFit() on separate goroutines without affecting the original or each other.
+type Cloner interface {
+	Clone() Transformer
+}
+
+// WeightFunc calculates the weight of a term from its document frequency, df (the number of
+// documents in which the term occurs), and n, the total number of documents in the corpus.
+type WeightFunc func(df, n float64) float64
+
 // TfidfTransformer takes a raw term document matrix and weights each raw term frequency
 // value depending upon how commonly it occurs across all documents within the corpus.
 // For example a very commonly occuring word like `the` is likely to occur in all documents
@@ -19,56 +37,1791 @@ type Transformer interface {
 // More precisely, TfidfTransformer applies a tf-idf algorithm to the matrix where each
 // term frequency is multiplied by the inverse document frequency.  Inverse document
 // frequency is calculated as log(n/df) where df is the number of documents in which the
-// term occurs and n is the total number of documents within the corpus.  We add 1 to both n
-// and df before division to prevent division by zero.
+// term occurs and n is the total number of documents within the corpus.  By default
+// (see Smooth) we add 1 to both n and df before division to prevent division by zero.
+//
+// Once fitted, a TfidfTransformer is safe for concurrent use by multiple goroutines calling
+// Transform(), TransformSparse(), TransformInto() (with distinct destination matrices) and
+// InverseTransform() as these methods only read the fitted state and write to output
+// matrices allocated per call (or supplied by the caller).  Fit(), PartialFit(), Merge()
+// and UnmarshalBinary(), and changes to the configuration fields, modify the transformer and
+// so must not be called concurrently with any other method.
 type TfidfTransformer struct {
 	weights []float64
+	docs    int
+
+	// df holds the (raw) document frequency of each term and n the total number of
+	// documents accumulated across calls to Fit() and PartialFit() from which the weights
+	// are derived.
+	df []float64
+	n  float64
+
+	// frozen holds the weights of the terms frozen with FreezeTerms(), keyed by term row,
+	// which are preserved when the weights are recalculated.
+	frozen map[int]float64
+
+	// fitErr records why the last call to Fit() (or FitFromDocFreq()) left the transformer
+	// unfitted so that it may be returned by subsequent calls to Transform().
+	fitErr error
+
+	// Smooth adds 1 to both n and df before division when calculating the inverse document
+	// frequency i.e. log((1+n)/(1+df)) as though an extra document containing every term had
+	// been seen.  If false, the raw form log(n/df) is used instead with terms that never occur
+	// in the training data (df is 0) receiving a weight of 0.  Smooth is true by default.
+	Smooth bool
+
+	// OffsetIdf adds 1 to each calculated inverse document frequency e.g. log(n/df)+1 so that
+	// terms occuring in every document are not entirely ignored.  This matches the idf formula
+	// used by scikit-learn.
+	OffsetIdf bool
+
+	// LogBase is the base of the logarithm used to calculate the inverse document frequency
+	// e.g. 2 or 10 for compatibility with other toolkits.  The default, 0, uses the natural
+	// logarithm (base e).  Changing the base scales the magnitude of all the weights.  Any
+	// other base must be finite, greater than 0 and not equal to 1 otherwise fitting leaves
+	// the transformer unfitted and Transform() returns an error wrapping ErrInvalidArgument.
+	LogBase float64
+
+	// MaxIDF, if greater than 0, caps the inverse document frequency calculated for each term
+	// during Fit() so that any idf above MaxIDF is clamped to MaxIDF.  Extremely rare terms
+	// (e.g. typos occuring in a single document) otherwise receive very large weights and may
+	// dominate similarity scores.  The cap is applied to the final idf (following LogBase
+	// and OffsetIdf) but not to weights calculated with WeightFunc.  The default, 0, applies
+	// no cap.
+	MaxIDF float64
+
+	// CorpusSize, if greater than 0, is used in place of the number of training documents
+	// as n when calculating the weights e.g. log(n/df) so that a transformer fitted to a
+	// sample of a larger corpus produces weights calibrated to the size of the full corpus.
+	// The document frequencies are still counted from the training data and are not scaled,
+	// so CorpusSize assumes that the training data holds (almost) all of the documents of
+	// the full corpus in which the terms occur (e.g. those documents retrieved by a search
+	// for the terms) with the remaining documents containing none of them.  For a uniform
+	// random sample the document frequencies should instead be scaled, or the sample
+	// fitted directly.  The default, 0, uses the number of training documents.
+	CorpusSize int
+
+	// Epsilon, if greater than 0, is the magnitude below which values of the training data
+	// matrices are treated as zero when counting the document frequency of each term during
+	// Fit(), PartialFit() etc. so that near zero values (e.g. 1e-18 left by floating point
+	// error in upstream processing) do not count as occurances of the term.  Transform() is
+	// unaffected.  The default, 0, counts every value not exactly equal to 0.
+	Epsilon float64
+
+	// WeightFunc, if set, replaces the inverse document frequency calculation used to derive
+	// the weight of each term from its document frequency, allowing alternative term
+	// weighting schemes to be used (e.g. entropy or GF-IDF weighting) while reusing the
+	// document frequency counting of Fit() and PartialFit().  Smooth, OffsetIdf and LogBase
+	// are ignored when WeightFunc is set.  The default, nil, uses the inverse document
+	// frequency.  WeightFunc is not encoded by MarshalBinary() so must be reassigned
+	// following UnmarshalBinary() if the restored transformer is to be fitted further.
+	WeightFunc WeightFunc
+
+	// Norm is the normalisation applied to each document (column) of the matrix following
+	// weighting in Transform().  Normalising document vectors to unit length removes the bias
+	// towards longer documents (which naturally have more words and so higher word counts).
+	// The default, NoNorm, applies no normalisation.
+	Norm NormType
+
+	// NonFinite specifies how NaN and infinite values within matrices supplied to Transform()
+	// are handled e.g. as produced by a faulty upstream stage.  RejectNonFinite returns an
+	// error identifying the first offending element and ZeroNonFinite treats such values as
+	// 0.  The default, PropagateNonFinite, applies no special handling.
+	NonFinite NonFinitePolicy
+
+	// SublinearTF replaces each raw term frequency, tf, with 1 + log(tf) prior to weighting
+	// in Transform().  This dampens the effect of terms occuring many times within a single
+	// document.  Zero term frequencies remain zero.
+	SublinearTF bool
+
+	// AllowExtraTerms, if true, allows Transform() to accept matrices with more rows than the
+	// number of terms the transformer was fitted to (e.g. where the vocabulary has grown
+	// since fitting) rather than returning an error.  The additional rows are treated as
+	// terms unseen during fitting and weighted by DefaultWeight.  Matrices with fewer rows
+	// than fitted are still rejected.
+	AllowExtraTerms bool
+
+	// DefaultWeight is the weight applied to the additional rows of matrices transformed with
+	// AllowExtraTerms.  Setting DefaultWeight to the largest fitted weight (the maximum of
+	// Weights()) treats unseen terms as maximally rare whereas the default, 0, ignores them.
+	DefaultWeight float64
 }
 
 // NewTfidfTransformer constructs a new TfidfTransformer.
 func NewTfidfTransformer() *TfidfTransformer {
-	return &TfidfTransformer{}
+	return &TfidfTransformer{Smooth: true}
+}
+
+// NewScikitCompatibleTfidf constructs a new TfidfTransformer configured to match the
+// default behaviour of scikit-learn's TfidfTransformer (and so TfidfVectorizer) i.e. a
+// smoothed idf with 1 added, ln((1+n)/(1+df))+1, raw (not sublinear) term frequencies and L2
+// normalisation of each document following weighting.  Used with the same term counts,
+// the output matches that of scikit-learn within floating point tolerance (with documents
+// as columns rather than rows).  Note that the tokenisation of TfidfVectorizer differs from
+// CountVectoriser (e.g. it ignores single character tokens) so the term counts supplied
+// must be equivalent for the outputs to match.
+func NewScikitCompatibleTfidf() *TfidfTransformer {
+	return &TfidfTransformer{
+		Smooth:    true,
+		OffsetIdf: true,
+		Norm:      L2Norm,
+	}
+}
+
+// NewTfidfTransformerWithWeights constructs a new TfidfTransformer using the supplied,
+// precomputed, inverse document frequency weights (e.g. calculated from a large background
+// corpus by another tool) rather than fitting them to training data.  The transformer may
+// be used to Transform() matrices immediately without calling Fit().  Each element of
+// weights is the weight of the term represented by the corresponding row of the term
+// document matrices to be transformed i.e. weights[i] is applied to row i and so the
+// weights must follow the same term ordering as the vocabulary used to construct the
+// matrices.  The weights are copied.  An error is returned if weights is empty.  As the
+// document frequencies from which the weights were derived are unknown, subsequent calls
+// to Fit() or PartialFit() will replace the weights.
+func NewTfidfTransformerWithWeights(weights []float64) (*TfidfTransformer, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("%w: no weights supplied for TfidfTransformer", ErrInvalidArgument)
+	}
+	t := NewTfidfTransformer()
+	t.weights = make([]float64, len(weights))
+	copy(t.weights, weights)
+	return t, nil
 }
 
 // Fit takes a training term document matrix, counts term occurances across all documents
 // and constructs an inverse document frequency transform to apply to matrices in subsequent
-// calls to Transform().
+// calls to Transform().  Any statistics accumulated by previous calls to Fit() or
+// PartialFit() are discarded.  If the matrix contains no terms (rows) or no documents
+// (columns) the transformer is left unfitted and subsequent calls to Transform() (and so
+// FitTransform()) return an error wrapping ErrEmptyVocabulary or ErrEmptyCorpus
+// respectively rather than ErrNotFitted.
 func (t *TfidfTransformer) Fit(mat mat64.Matrix) Transformer {
-	m, n := mat.Dims()
+	t.df = nil
+	t.n = 0
+	t.docs = 0
 
-	t.weights = make([]float64, m)
+	if err := checkCorpus(mat.Dims()); err != nil {
+		t.weights = nil
+		t.fitErr = err
+		return t
+	}
+	return t.PartialFit(mat)
+}
 
-	for i := 0; i < m; i++ {
-		df := 0
-		for j := 0; j < n; j++ {
-			if mat.At(i, j) != 0 {
-				df++
+// FitFromDocFreq fits the transformer directly from precomputed document frequencies, df,
+// (the number of documents in which each term occurs, indexed by term row) and the total
+// number of documents in the corpus, n, rather than from a term document matrix.  The
+// weights are calculated exactly as for Fit() so the result is identical to calling Fit()
+// with a matrix of n documents producing the same document frequencies.  Any statistics
+// accumulated previously are discarded and subsequent calls to PartialFit(), Update() or
+// Merge() accumulate further statistics as though the transformer had been fitted using
+// Fit().  The supplied slice is copied.  If n is not positive or any document frequency is
+// negative or greater than n, the transformer is left unfitted and subsequent calls to
+// Transform() return an error wrapping ErrInvalidArgument.
+func (t *TfidfTransformer) FitFromDocFreq(df []int, n int) Transformer {
+	t.df = nil
+	t.n = 0
+	t.docs = 0
+	t.weights = nil
+
+	if n <= 0 {
+		t.fitErr = fmt.Errorf("%w: number of documents (%d) must be positive", ErrInvalidArgument, n)
+		return t
+	}
+	for i, v := range df {
+		if v < 0 || v > n {
+			t.fitErr = fmt.Errorf("%w: document frequency of term %d (%d) must be between 0 and the number of documents (%d)", ErrInvalidArgument, i, v, n)
+			return t
+		}
+	}
+
+	t.df = make([]float64, len(df))
+	for i, v := range df {
+		t.df[i] = float64(v)
+	}
+	t.n = float64(n)
+	t.docs = n
+	t.updateWeights()
+
+	return t
+}
+
+// FitWeighted is equivalent to Fit() except that each document (column) of the training
+// matrix contributes its weight, docWeights[j], to the document frequencies of its terms and
+// to the number of documents rather than 1.  A document with a weight of 2 is therefore
+// treated as though it occurred twice within the corpus, allowing deduplicated or importance
+// sampled corpora to be fitted as though the original documents were present, while a
+// weight between 0 and 1 reduces the influence of less reliable documents.  Subsequent calls
+// to PartialFit() accumulate further statistics with each document contributing 1.  If the
+// length of docWeights differs from the number of columns in the matrix, any weight is
+// negative or the matrix is empty, the transformer is left unfitted and subsequent calls to
+// Transform() return an error wrapping ErrDimensionMismatch, ErrInvalidArgument,
+// ErrEmptyVocabulary or ErrEmptyCorpus respectively.
+func (t *TfidfTransformer) FitWeighted(mat mat64.Matrix, docWeights []float64) Transformer {
+	t.df = nil
+	t.n = 0
+	t.docs = 0
+	t.weights = nil
+
+	m, n := mat.Dims()
+	if err := checkCorpus(m, n); err != nil {
+		t.fitErr = err
+		return t
+	}
+	if len(docWeights) != n {
+		t.fitErr = fmt.Errorf("%w: %d document weights supplied for %d documents", ErrDimensionMismatch, len(docWeights), n)
+		return t
+	}
+	for j, w := range docWeights {
+		if w < 0 {
+			t.fitErr = fmt.Errorf("%w: weight of document %d (%f) must not be negative", ErrInvalidArgument, j, w)
+			return t
+		}
+	}
+
+	t.df = make([]float64, m)
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			if nonZero(v, t.Epsilon) {
+				t.df[i] += docWeights[j]
+			}
+		})
+	} else {
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				if nonZero(mat.At(i, j), t.Epsilon) {
+					t.df[i] += docWeights[j]
+				}
 			}
 		}
-		idf := math.Log(float64(1+n) / float64(1+df))
-		t.weights[i] = idf
 	}
+	for _, w := range docWeights {
+		t.n += w
+	}
+	t.docs = n
+	t.updateWeights()
+
+	return t
+}
+
+// PartialFit incrementally fits the transformer to the supplied batch of training data.
+// Term occurance counts and the number of documents are accumulated across successive calls
+// to PartialFit() with the inverse document frequency weights recalculated following each
+// call.  This allows the transformer to be fitted to corpora too large to fit into memory at
+// once by supplying the documents in batches.  Calling PartialFit() on an unfitted
+// transformer is equivalent to calling Fit().  All batches should share the same term
+// ordering (rows), if a batch contains more rows than previous batches then the additional
+// rows are treated as new terms that did not occur in any of the previous batches.
+func (t *TfidfTransformer) PartialFit(mat mat64.Matrix) Transformer {
+	m, n := mat.Dims()
+
+	if t.df == nil {
+		// discard any precomputed weights not derived from document frequencies
+		t.weights = nil
+	}
+	if m > len(t.df) {
+		df := make([]float64, m)
+		copy(df, t.df)
+		t.df = df
+	}
+
+	documentFrequencies(mat, t.df, t.Epsilon)
+
+	t.n += float64(n)
+	t.docs += n
+	t.updateWeights()
 
 	return t
 }
 
+// Update incrementally updates the transformer with the supplied batch of training data,
+// blending the document frequencies of the batch with those accumulated previously using an
+// exponential decay factor i.e. df = (1-decay)*df + decay*df(batch), and similarly for the
+// number of documents, before recalculating the weights.  This allows long running systems
+// to adapt to changes in the corpus over time (e.g. topic drift) without refitting, with the
+// influence of older batches decaying away.  A decay of 1 ignores the history entirely
+// (equivalent to fitting to the batch alone) and a decay of 0 ignores the new batch.
+// Calling Update() on an unfitted transformer is equivalent to calling Fit().  As for
+// PartialFit(), additional rows are treated as new terms.  An error is returned if decay is
+// outside the range 0 to 1 or LogBase is invalid.
+func (t *TfidfTransformer) Update(mat mat64.Matrix, decay float64) error {
+	if decay < 0 || decay > 1 {
+		return fmt.Errorf("%w: decay must be between 0 and 1 but was %f", ErrInvalidArgument, decay)
+	}
+	if t.df == nil {
+		t.PartialFit(mat)
+		return nil
+	}
+
+	m, n := mat.Dims()
+	df := make([]float64, max(m, len(t.df)))
+	documentFrequencies(mat, df, t.Epsilon)
+
+	for i := range df {
+		var prev float64
+		if i < len(t.df) {
+			prev = t.df[i]
+		}
+		df[i] = (1-decay)*prev + decay*df[i]
+	}
+
+	t.df = df
+	t.n = (1-decay)*t.n + decay*float64(n)
+	t.docs += n
+	t.updateWeights()
+
+	return t.fitErr
+}
+
+// documentFrequencies adds the number of columns (documents) in which each row (term) of
+// the matrix is non-zero (see nonZero()) to the corresponding element of df.  If the matrix
+// is a *SparseMatrix, only its non zero elements are visited.
+func documentFrequencies(mat mat64.Matrix, df []float64, epsilon float64) {
+	if s, ok := mat.(*SparseMatrix); ok {
+		s.DoNonZero(func(i, j int, v float64) {
+			if nonZero(v, epsilon) {
+				df[i]++
+			}
+		})
+		return
+	}
+	countDocumentFrequencies(mat, df, epsilon, runtime.NumCPU())
+}
+
+// nonZero reports whether v should be counted as an occurance of a term when counting
+// document frequencies i.e. whether v is non zero or, if epsilon is greater than 0, whether
+// the magnitude of v is at least epsilon.
+func nonZero(v, epsilon float64) bool {
+	if epsilon > 0 {
+		return math.Abs(v) >= epsilon
+	}
+	return v != 0
+}
+
+// Merge combines the corpus statistics (document frequencies and number of documents)
+// accumulated by other into this transformer and recalculates the weights so that the
+// result is equivalent to having fitted this transformer on the training data supplied to
+// both.  This allows transformers fitted separately on shards of a corpus to be combined
+// without revisiting the documents.  Both transformers must have been fitted (rather than
+// constructed from precomputed weights) to matrices with the same term ordering (rows).  An
+// error is returned if either transformer has not been fitted, the number of terms differs
+// or LogBase is invalid.  The configuration (e.g. Smooth) of this transformer is used to
+// recalculate the weights.
+func (t *TfidfTransformer) Merge(other *TfidfTransformer) error {
+	if t.df == nil || other.df == nil {
+		return fmt.Errorf("%w: TfidfTransformer, both transformers must be fitted to merge", ErrNotFitted)
+	}
+	if len(t.df) != len(other.df) {
+		return fmt.Errorf("%w: cannot merge TfidfTransformer fitted on %d terms with one fitted on %d terms", ErrDimensionMismatch, len(t.df), len(other.df))
+	}
+
+	for i, df := range other.df {
+		t.df[i] += df
+	}
+	t.n += other.n
+	t.docs += other.docs
+	t.updateWeights()
+
+	return t.fitErr
+}
+
+// Subset returns a new transformer whose weights (and document frequencies) are restricted
+// to the specified term rows, in the order specified, so that it may be used to transform
+// term document matrices built with the reduced vocabulary e.g. to serve a model with only
+// a whitelisted subset of the terms it was fitted on.  Row i of matrices transformed by
+// the returned transformer corresponds to row indices[i] of the matrices this transformer
+// was fitted on.  The configuration (e.g. Norm) of this transformer and any frozen terms
+// (see FreezeTerms()) are copied.  As normalisation is applied to the reduced vectors, the
+// result is equivalent to selecting the rows from the output of Transform() only when Norm
+// is NoNorm.  An error is returned if the transformer has not been fitted or if indices
+// contains duplicates or indices that are out of range.
+func (t *TfidfTransformer) Subset(indices []int) (*TfidfTransformer, error) {
+	if t.weights == nil {
+		return nil, fmt.Errorf("%w: TfidfTransformer", ErrNotFitted)
+	}
+
+	seen := make(map[int]struct{}, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= len(t.weights) {
+			return nil, fmt.Errorf("%w: index %d out of range for transformer fitted on %d terms", ErrInvalidArgument, i, len(t.weights))
+		}
+		if _, exists := seen[i]; exists {
+			return nil, fmt.Errorf("%w: duplicate index %d", ErrInvalidArgument, i)
+		}
+		seen[i] = struct{}{}
+	}
+
+	subset := *t
+	subset.weights = make([]float64, len(indices))
+	if t.df != nil {
+		subset.df = make([]float64, len(indices))
+	}
+	subset.frozen = nil
+	for s, i := range indices {
+		subset.weights[s] = t.weights[i]
+		if t.df != nil {
+			subset.df[s] = t.df[i]
+		}
+		if w, ok := t.frozen[i]; ok {
+			if subset.frozen == nil {
+				subset.frozen = make(map[int]float64)
+			}
+			subset.frozen[s] = w
+		}
+	}
+	return &subset, nil
+}
+
+// Reindex returns a new transformer whose weights (and document frequencies) are permuted
+// to a different term ordering so that a transformer fitted to matrices built with one
+// vocabulary may be applied to matrices built with another vocabulary of the same terms
+// in a different order e.g. by a separate vectorisation service.  mapping specifies, for
+// each term row of the matrices this transformer was fitted on, the row of the same term
+// in the new ordering i.e. row i is moved to row mapping[i].  The result is identical to
+// fitting a transformer to the matrices with their rows reordered.  The configuration
+// (e.g. Norm) of this transformer is copied.  To reorder and also drop terms, use Subset().
+// An error is returned if the transformer has not been fitted, if the length of mapping
+// differs from the number of terms the transformer was fitted on or if mapping is not a
+// permutation (contains duplicates or rows that are out of range).
+func (t *TfidfTransformer) Reindex(mapping []int) (*TfidfTransformer, error) {
+	if t.weights == nil {
+		return nil, fmt.Errorf("%w: TfidfTransformer", ErrNotFitted)
+	}
+	if len(mapping) != len(t.weights) {
+		return nil, fmt.Errorf("%w: mapping has %d rows but transformer was fitted on %d terms", ErrDimensionMismatch, len(mapping), len(t.weights))
+	}
+
+	indices := make([]int, len(mapping))
+	for i := range indices {
+		indices[i] = -1
+	}
+	for i, r := range mapping {
+		if r < 0 || r >= len(mapping) {
+			return nil, fmt.Errorf("%w: row %d out of range for transformer fitted on %d terms", ErrInvalidArgument, r, len(mapping))
+		}
+		if indices[r] != -1 {
+			return nil, fmt.Errorf("%w: duplicate row %d", ErrInvalidArgument, r)
+		}
+		indices[r] = i
+	}
+	return t.Subset(indices)
+}
+
+// countDocumentFrequencies adds the number of columns (documents) in which each row (term)
+// of the matrix is non-zero (see nonZero()) to the corresponding element of df.  The rows
+// are partitioned into contiguous ranges, counted concurrently by up to the specified
+// number of workers.
+// As each worker writes only to its own region of df no locking is required.  The matrix
+// must be safe for concurrent reads via At().
+func countDocumentFrequencies(mat mat64.Matrix, df []float64, epsilon float64, workers int) {
+	m, n := mat.Dims()
+	if workers > m {
+		workers = m
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	size := (m + workers - 1) / workers
+	for start := 0; start < m; start += size {
+		end := min(start+size, m)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				for j := 0; j < n; j++ {
+					if nonZero(mat.At(i, j), epsilon) {
+						df[i]++
+					}
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// updateWeights recalculates the weights from the accumulated document frequencies using
+// WeightFunc or, if not set, the inverse document frequency.
+func (t *TfidfTransformer) updateWeights() {
+	t.fitErr = nil
+	weight := t.idf
+	if t.WeightFunc != nil {
+		weight = t.WeightFunc
+	} else if err := checkLogBase(t.LogBase); err != nil {
+		t.weights = nil
+		t.fitErr = err
+		return
+	}
+	t.weights = make([]float64, len(t.df))
+	n := t.n
+	if t.CorpusSize > 0 {
+		n = float64(t.CorpusSize)
+	}
+	for i, df := range t.df {
+		t.weights[i] = weight(df, n)
+	}
+	for i, w := range t.frozen {
+		if i < len(t.weights) {
+			t.weights[i] = w
+		}
+	}
+}
+
+// Weights returns a copy of the inverse document frequency weights calculated during Fit().
+// Each element corresponds to the term represented by the same row of the fitted term
+// document matrix.  Weights returns nil if the transformer has not been fitted.
+func (t *TfidfTransformer) Weights() []float64 {
+	if t.weights == nil {
+		return nil
+	}
+	weights := make([]float64, len(t.weights))
+	copy(weights, t.weights)
+	return weights
+}
+
+// Documents returns the number of documents (columns) within the training data matrices
+// supplied to Fit() and PartialFit().
+func (t *TfidfTransformer) Documents() int {
+	return t.docs
+}
+
+// ConstantTerms returns the indices (rows) of the terms occuring in every document of the
+// training data (where the document frequency equals the number of documents) in ascending
+// order.  Such terms contribute nothing to distinguishing documents and so are candidates
+// for pruning e.g. by refitting the vectoriser with DropUbiquitous or by using Subset() to
+// exclude them.  ConstantTerms is purely informational and does not alter the weights.  An
+// empty slice is returned if no terms are constant and nil if the transformer has not been
+// fitted from document frequencies.
+func (t *TfidfTransformer) ConstantTerms() []int {
+	if t.df == nil {
+		return nil
+	}
+	constant := []int{}
+	for i, df := range t.df {
+		if t.n > 0 && df >= t.n {
+			constant = append(constant, i)
+		}
+	}
+	return constant
+}
+
+// FreezeTerms freezes the current weights of the terms at the specified indices (rows) so
+// that subsequent calls to Fit(), PartialFit(), Update() etc. preserve them rather than
+// recalculating them from the new training data, while the weights of all other terms are
+// updated as normal.  This allows hand tuned weights for a core set of terms (e.g. set via
+// NewTfidfTransformerWithWeights()) to be retained across refits.  Document frequencies
+// continue to be accumulated for frozen terms so that they may later be thawed with
+// UnfreezeTerms().  Calling FreezeTerms for a term that is already frozen re-freezes it
+// at its current (frozen) weight.  An error is returned if the transformer has not been
+// fitted or any index is out of range in which case no terms are frozen.
+func (t *TfidfTransformer) FreezeTerms(indices []int) error {
+	if t.weights == nil {
+		return fmt.Errorf("%w: TfidfTransformer", ErrNotFitted)
+	}
+	for _, i := range indices {
+		if i < 0 || i >= len(t.weights) {
+			return fmt.Errorf("%w: index %d out of range for transformer fitted on %d terms", ErrInvalidArgument, i, len(t.weights))
+		}
+	}
+
+	if t.frozen == nil {
+		t.frozen = make(map[int]float64, len(indices))
+	}
+	for _, i := range indices {
+		t.frozen[i] = t.weights[i]
+	}
+	return nil
+}
+
+// UnfreezeTerms thaws all terms frozen with FreezeTerms() so that their weights are
+// recalculated, along with those of all other terms, by the next call to Fit() or
+// PartialFit().  The current weights are left unchanged.
+func (t *TfidfTransformer) UnfreezeTerms() {
+	t.frozen = nil
+}
+
+// checkLogBase returns an error wrapping ErrInvalidArgument if base is not a valid LogBase
+// i.e. neither 0 (the natural logarithm) nor a finite value greater than 0 other than 1.
+func checkLogBase(base float64) error {
+	if base == 0 || (base > 0 && base != 1 && !math.IsInf(base, 1)) {
+		return nil
+	}
+	return fmt.Errorf("%w: logarithm base (%v) must be greater than 0 and not equal to 1", ErrInvalidArgument, base)
+}
+
+// idf calculates the inverse document frequency weight for a term occuring in df of the
+// n documents within the corpus according to the formula configured on the transformer.
+func (t *TfidfTransformer) idf(df, n float64) float64 {
+	var idf float64
+	if t.Smooth {
+		idf = math.Log((1 + n) / (1 + df))
+	} else if df != 0 {
+		idf = math.Log(n / df)
+	}
+
+	if t.LogBase != 0 && t.LogBase != math.E {
+		idf /= math.Log(t.LogBase)
+	}
+
+	if t.OffsetIdf {
+		idf++
+	}
+
+	if t.MaxIDF > 0 && idf > t.MaxIDF {
+		idf = t.MaxIDF
+	}
+	return idf
+}
+
+// Transform applies the inverse document frequency (IDF) transform to the supplied term
+// document matrix, weighting each term by the IDF weights calculated during Fit().  If Norm
+// is set, each document (column) in the resulting matrix is then normalised accordingly.
+// An error is returned if the transformer has not been fitted or if the number of rows
+// (terms) in the matrix differs from the number of terms the transformer was fitted to.
+// If the supplied matrix is a *SparseMatrix, only its non zero elements are visited.
 func (t *TfidfTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
 	m, n := mat.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return nil, err
+	}
 	product := mat64.NewDense(m, n, nil)
 
-	product.Apply(func(i, j int, v float64) float64 {
-		return (v * t.weights[i])
-	}, mat)
+	if err := t.TransformInto(product, mat); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// TransformInto is equivalent to Transform() but writes the output into the supplied
+// destination matrix, dst, rather than allocating a new matrix.  This allows the same
+// destination matrix to be reused across repeated calls e.g. when transforming batches of
+// documents in a loop.  dst must have the same dimensions as src and may be src itself to
+// transform the matrix in place.  Any existing values in dst are overwritten.
+func (t *TfidfTransformer) TransformInto(dst *mat64.Dense, src mat64.Matrix) error {
+	m, n := src.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return err
+	}
+	if r, c := dst.Dims(); r != m || c != n {
+		return fmt.Errorf("%w: destination matrix is %d x %d but source matrix is %d x %d", ErrDimensionMismatch, r, c, m, n)
+	}
+	if t.NonFinite == RejectNonFinite {
+		if err := checkFinite(src); err != nil {
+			return err
+		}
+	}
+
+	if s, ok := src.(*SparseMatrix); ok {
+		for i := 0; i < m; i++ {
+			row := dst.RawRowView(i)
+			for j := range row {
+				row[j] = 0
+			}
+		}
+		s.DoNonZero(func(i, j int, v float64) {
+			dst.Set(i, j, t.weight(i, v))
+		})
+	} else {
+		dst.Apply(func(i, j int, v float64) float64 {
+			return t.weight(i, v)
+		}, src)
+	}
+
+	normaliseColumns(dst, t.Norm)
+
+	return nil
+}
+
+// TransformInPlace applies the same weighting and normalisation as Transform() directly to
+// the supplied matrix, overwriting its values, rather than allocating a new output matrix.
+// For large term document matrices this avoids holding 2 copies of the matrix in memory at
+// once and so should be preferred where the caller owns the matrix and no longer needs the
+// original values.  An error is returned, and the matrix left unmodified, if the
+// transformer has not been fitted, the number of rows in the matrix differs from the number
+// of terms the transformer was fitted to or (if NonFinite is RejectNonFinite) the matrix
+// contains NaN or infinite values.
+func (t *TfidfTransformer) TransformInPlace(dst *mat64.Dense) error {
+	m, _ := dst.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return err
+	}
+	if t.NonFinite == RejectNonFinite {
+		if err := checkFinite(dst); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < m; i++ {
+		row := dst.RawRowView(i)
+		for j, v := range row {
+			row[j] = t.weight(i, v)
+		}
+	}
+	normaliseColumns(dst, t.Norm)
+
+	return nil
+}
+
+// Transform32 is equivalent to Transform() but produces a single precision (float32)
+// output matrix, requiring half the memory of the double precision matrix output by
+// Transform().  The weighting and normalisation are calculated in double precision and only
+// the results are stored in single precision.  The supplied matrix may itself be a
+// *Dense32 to avoid holding the input in double precision.  If the supplied matrix is a
+// *SparseMatrix, only its non zero elements are visited.
+func (t *TfidfTransformer) Transform32(mat mat64.Matrix) (*Dense32, error) {
+	m, n := mat.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return nil, err
+	}
+	if t.NonFinite == RejectNonFinite {
+		if err := checkFinite(mat); err != nil {
+			return nil, err
+		}
+	}
+	product := NewDense32(m, n, nil)
+
+	switch s := mat.(type) {
+	case *SparseMatrix:
+		s.DoNonZero(func(i, j int, v float64) {
+			product.data[i*n+j] = float32(t.weight(i, v))
+		})
+	case *Dense32:
+		for k, v := range s.data {
+			product.data[k] = float32(t.weight(k/n, float64(v)))
+		}
+	default:
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				product.data[i*n+j] = float32(t.weight(i, mat.At(i, j)))
+			}
+		}
+	}
 
-	// todo: possibly L2 norm matrix to remove any bias caused by documents of different
-	// lengths where longer documents naturally have more words and so higher word counts
+	product.normaliseColumns(t.Norm)
 
 	return product, nil
 }
 
-// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
-// same matrix.  This is a convenience where separate trianing data is not being
-// used to fit the model i.e. the model is fitted on the fly to the test data.
-func (t *TfidfTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
-	return t.Fit(mat).Transform(mat)
+// TransformSparse is equivalent to Transform() but produces a sparse output matrix rather
+// than a dense one.  As term document matrices are typically overwhelmingly zero, this can
+// substantially reduce memory usage for large vocabularies.  If the supplied matrix is a
+// *SparseMatrix, only its non zero elements are visited.
+func (t *TfidfTransformer) TransformSparse(mat mat64.Matrix) (*SparseMatrix, error) {
+	product := &SparseMatrix{}
+	if err := t.TransformSparseInto(product, mat); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// TransformSparseInto is equivalent to TransformSparse() but writes the output into the
+// supplied destination sparse matrix, dst, rather than allocating a new matrix.  Any existing
+// contents of dst are discarded, with dst resized to the dimensions of src, and its storage
+// reused (and only grown where insufficient) so that repeatedly transforming small batches
+// (e.g. individual queries within a server) into the same destination avoids allocating
+// new storage for each call.  dst must not be src.
+func (t *TfidfTransformer) TransformSparseInto(dst *SparseMatrix, src mat64.Matrix) error {
+	m, n := src.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return err
+	}
+	if s, ok := src.(*SparseMatrix); ok && s == dst {
+		return fmt.Errorf("%w: destination matrix must not be the source matrix", ErrInvalidArgument)
+	}
+	if t.NonFinite == RejectNonFinite {
+		if err := checkFinite(src); err != nil {
+			return err
+		}
+	}
+
+	dst.r, dst.c = m, n
+	if cap(dst.indptr) < n+1 {
+		dst.indptr = make([]int, n+1)
+	} else {
+		dst.indptr = dst.indptr[:n+1]
+		for j := range dst.indptr {
+			dst.indptr[j] = 0
+		}
+	}
+	dst.ind = dst.ind[:0]
+	dst.data = dst.data[:0]
+
+	if s, ok := src.(*SparseMatrix); ok {
+		if cap(dst.ind) < s.NNZ() {
+			dst.ind = make([]int, 0, s.NNZ())
+			dst.data = make([]float64, 0, s.NNZ())
+		}
+		for j := 0; j < n; j++ {
+			for k := s.indptr[j]; k < s.indptr[j+1]; k++ {
+				t.appendWeighted(dst, s.ind[k], j, s.data[k])
+			}
+		}
+	} else {
+		for j := 0; j < n; j++ {
+			for i := 0; i < m; i++ {
+				if v := src.At(i, j); v != 0 {
+					t.appendWeighted(dst, i, j, v)
+				}
+			}
+		}
+	}
+
+	// columns without any non zero elements will not have been visited
+	for j := 1; j <= n; j++ {
+		if dst.indptr[j] < dst.indptr[j-1] {
+			dst.indptr[j] = dst.indptr[j-1]
+		}
+	}
+
+	dst.normaliseColumns(t.Norm)
+
+	return nil
+}
+
+// appendWeighted appends the weighted value of v, the element at row i and column j, to the
+// sparse matrix dst (whose columns must be populated in order) if it is non zero.
+func (t *TfidfTransformer) appendWeighted(dst *SparseMatrix, i, j int, v float64) {
+	if w := t.weight(i, v); w != 0 {
+		dst.ind = append(dst.ind, i)
+		dst.data = append(dst.data, w)
+	}
+	dst.indptr[j+1] = len(dst.ind)
+}
+
+// checkFitted returns an error if the transformer has not been fitted or was fitted to
+// matrices with a different number of terms (rows) than m (or, if AllowExtraTerms, more
+// terms than m).
+func (t *TfidfTransformer) checkFitted(m int) error {
+	if t.weights == nil {
+		if t.fitErr != nil {
+			return fmt.Errorf("TfidfTransformer is not fitted as fitting failed caused by %w", t.fitErr)
+		}
+		return fmt.Errorf("%w: TfidfTransformer", ErrNotFitted)
+	}
+	if m != len(t.weights) && !(t.AllowExtraTerms && m > len(t.weights)) {
+		return fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d terms", ErrDimensionMismatch, m, len(t.weights))
+	}
+	return nil
+}
+
+// weight applies the term weighting to the value v of the term represented by row i
+func (t *TfidfTransformer) weight(i int, v float64) float64 {
+	if t.NonFinite == ZeroNonFinite && isNonFinite(v) {
+		return 0
+	}
+	if t.SublinearTF && v != 0 {
+		v = 1 + math.Log(v)
+	}
+	return v * t.termWeight(i)
+}
+
+// termWeight returns the weight of the term represented by row i or, if the row is beyond
+// the terms the transformer was fitted to, DefaultWeight.
+func (t *TfidfTransformer) termWeight(i int) float64 {
+	if i >= len(t.weights) {
+		return t.DefaultWeight
+	}
+	return t.weights[i]
+}
+
+// TransformWithLengths is equivalent to Transform() except that each term frequency is first
+// divided by the length of its document (e.g. the total number of tokens), as supplied in
+// lengths, to give relative term frequencies.  This allows document lengths tracked outside
+// the matrix to be used where the matrix is a truncated or sampled representation of the
+// documents and so the sums of its columns do not reflect the true lengths.  lengths must
+// contain one entry per column of the matrix and no negative values.  Documents with a
+// length of 0 produce columns of zeros.
+func (t *TfidfTransformer) TransformWithLengths(mat mat64.Matrix, lengths []float64) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return nil, err
+	}
+	if len(lengths) != n {
+		return nil, fmt.Errorf("%w: %d document lengths supplied but matrix has %d columns", ErrDimensionMismatch, len(lengths), n)
+	}
+	for j, l := range lengths {
+		if l < 0 {
+			return nil, fmt.Errorf("%w: document length %f for column %d is negative", ErrInvalidArgument, l, j)
+		}
+	}
+
+	relative := func(v, length float64) float64 {
+		if length == 0 {
+			return 0
+		}
+		return v / length
+	}
+
+	var scaled mat64.Matrix
+	if s, ok := mat.(*SparseMatrix); ok {
+		c := s.clone()
+		for j := 0; j < n; j++ {
+			for k := c.indptr[j]; k < c.indptr[j+1]; k++ {
+				c.data[k] = relative(c.data[k], lengths[j])
+			}
+		}
+		scaled = c
+	} else {
+		d := mat64.NewDense(m, n, nil)
+		d.Apply(func(i, j int, v float64) float64 {
+			return relative(v, lengths[j])
+		}, mat)
+		scaled = d
+	}
+
+	return t.Transform(scaled)
+}
+
+// TransformWithBoost is equivalent to Transform() except that the weight of each term is
+// multiplied by the corresponding boost factor, indexed by term row, before normalisation.
+// This allows terms to be emphasised relative to others without a separate transformer e.g.
+// for field boosting in search applications where the terms of a document's title (encoded
+// as separate rows from the same terms within the body) should count more than those of
+// its body.  A boost of 1 leaves the weight of a term unchanged and 0 removes the term.
+// boost must contain one entry per row of the matrix (as fitted) and no negative values.
+func (t *TfidfTransformer) TransformWithBoost(mat mat64.Matrix, boost []float64) (*mat64.Dense, error) {
+	m, _ := mat.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return nil, err
+	}
+	if len(boost) != m {
+		return nil, fmt.Errorf("%w: %d boost factors supplied but matrix has %d rows", ErrDimensionMismatch, len(boost), m)
+	}
+	for i, b := range boost {
+		if b < 0 {
+			return nil, fmt.Errorf("%w: boost factor %f for row %d is negative", ErrInvalidArgument, b, i)
+		}
+	}
+
+	boosted := *t
+	boosted.weights = make([]float64, m)
+	for i := range boosted.weights {
+		boosted.weights[i] = t.termWeight(i) * boost[i]
+	}
+
+	return boosted.Transform(mat)
+}
+
+// TransformQuery weights the supplied query term counts, keyed by term (row) index, using
+// the fitted weights in the same way as Transform() so that the resulting query vector may
+// be compared with transformed documents e.g. to retrieve the most similar documents.  Term
+// indices outside the range of the fitted terms (e.g. terms not present in the training
+// vocabulary) are ignored.  The returned vector has one element per fitted term.
+func (t *TfidfTransformer) TransformQuery(counts map[int]float64) (*mat64.Vector, error) {
+	if t.weights == nil {
+		return nil, fmt.Errorf("%w: TfidfTransformer", ErrNotFitted)
+	}
+
+	query := mat64.NewVector(len(t.weights), nil)
+	for i, v := range counts {
+		if i < 0 || i >= len(t.weights) {
+			continue
+		}
+		if t.NonFinite == RejectNonFinite && isNonFinite(v) {
+			return nil, fmt.Errorf("%w: query contains %f for term %d", ErrNonFinite, v, i)
+		}
+		query.SetVec(i, t.weight(i, v))
+	}
+
+	if p, ok := t.Norm.p(); ok {
+		if length := mat64.Norm(query, p); length != 0 {
+			query.ScaleVec(1/length, query)
+		}
+	}
+	return query, nil
+}
+
+// TermScore is a term along with its weighted value (score) within a document
+type TermScore struct {
+	Term  string
+	Score float64
+}
+
+// ExplainDocument returns the k terms contributing most to the specified document (column)
+// of the supplied term document matrix, of raw term frequencies, once weighted by the
+// transformer (including normalisation), in descending order of weighted value with ties
+// broken by row.  This is useful to explain why a document was considered relevant or
+// similar to another.  vocabulary maps each row (term) of the matrix to the corresponding
+// term and rows beyond the end of vocabulary are ignored, as are terms not occuring within
+// the document.  If k exceeds the number of terms occuring within the document then all of
+// them are returned.  If the transformer has not been fitted, the number of rows in the
+// matrix differs from the number of terms the transformer was fitted to or col is not a
+// valid column index then nil is returned.
+func (t *TfidfTransformer) ExplainDocument(col int, mat mat64.Matrix, vocabulary []string, k int) []TermScore {
+	m, n := mat.Dims()
+	if col < 0 || col >= n {
+		return nil
+	}
+	weighted, err := t.Transform(mat64.NewDense(m, 1, mat64.Col(nil, col, mat)))
+	if err != nil {
+		return nil
+	}
+
+	var terms []TermScore
+	for i := 0; i < min(m, len(vocabulary)); i++ {
+		if v := weighted.At(i, 0); v != 0 {
+			terms = append(terms, TermScore{Term: vocabulary[i], Score: v})
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].Score > terms[j].Score
+	})
+
+	if k < 0 {
+		k = 0
+	}
+	if k < len(terms) {
+		terms = terms[:k]
+	}
+	return terms
+}
+
+// MeanContribution returns the mean weighted value of each term (row) of the supplied term
+// document matrix, once weighted by the transformer (including normalisation), across the
+// documents (columns) in which the weighted value is non zero.  This profiles how much each
+// term typically contributes to the document vectors in which it occurs and so helps to
+// identify dominant terms within a corpus.  Terms with no non zero weighted values have a
+// mean of 0.  If the transformer has not been fitted or the number of rows in the matrix
+// differs from the number of terms the transformer was fitted to then nil is returned.
+func (t *TfidfTransformer) MeanContribution(mat mat64.Matrix) []float64 {
+	weighted, err := t.TransformSparse(mat)
+	if err != nil {
+		return nil
+	}
+
+	m, _ := weighted.Dims()
+	sums := make([]float64, m)
+	counts := make([]float64, m)
+	weighted.DoNonZero(func(i, j int, v float64) {
+		sums[i] += v
+		counts[i]++
+	})
+	for i, count := range counts {
+		if count != 0 {
+			sums[i] /= count
+		}
+	}
+	return sums
+}
+
+// InverseTransform approximately reverses Transform(), mapping the supplied tf-idf weighted
+// matrix back to term frequencies by dividing each element by the idf weight of the
+// corresponding term (and reversing sublinear scaling if SublinearTF is enabled).  Elements
+// for terms with an idf weight of 0 are returned as 0.  Normalisation cannot be reversed
+// so, if Norm was applied by Transform(), the output will be term frequencies scaled
+// per document rather than the original counts.
+func (t *TfidfTransformer) InverseTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if err := t.checkFitted(m); err != nil {
+		return nil, err
+	}
+	product := mat64.NewDense(m, n, nil)
+
+	product.Apply(func(i, j int, v float64) float64 {
+		w := t.termWeight(i)
+		if w == 0 {
+			return 0
+		}
+		v /= w
+		if t.SublinearTF && v != 0 {
+			v = math.Exp(v - 1)
+		}
+		return v
+	}, mat)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.  An error
+// wrapping ErrEmptyCorpus or ErrEmptyVocabulary is returned if the matrix contains no
+// documents or no terms respectively.
+func (t *TfidfTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if err := checkCorpus(mat.Dims()); err != nil {
+		t.Fit(mat)
+		return nil, err
+	}
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted weights and accumulated
+// document statistics.
+func (t *TfidfTransformer) Clone() Transformer {
+	c := *t
+	c.weights = copyFloats(t.weights)
+	c.df = copyFloats(t.df)
+	if t.frozen != nil {
+		c.frozen = make(map[int]float64, len(t.frozen))
+		for i, w := range t.frozen {
+			c.frozen[i] = w
+		}
+	}
+	return &c
+}
+
+// tfidfEncodingVersion is the version of the binary encoding of TfidfTransformer written
+// by MarshalBinary()
+const tfidfEncodingVersion = 1
+
+// tfidfState is the serialisable state of a TfidfTransformer.  Fields may be added in
+// future versions but existing fields should not be removed or changed.
+type tfidfState struct {
+	Weights     []float64
+	DF          []float64
+	N           float64
+	Docs        int
+	Norm        NormType
+	SublinearTF bool
+	Smooth      bool
+	OffsetIdf   bool
+	LogBase     float64
+	NonFinite   NonFinitePolicy
+	MaxIDF      float64
+	CorpusSize  int
+	Epsilon     float64
+
+	AllowExtraTerms bool
+	DefaultWeight   float64
+
+	Frozen map[int]float64
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface, encoding the fitted
+// weights, accumulated document statistics and configuration of the transformer so that it
+// may be persisted and later restored with UnmarshalBinary() e.g. to fit a model once and
+// reuse it within a separate process.  The encoding begins with a version number to allow
+// the format to evolve.
+func (t *TfidfTransformer) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(tfidfEncodingVersion)
+
+	state := tfidfState{
+		Weights:     t.weights,
+		DF:          t.df,
+		N:           t.n,
+		Docs:        t.docs,
+		Norm:        t.Norm,
+		SublinearTF: t.SublinearTF,
+		Smooth:      t.Smooth,
+		OffsetIdf:   t.OffsetIdf,
+		LogBase:     t.LogBase,
+		NonFinite:   t.NonFinite,
+		MaxIDF:      t.MaxIDF,
+		CorpusSize:  t.CorpusSize,
+		Epsilon:     t.Epsilon,
+
+		AllowExtraTerms: t.AllowExtraTerms,
+		DefaultWeight:   t.DefaultWeight,
+
+		Frozen: t.frozen,
+	}
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, fmt.Errorf("Failed to encode TfidfTransformer caused by %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface, restoring the state
+// of a transformer previously encoded with MarshalBinary().  Any existing state of the
+// transformer is replaced.
+func (t *TfidfTransformer) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("Failed to decode TfidfTransformer: no data")
+	}
+	if version := data[0]; version != tfidfEncodingVersion {
+		return fmt.Errorf("Failed to decode TfidfTransformer: unsupported encoding version %d", version)
+	}
+
+	var state tfidfState
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&state); err != nil {
+		return fmt.Errorf("Failed to decode TfidfTransformer caused by %w", err)
+	}
+
+	t.weights = state.Weights
+	t.df = state.DF
+	t.fitErr = nil
+	t.n = state.N
+	t.docs = state.Docs
+	t.Norm = state.Norm
+	t.SublinearTF = state.SublinearTF
+	t.Smooth = state.Smooth
+	t.OffsetIdf = state.OffsetIdf
+	t.LogBase = state.LogBase
+	t.NonFinite = state.NonFinite
+	t.MaxIDF = state.MaxIDF
+	t.CorpusSize = state.CorpusSize
+	t.Epsilon = state.Epsilon
+	t.AllowExtraTerms = state.AllowExtraTerms
+	t.DefaultWeight = state.DefaultWeight
+	t.frozen = state.Frozen
+
+	return nil
+}
+
+// BM25Transformer weights a raw term document matrix using the Okapi BM25 ranking function
+// commonly used within information retrieval.  Like TF-IDF, terms are weighted by their
+// inverse document frequency but, unlike TF-IDF, the contribution of the term frequency
+// saturates as it grows (controlled by K1) and is normalised by the length of the document
+// relative to the average document length within the corpus (controlled by B).
+// More precisely, each term frequency tf is transformed as:
+//
+//	idf * (tf * (K1 + 1)) / (tf + K1 * (1 - B + B * dl / avgdl))
+//
+// where dl is the length (total term count) of the document and avgdl is the average
+// document length across the training corpus.  The idf is calculated as
+// log(1 + (n - df + 0.5) / (df + 0.5)) which, unlike the classic BM25 idf, is never negative.
+type BM25Transformer struct {
+	weights []float64
+	avgdl   float64
+
+	// K1 controls term frequency saturation.  Higher values allow term frequency to
+	// contribute more before saturating.
+	K1 float64
+
+	// B controls the degree of document length normalisation where 0 applies no length
+	// normalisation and 1 applies full length normalisation.
+	B float64
+}
+
+// NewBM25Transformer constructs a new BM25Transformer with the commonly used default values
+// for K1 (1.5) and B (0.75).
+func NewBM25Transformer() *BM25Transformer {
+	return &BM25Transformer{K1: 1.5, B: 0.75}
+}
+
+// Fit takes a training term document matrix, counts term occurances across all documents
+// to calculate the idf weights and calculates the average document length for use in
+// subsequent calls to Transform().
+func (t *BM25Transformer) Fit(mat mat64.Matrix) Transformer {
+	m, n := mat.Dims()
+
+	t.weights = make([]float64, m)
+
+	var total float64
+	for i := 0; i < m; i++ {
+		df := 0
+		for j := 0; j < n; j++ {
+			if v := mat.At(i, j); v != 0 {
+				df++
+				total += v
+			}
+		}
+		t.weights[i] = math.Log(1 + (float64(n-df)+0.5)/(float64(df)+0.5))
+	}
+
+	t.avgdl = 0
+	if n > 0 {
+		t.avgdl = total / float64(n)
+	}
+
+	return t
+}
+
+// Transform applies the BM25 weighting to the supplied term document matrix using the idf
+// weights and average document length calculated during Fit().  Empty documents (with a
+// length of 0) produce columns of zeros.  An error is returned if the transformer has not
+// been fitted or the number of rows (terms) in the matrix differs from the number of terms
+// the transformer was fitted to.
+func (t *BM25Transformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if t.weights == nil {
+		return nil, fmt.Errorf("%w: BM25Transformer", ErrNotFitted)
+	}
+	if m != len(t.weights) {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d terms", ErrDimensionMismatch, m, len(t.weights))
+	}
+	product := mat64.NewDense(m, n, nil)
+
+	lengths := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			lengths[j] += mat.At(i, j)
+		}
+	}
+
+	product.Apply(func(i, j int, v float64) float64 {
+		if v == 0 {
+			return 0
+		}
+		var ratio float64
+		if t.avgdl != 0 {
+			ratio = lengths[j] / t.avgdl
+		}
+		return t.weights[i] * (v * (t.K1 + 1)) / (v + t.K1*(1-t.B+t.B*ratio))
+	}, mat)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (t *BM25Transformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted weights.
+func (t *BM25Transformer) Clone() Transformer {
+	c := *t
+	c.weights = copyFloats(t.weights)
+	return &c
+}
+
+// LogEntropyTransformer weights a raw term document matrix using log-entropy weighting, an
+// alternative to TF-IDF that often performs better for LSA (Latent Semantic Analysis).
+// Each term frequency tf is transformed as:
+//
+//	log(1 + tf) * g
+//
+// where g is the global weight of the term calculated as:
+//
+//	1 + sum_j(p_j * log(p_j)) / log(n)
+//
+// where p_j is the frequency of the term in document j divided by the total frequency of
+// the term across the n documents of the training corpus.  The global weight is 1 for a
+// term occuring in a single document and falls towards 0 the more evenly the term is
+// distributed across the documents (a term occuring equally often in every document has a
+// weight of 0).
+type LogEntropyTransformer struct {
+	weights []float64
+}
+
+// NewLogEntropyTransformer constructs a new LogEntropyTransformer.
+func NewLogEntropyTransformer() *LogEntropyTransformer {
+	return &LogEntropyTransformer{}
+}
+
+// Fit takes a training term document matrix and calculates the global entropy based weight
+// of each term for use in subsequent calls to Transform().  Terms not occuring within the
+// training matrix (total frequency of 0), and all terms if the matrix contains only a single
+// document, have a weight of 1.
+func (t *LogEntropyTransformer) Fit(mat mat64.Matrix) Transformer {
+	m, n := mat.Dims()
+	t.weights = make([]float64, m)
+
+	logN := math.Log(float64(n))
+	for i := 0; i < m; i++ {
+		var gf float64
+		for j := 0; j < n; j++ {
+			gf += mat.At(i, j)
+		}
+		t.weights[i] = 1
+		if gf == 0 || logN == 0 {
+			continue
+		}
+
+		var entropy float64
+		for j := 0; j < n; j++ {
+			if v := mat.At(i, j); v != 0 {
+				p := v / gf
+				entropy += p * math.Log(p)
+			}
+		}
+		t.weights[i] += entropy / logN
+	}
+
+	return t
+}
+
+// Weights returns a copy of the global weights calculated during Fit().  Each element
+// corresponds to the term represented by the same row of the fitted term document matrix.
+// Weights returns nil if the transformer has not been fitted.
+func (t *LogEntropyTransformer) Weights() []float64 {
+	if t.weights == nil {
+		return nil
+	}
+	weights := make([]float64, len(t.weights))
+	copy(weights, t.weights)
+	return weights
+}
+
+// Transform applies the log-entropy weighting to the supplied term document matrix using
+// the global weights calculated during Fit().  An error is returned if the transformer has
+// not been fitted or the number of rows (terms) in the matrix differs from the number of
+// terms the transformer was fitted to.
+func (t *LogEntropyTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if t.weights == nil {
+		return nil, fmt.Errorf("%w: LogEntropyTransformer", ErrNotFitted)
+	}
+	if m != len(t.weights) {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d terms", ErrDimensionMismatch, m, len(t.weights))
+	}
+	product := mat64.NewDense(m, n, nil)
+
+	product.Apply(func(i, j int, v float64) float64 {
+		if v == 0 {
+			return 0
+		}
+		return math.Log1p(v) * t.weights[i]
+	}, mat)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (t *LogEntropyTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted weights.
+func (t *LogEntropyTransformer) Clone() Transformer {
+	return &LogEntropyTransformer{weights: copyFloats(t.weights)}
+}
+
+// TficfTransformer is a supervised alternative to TfidfTransformer for text classification
+// which weights the term frequencies within a term document matrix by the inverse class
+// frequency (icf) of each term rather than its inverse document frequency.  The class
+// frequency of a term is the number of classes with at least one training document
+// containing the term so terms concentrated within few classes, and therefore most
+// discriminative of the class of a document, are weighted more heavily than terms spread
+// across many classes regardless of how many documents they occur in.  The icf of term i is
+// calculated (smoothed as for the idf of NewScikitCompatibleTfidf()) as:
+//
+//	icf(i) = log((1 + c) / (1 + cf(i))) + 1
+//
+// where c is the number of classes and cf(i) is the class frequency of the term.  The
+// transformer should be fitted to labelled training data using FitWithLabels().
+type TficfTransformer struct {
+	weights []float64
+
+	// fitErr records why the last fit left the transformer unfitted so that it may be
+	// returned by subsequent calls to Transform().
+	fitErr error
+}
+
+// NewTficfTransformer constructs a new TficfTransformer.
+func NewTficfTransformer() *TficfTransformer {
+	return &TficfTransformer{}
+}
+
+// Fit takes a training term document matrix without class labels and calculates the icf
+// weight of each term treating every document as a class of its own, which is equivalent to
+// the idf weights of NewScikitCompatibleTfidf().  Fit allows TficfTransformer to satisfy the
+// Transformer interface but FitWithLabels() should normally be used instead.  If the matrix
+// is empty the transformer is left unfitted and subsequent calls to Transform() (and so
+// FitTransform()) return an error wrapping ErrEmptyVocabulary or ErrEmptyCorpus.
+func (t *TficfTransformer) Fit(mat mat64.Matrix) Transformer {
+	_, n := mat.Dims()
+	labels := make([]int, n)
+	for j := range labels {
+		labels[j] = j
+	}
+	t.FitWithLabels(mat, labels)
+	return t
+}
+
+// FitWithLabels takes a training term document matrix along with the class label of each
+// document (column), where labels[j] is the class of document j, and calculates the icf
+// weight of each term for use in subsequent calls to Transform().  Terms are treated as
+// occuring within a class if their value is non zero in any document of that class.  An
+// error is returned, leaving the transformer unfitted, if the matrix is empty or the length
+// of labels differs from the number of documents.  The same error is returned by
+// subsequent calls to Transform() (and so by FitTransform()) until the transformer is
+// successfully fitted.
+func (t *TficfTransformer) FitWithLabels(mat mat64.Matrix, labels []int) error {
+	t.weights = nil
+	t.fitErr = t.fitWithLabels(mat, labels)
+	return t.fitErr
+}
+
+// fitWithLabels calculates the icf weights for FitWithLabels()
+func (t *TficfTransformer) fitWithLabels(mat mat64.Matrix, labels []int) error {
+	m, n := mat.Dims()
+	if err := checkCorpus(m, n); err != nil {
+		return err
+	}
+	if len(labels) != n {
+		return fmt.Errorf("%w: %d labels supplied for %d documents", ErrDimensionMismatch, len(labels), n)
+	}
+
+	docClass, classDocs := classIndices(labels)
+	members := make([][]int, len(classDocs))
+	for j, c := range docClass {
+		members[c] = append(members[c], j)
+	}
+
+	// count the classes in which each term occurs, visiting the documents class by class
+	// so each term need only record the last class in which it was seen
+	cf := make([]int, m)
+	last := make([]int, m)
+	for i := range last {
+		last[i] = -1
+	}
+	occurs := func(i, c int) {
+		if last[i] != c {
+			last[i] = c
+			cf[i]++
+		}
+	}
+	s, sparse := mat.(*SparseMatrix)
+	for c, docs := range members {
+		for _, j := range docs {
+			if sparse {
+				for k := s.indptr[j]; k < s.indptr[j+1]; k++ {
+					if s.data[k] != 0 {
+						occurs(s.ind[k], c)
+					}
+				}
+				continue
+			}
+			for i := 0; i < m; i++ {
+				if mat.At(i, j) != 0 {
+					occurs(i, c)
+				}
+			}
+		}
+	}
+
+	c := float64(len(classDocs))
+	t.weights = make([]float64, m)
+	for i, classes := range cf {
+		t.weights[i] = math.Log((1+c)/(1+float64(classes))) + 1
+	}
+	return nil
+}
+
+// Weights returns a copy of the icf weights calculated during fitting.  Each element
+// corresponds to the term represented by the same row of the fitted term document matrix.
+// Weights returns nil if the transformer has not been fitted.
+func (t *TficfTransformer) Weights() []float64 {
+	return copyFloats(t.weights)
+}
+
+// Transform applies the tf-icf weighting to the supplied term document matrix, multiplying
+// each term frequency by the icf weight of the term calculated during fitting.  An error is
+// returned if the transformer has not been fitted or the number of rows (terms) in the
+// matrix differs from the number of terms the transformer was fitted to.
+func (t *TficfTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	if t.weights == nil {
+		if t.fitErr != nil {
+			return nil, fmt.Errorf("TficfTransformer is not fitted as fitting failed caused by %w", t.fitErr)
+		}
+		return nil, fmt.Errorf("%w: TficfTransformer", ErrNotFitted)
+	}
+	if m != len(t.weights) {
+		return nil, fmt.Errorf("%w: matrix has %d rows but transformer was fitted on %d terms", ErrDimensionMismatch, m, len(t.weights))
+	}
+	product := mat64.NewDense(m, n, nil)
+
+	product.Apply(func(i, j int, v float64) float64 {
+		return v * t.weights[i]
+	}, mat)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix i.e. without class labels.  FitWithLabels() followed by Transform() should
+// normally be used instead.
+func (t *TficfTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a deep copy of the transformer including its fitted weights.
+func (t *TficfTransformer) Clone() Transformer {
+	return &TficfTransformer{weights: copyFloats(t.weights), fitErr: t.fitErr}
+}
+
+// TfTransformer converts raw term frequencies (counts) within a term document matrix into
+// relative term frequencies by dividing each count by the total number of terms within the
+// document (the sum of the column).  This accounts for differences in document length
+// without applying any corpus level weighting such as idf.  TfTransformer requires no
+// fitting.
+type TfTransformer struct {
+	// Augmented, if true, uses augmented term frequencies instead of relative term frequencies
+	// i.e. 0.5 + 0.5 * tf / max(tf) where max(tf) is the largest count within the document.
+	// This prevents a bias towards longer documents in a different way to dividing by the
+	// number of terms, scaling each non zero count to the range 0.5 to 1.  Zero counts remain
+	// zero.  Augmented term frequencies may be weighted further, e.g. by idf, by following
+	// with a TfidfTransformer.
+	Augmented bool
+}
+
+// NewTfTransformer constructs a new TfTransformer.
+func NewTfTransformer() *TfTransformer {
+	return &TfTransformer{}
+}
+
+// Fit does nothing as TfTransformer requires no fitting.  It is provided to implement the
+// Transformer interface.
+func (t *TfTransformer) Fit(mat mat64.Matrix) Transformer {
+	return t
+}
+
+// Transform divides each element of the supplied term document matrix by the sum of its
+// column (the total number of terms in the document) or, if Augmented, calculates the
+// augmented term frequency of each non zero element using the maximum of its column.
+// Empty documents (where the column contains only zeros) produce columns of zeros.
+func (t *TfTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	m, n := mat.Dims()
+	product := mat64.NewDense(m, n, nil)
+
+	totals := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			if t.Augmented {
+				totals[j] = math.Max(totals[j], mat.At(i, j))
+			} else {
+				totals[j] += mat.At(i, j)
+			}
+		}
+	}
+
+	product.Apply(func(i, j int, v float64) float64 {
+		if totals[j] == 0 || v == 0 {
+			return 0
+		}
+		if t.Augmented {
+			return 0.5 + 0.5*v/totals[j]
+		}
+		return v / totals[j]
+	}, mat)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (t *TfTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a copy of the transformer.
+func (t *TfTransformer) Clone() Transformer {
+	c := *t
+	return &c
+}
+
+// Normaliser scales each document (column) of a term document matrix by its norm so that
+// documents of differing lengths may be compared.  Unlike the Norm option of
+// TfidfTransformer, Normaliser may be used as a standalone stage e.g. following any
+// weighting transformer within a Pipeline.  Normaliser requires no fitting.
+type Normaliser struct {
+	// Norm is the type of normalisation applied to each column.  Columns with a norm of zero
+	// are left unchanged.
+	Norm NormType
+}
+
+// NewNormaliser constructs a new Normaliser applying the specified type of normalisation.
+func NewNormaliser(norm NormType) *Normaliser {
+	return &Normaliser{Norm: norm}
+}
+
+// Fit does nothing as Normaliser requires no fitting.  It is provided to implement the
+// Transformer interface.
+func (t *Normaliser) Fit(mat mat64.Matrix) Transformer {
+	return t
+}
+
+// Transform returns a copy of the supplied matrix with each column scaled by its norm.
+func (t *Normaliser) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	var product *mat64.Dense
+	if s, ok := mat.(*SparseMatrix); ok {
+		product = s.ToDense()
+	} else {
+		product = mat64.DenseCopyOf(mat)
+	}
+
+	normaliseColumns(product, t.Norm)
+
+	return product, nil
+}
+
+// TransformSparse is equivalent to Transform() but produces a sparse output matrix rather
+// than a dense one.
+func (t *Normaliser) TransformSparse(mat mat64.Matrix) (*SparseMatrix, error) {
+	var product *SparseMatrix
+	if s, ok := mat.(*SparseMatrix); ok {
+		product = s.clone()
+	} else {
+		product = NewSparseMatrixFrom(mat)
+	}
+
+	product.normaliseColumns(t.Norm)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (t *Normaliser) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a copy of the transformer.
+func (t *Normaliser) Clone() Transformer {
+	c := *t
+	return &c
+}
+
+// ClipTransformer clips (clamps) every value of a matrix into the range Min to Max.  This
+// limits the influence of extreme outliers e.g. a term repeated thousands of times within a
+// single document, and may be used as a standalone stage within a Pipeline before
+// weighting.  ClipTransformer requires no fitting.
+type ClipTransformer struct {
+	// Min is the minimum value of the output matrix.  Values less than Min are replaced with
+	// Min.
+	Min float64
+
+	// Max is the maximum value of the output matrix.  Values greater than Max are replaced
+	// with Max.
+	Max float64
+}
+
+// NewClipTransformer constructs a new ClipTransformer clipping values into the range min to
+// max e.g. NewClipTransformer(0, 10) limits raw term counts to at most 10.
+func NewClipTransformer(min, max float64) *ClipTransformer {
+	return &ClipTransformer{Min: min, Max: max}
+}
+
+// Fit does nothing as ClipTransformer requires no fitting.  It is provided to implement the
+// Transformer interface.
+func (t *ClipTransformer) Fit(mat mat64.Matrix) Transformer {
+	return t
+}
+
+// Transform returns a copy of the supplied matrix with values less than Min replaced with
+// Min and values greater than Max replaced with Max.  Values within the range (and NaN
+// values) are unchanged.  As every element is clipped, zero elements of sparse matrices
+// are also replaced if they fall outside the range.  An error is returned if Min is
+// greater than Max.
+func (t *ClipTransformer) Transform(mat mat64.Matrix) (*mat64.Dense, error) {
+	if t.Min > t.Max {
+		return nil, fmt.Errorf("%w: Min (%f) must not be greater than Max (%f)", ErrInvalidArgument, t.Min, t.Max)
+	}
+
+	m, n := mat.Dims()
+	product := mat64.NewDense(m, n, nil)
+	product.Apply(func(i, j int, v float64) float64 {
+		if v < t.Min {
+			return t.Min
+		}
+		if v > t.Max {
+			return t.Max
+		}
+		return v
+	}, mat)
+
+	return product, nil
+}
+
+// FitTransform is exactly equivalent to calling Fit() followed by Transform() on the
+// same matrix.  This is a convenience where separate trianing data is not being
+// used to fit the model i.e. the model is fitted on the fly to the test data.
+func (t *ClipTransformer) FitTransform(mat mat64.Matrix) (*mat64.Dense, error) {
+	return t.Fit(mat).Transform(mat)
+}
+
+// Clone returns a copy of the transformer.
+func (t *ClipTransformer) Clone() Transformer {
+	c := *t
+	return &c
 }
@@ -1,8 +1,17 @@
 package nlp
 
 import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix/mat64"
 )
 
@@ -52,69 +61,2205 @@ func TestTfidfTransformerFit(t *testing.T) {
 	}
 }
 
+func TestTfidfTransformerWeights(t *testing.T) {
+	transformer := NewTfidfTransformer()
+
+	if transformer.Weights() != nil {
+		t.Errorf("Expected nil weights before fitting but found %v", transformer.Weights())
+	}
+
+	input := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 3, 0, 0,
+		4, 5, 6, 1,
+	})
+	transformer.Fit(input)
+
+	if transformer.Documents() != 4 {
+		t.Errorf("Expected 4 documents but found %d", transformer.Documents())
+	}
+
+	weights := transformer.Weights()
+	if len(weights) != 3 {
+		t.Fatalf("Expected 3 weights but found %d", len(weights))
+	}
+	for i, v := range weights {
+		if v != transformer.weights[i] {
+			t.Errorf("Expected weight %f at index %d but found %f", transformer.weights[i], i, v)
+		}
+	}
+
+	// mutating the returned slice must not affect the transformer
+	weights[0] = 100
+	if transformer.weights[0] == 100 {
+		t.Errorf("Expected Weights() to return a copy but internal weights were modified")
+	}
+}
+
+func TestTfidfTransformerPartialFit(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	expected := NewTfidfTransformer()
+	expected.Fit(input)
+
+	for _, mat := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+		var first, second mat64.Dense
+		first.Clone(input.Slice(0, 6, 0, 2))
+		second.Clone(input.Slice(0, 6, 2, 4))
+
+		var halves []mat64.Matrix
+		if _, ok := mat.(*SparseMatrix); ok {
+			halves = []mat64.Matrix{NewSparseMatrixFrom(&first), NewSparseMatrixFrom(&second)}
+		} else {
+			halves = []mat64.Matrix{&first, &second}
+		}
+
+		transformer := NewTfidfTransformer()
+		for _, half := range halves {
+			transformer.PartialFit(half)
+		}
+
+		if transformer.Documents() != expected.Documents() {
+			t.Errorf("Expected %d documents but found %d", expected.Documents(), transformer.Documents())
+		}
+
+		for i, v := range transformer.weights {
+			if math.Abs(v-expected.weights[i]) > 0.0000001 {
+				t.Errorf("Expected weights: \n%v\n but found: \n%v\n", expected.weights, transformer.weights)
+				break
+			}
+		}
+
+		// a subsequent call to Fit should discard the accumulated statistics
+		transformer.Fit(input)
+		for i, v := range transformer.weights {
+			if v != expected.weights[i] {
+				t.Errorf("Expected weights after refit: \n%v\n but found: \n%v\n", expected.weights, transformer.weights)
+				break
+			}
+		}
+	}
+}
+
+func TestCountDocumentFrequencies(t *testing.T) {
+	mat := randomSparseTermDocMatrix(1001, 50, 20).ToDense()
+	m, _ := mat.Dims()
+
+	sequential := make([]float64, m)
+	countDocumentFrequencies(mat, sequential, 0, 1)
+
+	// include worker counts that do not evenly divide the rows and exceed the rows
+	for _, workers := range []int{2, 7, runtime.NumCPU(), 2000} {
+		parallel := make([]float64, m)
+		countDocumentFrequencies(mat, parallel, 0, workers)
+
+		for i := range sequential {
+			if parallel[i] != sequential[i] {
+				t.Errorf("Expected df %f for term %d with %d workers but found %f",
+					sequential[i], i, workers, parallel[i])
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerUpdate(t *testing.T) {
+	// term 0 is common in the old batch and rare in the new, term 1 the reverse
+	old := mat64.NewDense(3, 4, []float64{
+		1, 1, 1, 0,
+		0, 0, 0, 1,
+		1, 0, 1, 0,
+	})
+	batch := mat64.NewDense(3, 4, []float64{
+		0, 0, 0, 1,
+		1, 1, 1, 0,
+		1, 0, 1, 0,
+	})
+
+	fitted := func(mat mat64.Matrix) []float64 {
+		transformer := NewTfidfTransformer()
+		transformer.Fit(mat)
+		return transformer.Weights()
+	}
+	oldWeights, batchWeights := fitted(old), fitted(batch)
+
+	prev := oldWeights[0]
+	for _, decay := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		var current float64
+		for _, mat := range []mat64.Matrix{batch, NewSparseMatrixFrom(batch)} {
+			transformer := NewTfidfTransformer()
+			transformer.Fit(old)
+			if err := transformer.Update(mat, decay); err != nil {
+				t.Fatalf("Failed to update transformer caused by %v", err)
+			}
+			weights := transformer.Weights()
+
+			switch decay {
+			case 0:
+				for i := range weights {
+					if math.Abs(weights[i]-oldWeights[i]) > 0.0000001 {
+						t.Errorf("Expected weights to ignore new batch with decay 0: %v but found %v", oldWeights, weights)
+						break
+					}
+				}
+			case 1:
+				for i := range weights {
+					if math.Abs(weights[i]-batchWeights[i]) > 0.0000001 {
+						t.Errorf("Expected weights to ignore history with decay 1: %v but found %v", batchWeights, weights)
+						break
+					}
+				}
+			}
+
+			// term 0 becomes rarer (so its weight increases) as decay increases
+			if weights[0] < prev-0.0000001 {
+				t.Errorf("Expected weight of term 0 to increase with decay %f but %f < %f", decay, weights[0], prev)
+			}
+			// term 2 occurs equally often in both so is unaffected
+			if math.Abs(weights[2]-oldWeights[2]) > 0.0000001 {
+				t.Errorf("Expected weight of term 2 to be unaffected by decay %f but found %f", decay, weights[2])
+			}
+			current = weights[0]
+		}
+		prev = current
+	}
+
+	transformer := NewTfidfTransformer()
+	transformer.Fit(old)
+	if err := transformer.Update(batch, 1.5); err == nil {
+		t.Errorf("Expected error updating with decay outside 0 to 1 but found none")
+	}
+
+	// updating an unfitted transformer is equivalent to fitting
+	transformer = NewTfidfTransformer()
+	if err := transformer.Update(batch, 0.5); err != nil {
+		t.Fatalf("Failed to update transformer caused by %v", err)
+	}
+	for i, v := range transformer.Weights() {
+		if v != batchWeights[i] {
+			t.Errorf("Expected weights %v updating unfitted transformer but found %v", batchWeights, transformer.Weights())
+			break
+		}
+	}
+}
+
+func TestTfidfTransformerSubset(t *testing.T) {
+	mat := randomSparseTermDocMatrix(20, 10, 5).ToDense()
+	transformer := NewTfidfTransformer()
+	transformer.SublinearTF = true
+	transformer.Fit(mat)
+	expected, _ := transformer.Transform(mat)
+
+	indices := []int{5, 2, 19, 0}
+	subset, err := transformer.Subset(indices)
+	if err != nil {
+		t.Fatalf("Failed to subset transformer caused by %v", err)
+	}
+
+	// build the reduced input and output matrices by selecting the rows
+	_, n := mat.Dims()
+	input := mat64.NewDense(len(indices), n, nil)
+	selected := mat64.NewDense(len(indices), n, nil)
+	for s, i := range indices {
+		input.SetRow(s, mat.RawRowView(i))
+		selected.SetRow(s, expected.RawRowView(i))
+	}
+
+	result, err := subset.Transform(input)
+	if err != nil {
+		t.Fatalf("Failed to transform with subset transformer caused by %v", err)
+	}
+	if !mat64.EqualApprox(selected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(selected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+	if !subset.SublinearTF || subset.Documents() != transformer.Documents() {
+		t.Errorf("Expected configuration and statistics to be copied to subset transformer")
+	}
+
+	// the original transformer should be unaffected
+	if len(transformer.Weights()) != 20 {
+		t.Errorf("Expected original transformer to retain 20 weights but found %d", len(transformer.Weights()))
+	}
+
+	for _, invalid := range [][]int{{0, 20}, {-1}, {3, 1, 3}} {
+		if _, err := transformer.Subset(invalid); err == nil {
+			t.Errorf("Expected error subsetting with indices %v but found none", invalid)
+		}
+	}
+	if _, err := NewTfidfTransformer().Subset(indices); err == nil {
+		t.Errorf("Expected error subsetting unfitted transformer but found none")
+	}
+}
+
+func TestTfidfTransformerReindex(t *testing.T) {
+	mat := randomSparseTermDocMatrix(6, 10, 3).ToDense()
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	transformer.Fit(mat)
+
+	// move row i of the original ordering to row mapping[i]
+	mapping := []int{3, 0, 5, 1, 4, 2}
+	m, n := mat.Dims()
+	reordered := mat64.NewDense(m, n, nil)
+	for i, r := range mapping {
+		reordered.SetRow(r, mat.RawRowView(i))
+	}
+
+	refitted := NewTfidfTransformer()
+	refitted.Norm = L2Norm
+	expected, _ := refitted.FitTransform(reordered)
+
+	reindexed, err := transformer.Reindex(mapping)
+	if err != nil {
+		t.Fatalf("Failed to reindex transformer caused by %v", err)
+	}
+	if !reflect.DeepEqual(reindexed.Weights(), refitted.Weights()) {
+		t.Errorf("Expected weights %v but found %v", refitted.Weights(), reindexed.Weights())
+	}
+	result, err := reindexed.Transform(reordered)
+	if err != nil {
+		t.Fatalf("Failed to transform with reindexed transformer caused by %v", err)
+	}
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	var tests = []struct {
+		mapping  []int
+		expected error
+	}{
+		{mapping: []int{0, 1, 2}, expected: ErrDimensionMismatch},
+		{mapping: []int{0, 1, 2, 3, 4, 6}, expected: ErrInvalidArgument},
+		{mapping: []int{0, 1, 2, 3, 4, -1}, expected: ErrInvalidArgument},
+		{mapping: []int{0, 1, 2, 3, 4, 4}, expected: ErrInvalidArgument},
+	}
+	for _, test := range tests {
+		if _, err := transformer.Reindex(test.mapping); !errors.Is(err, test.expected) {
+			t.Errorf("Expected error wrapping '%v' reindexing with %v but found '%v'", test.expected, test.mapping, err)
+		}
+	}
+	if _, err := NewTfidfTransformer().Reindex(mapping); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+}
+
+func TestScikitCompatibleTfidf(t *testing.T) {
+	// term counts and output of TfidfTransformer with default options, as published in the
+	// scikit-learn user guide, transposed so that documents are columns
+	counts := mat64.NewDense(3, 6, []float64{
+		3, 2, 3, 4, 3, 3,
+		0, 0, 0, 0, 2, 0,
+		1, 0, 0, 0, 0, 2,
+	})
+	expected := mat64.NewDense(3, 6, []float64{
+		0.85151335, 1, 1, 1, 0.55422893, 0.63035731,
+		0, 0, 0, 0, 0.83236428, 0,
+		0.52433293, 0, 0, 0, 0, 0.77630514,
+	})
+
+	transformer := NewScikitCompatibleTfidf()
+	result, err := transformer.FitTransform(counts)
+	if err != nil {
+		t.Fatalf("Failed tfidf transform caused by %v", err)
+	}
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+}
+
+func TestTfidfTransformerFitFromDocFreq(t *testing.T) {
+	mat := randomSparseTermDocMatrix(30, 12, 6)
+	m, n := mat.Dims()
+
+	df := make([]int, m)
+	mat.DoNonZero(func(i, j int, v float64) {
+		df[i]++
+	})
+
+	for _, smooth := range []bool{true, false} {
+		expected := NewTfidfTransformer()
+		expected.Smooth = smooth
+		expected.Fit(mat)
+
+		transformer := NewTfidfTransformer()
+		transformer.Smooth = smooth
+		transformer.FitFromDocFreq(df, n)
+
+		if !reflect.DeepEqual(expected.Weights(), transformer.Weights()) {
+			t.Errorf("Smooth %t: Expected weights %v but found %v", smooth, expected.Weights(), transformer.Weights())
+		}
+		if transformer.Documents() != n {
+			t.Errorf("Smooth %t: Expected %d documents but found %d", smooth, n, transformer.Documents())
+		}
+
+		// further batches accumulate as though fitted with Fit()
+		expected.PartialFit(mat)
+		transformer.PartialFit(mat)
+		if !reflect.DeepEqual(expected.Weights(), transformer.Weights()) {
+			t.Errorf("Smooth %t: Expected weights %v following PartialFit() but found %v",
+				smooth, expected.Weights(), transformer.Weights())
+		}
+	}
+
+	var invalid = []struct {
+		df []int
+		n  int
+	}{
+		{[]int{1, 2}, 0},
+		{[]int{1, 2}, -3},
+		{[]int{1, -1}, 4},
+		{[]int{1, 5}, 4},
+	}
+	for _, test := range invalid {
+		transformer := NewTfidfTransformer()
+		transformer.FitFromDocFreq(df, n)
+		transformer.FitFromDocFreq(test.df, test.n)
+		if weights := transformer.Weights(); weights != nil {
+			t.Errorf("df %v, n %d: Expected transformer to be left unfitted but found weights %v", test.df, test.n, weights)
+		}
+		if _, err := transformer.Transform(mat64.NewDense(2, 1, []float64{1, 1})); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("df %v, n %d: Expected error wrapping '%v' but found '%v'", test.df, test.n, ErrInvalidArgument, err)
+		}
+	}
+}
+
+func TestTfidfTransformerConstantTerms(t *testing.T) {
+	corpus := []string{
+		"the quick brown fox",
+		"the lazy dog",
+		"the fox and the dog",
+	}
+	vectoriser := NewCountVectoriser(false)
+	mat, _ := vectoriser.FitTransform(corpus...)
+
+	transformer := NewTfidfTransformer()
+	if constant := transformer.ConstantTerms(); constant != nil {
+		t.Errorf("Expected nil constant terms before fitting but found %v", constant)
+	}
+
+	transformer.Fit(mat)
+	expected := []int{vectoriser.Vocabulary["the"]}
+	if constant := transformer.ConstantTerms(); !reflect.DeepEqual(expected, constant) {
+		t.Errorf("Expected constant terms %v but found %v", expected, constant)
+	}
+
+	// a further batch without "the" means it no longer occurs in every document
+	more, _ := vectoriser.Transform("quick dog")
+	transformer.PartialFit(more)
+	if constant := transformer.ConstantTerms(); len(constant) != 0 || constant == nil {
+		t.Errorf("Expected no constant terms but found %v", constant)
+	}
+}
+
+func TestTfidfTransformerFitWeighted(t *testing.T) {
+	mat := mat64.NewDense(4, 3, []float64{
+		1, 0, 2,
+		0, 3, 1,
+		1, 1, 0,
+		0, 0, 4,
+	})
+	// the same corpus with the first column duplicated and the last column triplicated
+	duplicated := mat64.NewDense(4, 6, []float64{
+		1, 1, 0, 2, 2, 2,
+		0, 0, 3, 1, 1, 1,
+		1, 1, 1, 0, 0, 0,
+		0, 0, 0, 4, 4, 4,
+	})
+
+	for _, input := range []mat64.Matrix{mat, NewSparseMatrixFrom(mat)} {
+		expected := NewTfidfTransformer()
+		expected.Fit(duplicated)
+
+		transformer := NewTfidfTransformer()
+		transformer.FitWeighted(input, []float64{2, 1, 3})
+		expectedWeights, weights := expected.Weights(), transformer.Weights()
+		if len(weights) != len(expectedWeights) {
+			t.Fatalf("Expected weights %v but found %v", expectedWeights, weights)
+		}
+		for i := range weights {
+			if math.Abs(weights[i]-expectedWeights[i]) > 0.000001 {
+				t.Errorf("Expected weights %v but found %v", expectedWeights, weights)
+				break
+			}
+		}
+	}
+
+	// unit weights are equivalent to Fit()
+	expected := NewTfidfTransformer()
+	expected.Fit(mat)
+	transformer := NewTfidfTransformer()
+	transformer.FitWeighted(mat, []float64{1, 1, 1})
+	if !reflect.DeepEqual(expected.Weights(), transformer.Weights()) {
+		t.Errorf("Expected weights %v but found %v", expected.Weights(), transformer.Weights())
+	}
+
+	var invalid = []struct {
+		mat        mat64.Matrix
+		docWeights []float64
+		expected   error
+	}{
+		{mat, []float64{1, 1}, ErrDimensionMismatch},
+		{mat, nil, ErrDimensionMismatch},
+		{mat, []float64{1, -1, 1}, ErrInvalidArgument},
+		{NewSparseMatrix(3, 0, nil, nil, nil), nil, ErrEmptyCorpus},
+		{NewSparseMatrix(0, 2, nil, nil, nil), []float64{1, 1}, ErrEmptyVocabulary},
+	}
+	for _, test := range invalid {
+		transformer.FitWeighted(test.mat, test.docWeights)
+		if transformer.Weights() != nil {
+			t.Errorf("Expected transformer to be unfitted for document weights %v but found weights %v", test.docWeights, transformer.Weights())
+		}
+		if _, err := transformer.Transform(mat); !errors.Is(err, test.expected) {
+			t.Errorf("Expected error wrapping '%v' for document weights %v but found '%v'", test.expected, test.docWeights, err)
+		}
+	}
+}
+
+func TestTfidfTransformerFreezeTerms(t *testing.T) {
+	first := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 3, 1, 1,
+		1, 1, 0, 0,
+	})
+	second := mat64.NewDense(3, 2, []float64{
+		1, 1,
+		0, 2,
+		1, 0,
+	})
+
+	transformer, _ := NewTfidfTransformerWithWeights([]float64{5, 0.5, 1})
+	if err := transformer.FreezeTerms([]int{0, 2}); err != nil {
+		t.Fatalf("Failed to freeze terms caused by %v", err)
+	}
+
+	for _, mat := range []mat64.Matrix{first, second} {
+		transformer.Fit(mat)
+		expected := NewTfidfTransformer().Fit(mat).(*TfidfTransformer).Weights()
+		expected[0], expected[2] = 5, 1
+
+		if weights := transformer.Weights(); !reflect.DeepEqual(expected, weights) {
+			t.Errorf("Expected weights %v but found %v", expected, weights)
+		}
+	}
+
+	// frozen terms survive cloning and encoding but are remapped by Subset()
+	clone := transformer.Clone().(*TfidfTransformer)
+	data, _ := transformer.MarshalBinary()
+	restored := NewTfidfTransformer()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to decode transformer caused by %v", err)
+	}
+	subset, _ := transformer.Subset([]int{2, 1})
+	for _, test := range []struct {
+		transformer *TfidfTransformer
+		frozen      int
+		weight      float64
+	}{
+		{clone, 0, 5},
+		{restored, 0, 5},
+		{subset, 0, 1},
+	} {
+		test.transformer.Fit(first)
+		if w := test.transformer.Weights()[test.frozen]; w != test.weight {
+			t.Errorf("Expected frozen weight %f but found %f", test.weight, w)
+		}
+	}
+
+	transformer.UnfreezeTerms()
+	transformer.Fit(first)
+	if expected := NewTfidfTransformer().Fit(first).(*TfidfTransformer).Weights(); !reflect.DeepEqual(expected, transformer.Weights()) {
+		t.Errorf("Expected weights %v following UnfreezeTerms() but found %v", expected, transformer.Weights())
+	}
+
+	if err := transformer.FreezeTerms([]int{1, 3}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+	if err := NewTfidfTransformer().FreezeTerms([]int{0}); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+}
+
+func TestTfidfTransformerMerge(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	expected := NewTfidfTransformer()
+	expected.Fit(input)
+
+	first := NewTfidfTransformer()
+	first.Fit(input.Slice(0, 6, 0, 1))
+	second := NewTfidfTransformer()
+	second.Fit(input.Slice(0, 6, 1, 4))
+
+	if err := first.Merge(second); err != nil {
+		t.Fatalf("Failed to merge transformers caused by %v", err)
+	}
+
+	if first.Documents() != expected.Documents() {
+		t.Errorf("Expected %d documents but found %d", expected.Documents(), first.Documents())
+	}
+	for i, v := range first.weights {
+		if math.Abs(v-expected.weights[i]) > 0.0000001 {
+			t.Errorf("Expected weights: \n%v\n but found: \n%v\n", expected.weights, first.weights)
+			break
+		}
+	}
+
+	mismatched := NewTfidfTransformer()
+	mismatched.Fit(input.Slice(0, 5, 0, 4))
+	if err := first.Merge(mismatched); err == nil {
+		t.Errorf("Expected error merging transformers with different numbers of terms but found none")
+	}
+
+	if err := first.Merge(NewTfidfTransformer()); err == nil {
+		t.Errorf("Expected error merging unfitted transformer but found none")
+	}
+	precomputed, _ := NewTfidfTransformerWithWeights(expected.Weights())
+	if err := precomputed.Merge(first); err == nil {
+		t.Errorf("Expected error merging into transformer with precomputed weights but found none")
+	}
+
+	// failed merges should leave the statistics unchanged
+	if first.Documents() != expected.Documents() {
+		t.Errorf("Expected %d documents after failed merges but found %d", expected.Documents(), first.Documents())
+	}
+}
+
+func TestNewTfidfTransformerWithWeights(t *testing.T) {
+	weights := []float64{0, 0.5, 2}
+	input := mat64.NewDense(3, 2, []float64{
+		1, 2,
+		3, 0,
+		1, 1,
+	})
+	expected := mat64.NewDense(3, 2, []float64{
+		0, 0,
+		1.5, 0,
+		2, 2,
+	})
+
+	transformer, err := NewTfidfTransformerWithWeights(weights)
+	if err != nil {
+		t.Fatalf("Failed to create transformer caused by %v", err)
+	}
+
+	// mutating the supplied slice must not affect the transformer
+	weights[2] = 100
+
+	result, err := transformer.Transform(input)
+	if err != nil {
+		t.Fatalf("Failed tfidf transform caused by %v", err)
+	}
+
+	if !mat64.Equal(expected, result) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	if _, err := NewTfidfTransformerWithWeights(nil); err == nil {
+		t.Errorf("Expected error creating transformer with no weights but found none")
+	}
+}
+
+func TestTfidfTransformerIdfVariants(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 2,
+		0, 0, 0,
+		4, 5, 6,
+	})
+
+	var tests = []struct {
+		smooth  bool
+		offset  bool
+		weights []float64
+	}{
+		{smooth: true, offset: false, weights: []float64{0.28768207245178085, 1.3862943611198906, 0}},
+		{smooth: true, offset: true, weights: []float64{1.2876820724517808, 2.386294361119891, 1}},
+		{smooth: false, offset: false, weights: []float64{0.4054651081081644, 0, 0}},
+		{smooth: false, offset: true, weights: []float64{1.4054651081081644, 1, 1}},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		transformer.Smooth = test.smooth
+		transformer.OffsetIdf = test.offset
+
+		transformer.Fit(input)
+
+		for i, v := range transformer.weights {
+			if math.Abs(v-test.weights[i]) > 0.0000001 {
+				t.Errorf("Smooth: %t, OffsetIdf: %t - Expected weights: \n%v\n but found: \n%v\n",
+					test.smooth, test.offset, test.weights, transformer.weights)
+				break
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerLogBase(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 2,
+		0, 0, 0,
+		4, 5, 6,
+	})
+
+	natural := NewTfidfTransformer()
+	natural.Fit(input)
+
+	var tests = []struct {
+		base   float64
+		offset bool
+	}{
+		{base: math.E},
+		{base: 2},
+		{base: 10},
+		{base: 2, offset: true},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		transformer.LogBase = test.base
+		transformer.OffsetIdf = test.offset
+		transformer.Fit(input)
+
+		for i, v := range transformer.weights {
+			expected := natural.weights[i] / math.Log(test.base)
+			if test.offset {
+				expected++
+			}
+			if math.Abs(v-expected) > 0.0000001 {
+				t.Errorf("LogBase: %f, OffsetIdf: %t - Expected weight %f for term %d but found %f",
+					test.base, test.offset, expected, i, v)
+			}
+		}
+	}
+
+	// log2((1+3)/(1+0)) = 2 for a term occuring in none of the 3 documents
+	transformer := NewTfidfTransformer()
+	transformer.LogBase = 2
+	transformer.Fit(input)
+	if math.Abs(transformer.weights[1]-2) > 0.0000001 {
+		t.Errorf("Expected base 2 weight of 2 but found %f", transformer.weights[1])
+	}
+
+	// invalid bases should leave the transformer unfitted
+	for _, base := range []float64{1, -2, math.Inf(1), math.NaN()} {
+		transformer := NewTfidfTransformer()
+		transformer.LogBase = base
+		transformer.Fit(input)
+		if transformer.Weights() != nil {
+			t.Errorf("LogBase: %f - Expected transformer to be unfitted but found weights %v", base, transformer.Weights())
+		}
+		if _, err := transformer.Transform(input); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("LogBase: %f - Expected error wrapping '%v' but found '%v'", base, ErrInvalidArgument, err)
+		}
+	}
+}
+
+func TestTfidfTransformerMaxIDF(t *testing.T) {
+	// term 0 is a hapax (occurs in a single document), term 1 occurs in every document
+	input := mat64.NewDense(2, 9, []float64{
+		1, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 1, 1, 1, 1, 1,
+	})
+
+	uncapped := NewTfidfTransformer()
+	uncapped.Fit(input)
+
+	limit := 1.0
+	if uncapped.weights[0] <= limit {
+		t.Fatalf("Expected uncapped weight of hapax term to exceed %f but found %f", limit, uncapped.weights[0])
+	}
+
+	var tests = []struct {
+		maxIDF   float64
+		expected []float64
+	}{
+		{maxIDF: 0, expected: uncapped.weights},
+		{maxIDF: math.Inf(1), expected: uncapped.weights},
+		{maxIDF: limit, expected: []float64{limit, uncapped.weights[1]}},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		transformer.MaxIDF = test.maxIDF
+		transformer.Fit(input)
+
+		for i, v := range transformer.weights {
+			if math.Abs(v-test.expected[i]) > 0.0000001 {
+				t.Errorf("MaxIDF: %f - Expected weight %f for term %d but found %f", test.maxIDF, test.expected[i], i, v)
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerCorpusSize(t *testing.T) {
+	// a sample of 4 documents from a corpus of 100
+	input := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 3, 1, 1,
+		1, 1, 1, 1,
+	})
+	df := []float64{2, 3, 4}
+
+	var tests = []struct {
+		corpusSize int
+		smooth     bool
+		n          float64
+	}{
+		{corpusSize: 0, smooth: true, n: 4},
+		{corpusSize: 100, smooth: true, n: 100},
+		{corpusSize: 100, smooth: false, n: 100},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		transformer.Smooth = test.smooth
+		transformer.CorpusSize = test.corpusSize
+		transformer.Fit(input)
+
+		for i, w := range transformer.Weights() {
+			expected := math.Log(test.n / df[i])
+			if test.smooth {
+				expected = math.Log((1 + test.n) / (1 + df[i]))
+			}
+			if math.Abs(w-expected) > 0.0000001 {
+				t.Errorf("CorpusSize: %d - Expected weight %f for term %d but found %f", test.corpusSize, expected, i, w)
+			}
+		}
+		if transformer.Documents() != 4 {
+			t.Errorf("CorpusSize: %d - Expected 4 documents but found %d", test.corpusSize, transformer.Documents())
+		}
+	}
+
+	// terms occuring in every sampled document are no longer ignored
+	transformer := NewTfidfTransformer()
+	sample := transformer.Fit(input).(*TfidfTransformer).Weights()
+	transformer.CorpusSize = 100
+	full := transformer.Fit(input).(*TfidfTransformer).Weights()
+	if sample[2] != 0 || full[2] <= 0 {
+		t.Errorf("Expected weight of ubiquitous term to be 0 for the sample and positive for the corpus but found %f and %f", sample[2], full[2])
+	}
+}
+
+func TestTfidfTransformerEpsilon(t *testing.T) {
+	// the noisy matrix is the clean matrix with near zero noise in place of some zeros
+	clean := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 0,
+		0, 3, 0, 0,
+		1, 1, 1, 1,
+	})
+	noisy := mat64.NewDense(3, 4, []float64{
+		1, 1e-18, 2, -1e-17,
+		2e-16, 3, 0, 0,
+		1, 1, 1, 1,
+	})
+
+	expected := NewTfidfTransformer().Fit(clean).(*TfidfTransformer).Weights()
+
+	var tests = []struct {
+		epsilon float64
+		equal   bool
+	}{
+		{epsilon: 0, equal: false},
+		{epsilon: 1e-9, equal: true},
+	}
+
+	for _, test := range tests {
+		for _, input := range []mat64.Matrix{noisy, NewSparseMatrixFrom(noisy)} {
+			transformer := NewTfidfTransformer()
+			transformer.Epsilon = test.epsilon
+			transformer.Fit(input)
+			if weights := transformer.Weights(); reflect.DeepEqual(expected, weights) != test.equal {
+				t.Errorf("Epsilon: %g - Expected weights %v (equal: %t) but found %v", test.epsilon, expected, test.equal, weights)
+			}
+
+			transformer.FitWeighted(input, []float64{1, 1, 1, 1})
+			if weights := transformer.Weights(); reflect.DeepEqual(expected, weights) != test.equal {
+				t.Errorf("Epsilon: %g - Expected weights %v (equal: %t) following FitWeighted() but found %v", test.epsilon, expected, test.equal, weights)
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerWeightFunc(t *testing.T) {
+	input := mat64.NewDense(3, 4, []float64{
+		1, 0, 2, 1,
+		0, 0, 0, 3,
+		4, 5, 6, 1,
+	})
+
+	// weights terms by 1 minus the (normalised) entropy of their distribution across
+	// documents so terms occuring in half the documents receive a weight of 0
+	entropy := func(df, n float64) float64 {
+		p := df / n
+		if p == 0 || p == 1 {
+			return 1
+		}
+		return 1 + p*math.Log2(p) + (1-p)*math.Log2(1-p)
+	}
+
+	transformer := NewTfidfTransformer()
+	transformer.WeightFunc = entropy
+	transformer.OffsetIdf = true
+	transformer.Fit(input)
+
+	// df of 3, 1 and 4 from 4 documents
+	expected := []float64{
+		1 + 0.75*math.Log2(0.75) + 0.25*math.Log2(0.25),
+		1 + 0.25*math.Log2(0.25) + 0.75*math.Log2(0.75),
+		1,
+	}
+	for i, v := range transformer.Weights() {
+		if math.Abs(v-expected[i]) > 0.0000001 {
+			t.Errorf("Expected weights: \n%v\n but found: \n%v\n", expected, transformer.Weights())
+			break
+		}
+	}
+
+	result, err := transformer.Transform(input)
+	if err != nil {
+		t.Fatalf("Failed tfidf transform caused by %v", err)
+	}
+	if math.Abs(result.At(2, 1)-5*expected[2]) > 0.0000001 {
+		t.Errorf("Expected weighted value %f but found %f", 5*expected[2], result.At(2, 1))
+	}
+
+	// the weight function applies to subsequent partial fits
+	transformer.PartialFit(input)
+	for i, v := range transformer.Weights() {
+		if math.Abs(v-expected[i]) > 0.0000001 {
+			t.Errorf("Expected weights after partial fit: \n%v\n but found: \n%v\n", expected, transformer.Weights())
+			break
+		}
+	}
+}
+
 func TestTfidfTransformerTransform(t *testing.T) {
 	var tests = []struct {
 		m      int
 		n      int
 		input  []float64
-		tm     int
-		tn     int
+		tm     int
+		tn     int
+		output []float64
+	}{
+		{
+			m: 6, n: 4,
+			input: []float64{
+				1, 3, 5, 2,
+				8, 1, 0, 0,
+				2, 1, 0, 1,
+				0, 0, 0, 0,
+				0, 0, 0, 1,
+				0, 1, 0, 0,
+			},
+			tm: 6, tn: 4,
+			output: []float64{
+				0.000, 0.000, 0.000, 0.000,
+				4.087, 0.511, 0.000, 0.000,
+				0.446, 0.223, 0.000, 0.223,
+				0.000, 0.000, 0.000, 0.000,
+				0.000, 0.000, 0.000, 0.916,
+				0.000, 0.916, 0.000, 0.000,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		input := mat64.NewDense(test.m, test.n, test.input)
+		output := mat64.NewDense(test.tm, test.tn, test.output)
+
+		result, err := transformer.FitTransform(input)
+
+		if err != nil {
+			t.Errorf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		if !mat64.EqualApprox(output, result, 0.001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(output),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+
+		// test that subsequent transforms produce same result as initial
+		result2, err := transformer.Transform(input)
+
+		if err != nil {
+			t.Errorf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		if !mat64.Equal(result, result2) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(result),
+				mat64.Formatted(result2))
+			t.Fail()
+		}
+	}
+}
+
+func TestTfidfTransformerNorm(t *testing.T) {
+	var tests = []struct {
+		m     int
+		n     int
+		input []float64
+		norm  NormType
+		p     float64
+	}{
+		{
+			m: 6, n: 4,
+			input: []float64{
+				1, 3, 5, 2,
+				8, 1, 0, 0,
+				2, 1, 0, 1,
+				0, 0, 0, 0,
+				0, 0, 0, 1,
+				0, 1, 0, 0,
+			},
+			norm: L2Norm,
+			p:    2,
+		},
+		{
+			m: 6, n: 4,
+			input: []float64{
+				1, 3, 5, 2,
+				8, 1, 0, 0,
+				2, 1, 0, 1,
+				0, 0, 0, 0,
+				0, 0, 0, 1,
+				0, 1, 0, 0,
+			},
+			norm: L1Norm,
+			p:    1,
+		},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		transformer.Norm = test.norm
+		input := mat64.NewDense(test.m, test.n, test.input)
+
+		result, err := transformer.FitTransform(input)
+
+		if err != nil {
+			t.Errorf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		for j := 0; j < test.n; j++ {
+			norm := mat64.Norm(result.ColView(j), test.p)
+
+			// the third column contains only the term occuring in every document (idf of 0)
+			// and so has a norm of zero which should be left unchanged rather than NaN
+			if j == 2 {
+				if norm != 0 {
+					t.Errorf("Expected zero vector for column %d but found norm %f", j, norm)
+				}
+				continue
+			}
+
+			if math.Abs(norm-1) > 0.000001 {
+				t.Errorf("Expected column %d to have unit norm but found %f", j, norm)
+			}
+		}
+
+		weighted := NewTfidfTransformer()
+		unnormalised, _ := weighted.FitTransform(input)
+		for j := 0; j < test.n; j++ {
+			for i := 0; i < test.m; i++ {
+				if unnormalised.At(i, j) != 0 && result.At(i, j) == 0 {
+					t.Errorf("Expected non zero value at (%d, %d) following normalisation", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerSublinearTF(t *testing.T) {
+	var tests = []struct {
+		m         int
+		n         int
+		input     []float64
+		sublinear bool
+		output    []float64
+	}{
+		{
+			m: 3, n: 3,
+			input: []float64{
+				1, 0, 4,
+				0, 2, 0,
+				3, 1, 0,
+			},
+			sublinear: false,
+			output: []float64{
+				0.288, 0.000, 1.151,
+				0.000, 1.386, 0.000,
+				0.863, 0.288, 0.000,
+			},
+		},
+		{
+			m: 3, n: 3,
+			input: []float64{
+				1, 0, 4,
+				0, 2, 0,
+				3, 1, 0,
+			},
+			sublinear: true,
+			output: []float64{
+				0.288, 0.000, 0.686,
+				0.000, 1.174, 0.000,
+				0.604, 0.288, 0.000,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		transformer := NewTfidfTransformer()
+		transformer.SublinearTF = test.sublinear
+		input := mat64.NewDense(test.m, test.n, test.input)
+		output := mat64.NewDense(test.m, test.n, test.output)
+
+		result, err := transformer.FitTransform(input)
+
+		if err != nil {
+			t.Errorf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		if !mat64.EqualApprox(output, result, 0.001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(output),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+}
+
+func TestTfidfTransformerTransformWithBoost(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+	boost := []float64{2, 1, 0.5}
+
+	transformer := NewTfidfTransformer()
+	transformer.Fit(input)
+	unboosted, _ := transformer.Transform(input)
+
+	result, err := transformer.TransformWithBoost(input, boost)
+	if err != nil {
+		t.Fatalf("Failed boosted tfidf transform caused by %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if math.Abs(result.At(i, j)-unboosted.At(i, j)*boost[i]) > 0.000001 {
+				t.Errorf("Expected %f at (%d, %d) but found %f", unboosted.At(i, j)*boost[i], i, j, result.At(i, j))
+			}
+		}
+	}
+
+	// boosting is applied before normalisation
+	transformer.Norm = L2Norm
+	result, _ = transformer.TransformWithBoost(input, boost)
+	for j := 0; j < 3; j++ {
+		if norm := mat64.Norm(result.ColView(j), 2); math.Abs(norm-1) > 0.000001 {
+			t.Errorf("Expected column %d to have unit norm but found %f", j, norm)
+		}
+	}
+
+	if _, err := transformer.TransformWithBoost(input, boost[:2]); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+	if _, err := transformer.TransformWithBoost(input, []float64{1, -1, 1}); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+	if !reflect.DeepEqual(transformer.Weights(), NewTfidfTransformer().Fit(input).(*TfidfTransformer).Weights()) {
+		t.Errorf("Expected transformer weights to be unaffected by boosting")
+	}
+}
+
+func TestTfidfTransformerTransformWithLengths(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+
+	transformer := NewTfidfTransformer()
+	transformer.Fit(input)
+	weights := transformer.Weights()
+
+	// the matrix is a truncated representation of longer documents
+	lengths := []float64{8, 6, 0}
+	expected := mat64.NewDense(3, 3, []float64{
+		weights[0] / 8, 0, 0,
+		0, weights[1] * 2 / 6, 0,
+		weights[2] * 3 / 8, weights[2] / 6, 0,
+	})
+
+	for _, mat := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+		result, err := transformer.TransformWithLengths(mat, lengths)
+		if err != nil {
+			t.Fatalf("Failed tfidf transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(expected, result, 0.000001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+
+	if _, err := transformer.TransformWithLengths(input, []float64{8, 6}); err == nil {
+		t.Errorf("Expected error for mismatched number of document lengths but found none")
+	}
+	if _, err := transformer.TransformWithLengths(input, []float64{8, -1, 2}); err == nil {
+		t.Errorf("Expected error for negative document length but found none")
+	}
+}
+
+func TestTfidfTransformerTransform32(t *testing.T) {
+	mat := randomSparseTermDocMatrix(200, 50, 20)
+
+	for _, norm := range []NormType{NoNorm, L1Norm, L2Norm} {
+		transformer := NewTfidfTransformer()
+		transformer.Norm = norm
+		transformer.SublinearTF = true
+		transformer.Fit(mat)
+
+		expected, err := transformer.Transform(mat)
+		if err != nil {
+			t.Fatalf("Failed tfidf transform caused by %v", err)
+		}
+
+		inputs := []struct {
+			name  string
+			input mat64.Matrix
+		}{
+			{"Sparse", mat},
+			{"Dense", mat.ToDense()},
+			{"Dense32", NewDense32From(mat)},
+		}
+		for _, input := range inputs {
+			result, err := transformer.Transform32(input.input)
+			if err != nil {
+				t.Fatalf("%s: Failed float32 tfidf transform caused by %v", input.name, err)
+			}
+			if !mat64.EqualApprox(expected, result, 0.00001) {
+				t.Errorf("Norm %d, %s: Expected float32 output to match float64 output within tolerance", norm, input.name)
+			}
+		}
+	}
+
+	if _, err := NewTfidfTransformer().Transform32(mat); err == nil {
+		t.Errorf("Expected error transforming with unfitted transformer but found none")
+	}
+}
+
+func TestTfidfTransformerTransformQuery(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+	query := mat64.NewDense(3, 1, []float64{2, 0, 1})
+
+	transformer := NewTfidfTransformer()
+	if _, err := transformer.TransformQuery(map[int]float64{0: 1}); err == nil {
+		t.Errorf("Expected error transforming query with unfitted transformer but found none")
+	}
+
+	transformer.Norm = L2Norm
+	transformer.Fit(input)
+	expected, _ := transformer.Transform(query)
+
+	// term 7 is unknown and term -1 is invalid so both should be dropped
+	result, err := transformer.TransformQuery(map[int]float64{0: 2, 2: 1, 7: 5, -1: 3})
+	if err != nil {
+		t.Fatalf("Failed tfidf query transform caused by %v", err)
+	}
+
+	if result.Len() != 3 {
+		t.Fatalf("Expected query vector of length 3 but found %d", result.Len())
+	}
+	if !mat64.EqualApprox(expected.ColView(0), result, 0.000001) {
+		t.Logf("Expected vector: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected.ColView(0)),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+}
+
+func TestTfidfTransformerInverseTransform(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+
+	for _, sublinear := range []bool{false, true} {
+		transformer := NewTfidfTransformer()
+		transformer.SublinearTF = sublinear
+
+		weighted, err := transformer.FitTransform(input)
+		if err != nil {
+			t.Fatalf("Failed tfidf fit transform caused by %v", err)
+		}
+
+		result, err := transformer.InverseTransform(weighted)
+		if err != nil {
+			t.Fatalf("Failed tfidf inverse transform caused by %v", err)
+		}
+
+		if !mat64.EqualApprox(input, result, 0.000001) {
+			t.Logf("Expected matrix (sublinear: %t): \n%v\n but found: \n%v\n",
+				sublinear,
+				mat64.Formatted(input),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+	}
+
+	if _, err := NewTfidfTransformer().InverseTransform(input); err == nil {
+		t.Errorf("Expected error inverse transforming with unfitted transformer but found none")
+	}
+}
+
+func TestTfidfTransformerTransformSparse(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+
+	for _, norm := range []NormType{NoNorm, L1Norm, L2Norm} {
+		for _, mat := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+			transformer := NewTfidfTransformer()
+			transformer.Norm = norm
+			transformer.SublinearTF = true
+			transformer.Fit(mat)
+
+			expected, err := transformer.Transform(input)
+			if err != nil {
+				t.Errorf("Failed tfidf transform caused by %v", err)
+			}
+
+			dense, err := transformer.Transform(mat)
+			if err != nil {
+				t.Errorf("Failed tfidf transform caused by %v", err)
+			}
+
+			sparse, err := transformer.TransformSparse(mat)
+			if err != nil {
+				t.Errorf("Failed sparse tfidf transform caused by %v", err)
+			}
+
+			if !mat64.EqualApprox(expected, dense, 0.000001) {
+				t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+					mat64.Formatted(expected),
+					mat64.Formatted(dense))
+				t.Fail()
+			}
+
+			if !mat64.EqualApprox(expected, sparse, 0.000001) {
+				t.Logf("Expected sparse matrix: \n%v\n but found: \n%v\n",
+					mat64.Formatted(expected),
+					mat64.Formatted(sparse))
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestTfidfTransformerTransformSparseInto(t *testing.T) {
+	train := randomSparseTermDocMatrix(50, 20, 10)
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	transformer.Fit(train)
+
+	// reusing the destination for a larger then smaller matrix should match TransformSparse()
+	dst := &SparseMatrix{}
+	for _, mat := range []mat64.Matrix{train, randomSparseTermDocMatrix(50, 3, 5), train.ToDense()} {
+		expected, _ := transformer.TransformSparse(mat)
+		if err := transformer.TransformSparseInto(dst, mat); err != nil {
+			t.Fatalf("Failed sparse tfidf transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(expected, dst, 0.000001) || dst.NNZ() != expected.NNZ() {
+			t.Logf("Expected sparse matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(expected),
+				mat64.Formatted(dst))
+			t.Fail()
+		}
+	}
+
+	// once grown, the destination storage should be reused without allocating
+	query := randomSparseTermDocMatrix(50, 1, 5)
+	transformer.TransformSparseInto(dst, query)
+	if allocs := testing.AllocsPerRun(10, func() {
+		transformer.TransformSparseInto(dst, query)
+	}); allocs != 0 {
+		t.Errorf("Expected no allocations reusing destination but found %f", allocs)
+	}
+
+	if err := transformer.TransformSparseInto(query, query); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+	if err := transformer.TransformSparseInto(dst, mat64.NewDense(3, 1, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+}
+
+func TestTfidfTransformerTransformInPlace(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+
+	for _, norm := range []NormType{NoNorm, L1Norm, L2Norm} {
+		transformer := NewTfidfTransformer()
+		transformer.Norm = norm
+		transformer.SublinearTF = true
+		transformer.Fit(input)
+		expected, err := transformer.Transform(input)
+		if err != nil {
+			t.Fatalf("Failed tfidf transform caused by %v", err)
+		}
+
+		var dst mat64.Dense
+		dst.Clone(input)
+		if err := transformer.TransformInPlace(&dst); err != nil {
+			t.Errorf("Failed in place tfidf transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(expected, &dst, 0.000001) {
+			t.Logf("Norm %v: Expected matrix: \n%v\n but found: \n%v\n",
+				norm,
+				mat64.Formatted(expected),
+				mat64.Formatted(&dst))
+			t.Fail()
+		}
+	}
+
+	transformer := NewTfidfTransformer()
+	transformer.Fit(input)
+	dst := mat64.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	if err := transformer.TransformInPlace(dst); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+	if dst.At(0, 0) != 1 {
+		t.Errorf("Expected matrix to be unmodified on error but found: \n%v\n", mat64.Formatted(dst))
+	}
+}
+
+func TestTfidfTransformerTransformInto(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	expected, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Fatalf("Failed tfidf fit transform caused by %v", err)
+	}
+
+	// reused destination containing values from a previous call
+	dst := mat64.NewDense(3, 3, nil)
+	for _, src := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+		dst.Apply(func(i, j int, v float64) float64 { return 9 }, dst)
+
+		if err := transformer.TransformInto(dst, src); err != nil {
+			t.Errorf("Failed tfidf transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(expected, dst, 0.000001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(expected),
+				mat64.Formatted(dst))
+			t.Fail()
+		}
+	}
+
+	if err := transformer.TransformInto(mat64.NewDense(3, 2, nil), input); err == nil {
+		t.Errorf("Expected error transforming into destination with mismatched dimensions but found none")
+	}
+
+	// in place
+	inPlace := mat64.DenseCopyOf(input)
+	if err := transformer.TransformInto(inPlace, inPlace); err != nil {
+		t.Errorf("Failed tfidf transform caused by %v", err)
+	}
+	if !mat64.EqualApprox(expected, inPlace, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(inPlace))
+		t.Fail()
+	}
+}
+
+func TestTfidfTransformerNonFinite(t *testing.T) {
+	train := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 2, 0,
+		3, 1, 0,
+	})
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, math.NaN(), 0,
+		3, 1, math.Inf(1),
+	})
+	// the same matrix with the non finite values replaced by 0
+	zeroed := mat64.NewDense(3, 3, []float64{
+		1, 0, 4,
+		0, 0, 0,
+		3, 1, 0,
+	})
+
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	transformer.Fit(train)
+
+	// by default non finite values propagate
+	result, err := transformer.Transform(input)
+	if err != nil {
+		t.Errorf("Failed tfidf transform caused by %v", err)
+	} else if !math.IsNaN(result.At(1, 1)) {
+		t.Errorf("Expected NaN to propagate but found %f", result.At(1, 1))
+	}
+
+	transformer.NonFinite = RejectNonFinite
+	for _, mat := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+		_, err := transformer.Transform(mat)
+		if err == nil || !strings.Contains(err.Error(), "(1, 1)") {
+			t.Errorf("Expected error identifying non finite value at (1, 1) but found %v", err)
+		}
+		_, err = transformer.TransformSparse(mat)
+		if err == nil || !strings.Contains(err.Error(), "(1, 1)") {
+			t.Errorf("Expected error identifying non finite value at (1, 1) but found %v", err)
+		}
+	}
+	if _, err := transformer.Transform(zeroed); err != nil {
+		t.Errorf("Expected no error transforming finite matrix but found %v", err)
+	}
+
+	transformer.NonFinite = ZeroNonFinite
+	expected, _ := transformer.Transform(zeroed)
+	for _, mat := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+		result, err := transformer.Transform(mat)
+		if err != nil {
+			t.Errorf("Failed tfidf transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(expected, result, 0.000001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(expected),
+				mat64.Formatted(result))
+			t.Fail()
+		}
+
+		sparse, err := transformer.TransformSparse(mat)
+		if err != nil {
+			t.Errorf("Failed tfidf sparse transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(expected, sparse, 0.000001) {
+			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+				mat64.Formatted(expected),
+				mat64.Formatted(sparse))
+			t.Fail()
+		}
+	}
+}
+
+func TestTfidfTransformerConcurrentTransform(t *testing.T) {
+	train := randomSparseTermDocMatrix(500, 50, 20)
+	input := randomSparseTermDocMatrix(500, 20, 20)
+	dense := input.ToDense()
+
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	transformer.SublinearTF = true
+	transformer.Fit(train)
+
+	expected, err := transformer.Transform(input)
+	if err != nil {
+		t.Fatalf("Failed tfidf transform caused by %v", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*mat64.Dense, 32)
+	errs := make([]error, len(results))
+	for g := range results {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			switch g % 3 {
+			case 0:
+				results[g], errs[g] = transformer.Transform(input)
+			case 1:
+				results[g], errs[g] = transformer.Transform(dense)
+			case 2:
+				var sparse *SparseMatrix
+				sparse, errs[g] = transformer.TransformSparse(input)
+				if errs[g] == nil {
+					results[g] = sparse.ToDense()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for g, result := range results {
+		if errs[g] != nil {
+			t.Errorf("Failed concurrent tfidf transform caused by %v", errs[g])
+			continue
+		}
+		if !mat64.EqualApprox(expected, result, 0.000000001) {
+			t.Errorf("Expected identical results from concurrent transform %d", g)
+		}
+	}
+}
+
+func TestTfidfTransformerExplainDocument(t *testing.T) {
+	vocabulary := []string{"the", "fox", "dog", "aardvark"}
+	mat := mat64.NewDense(4, 4, []float64{
+		3, 2, 4, 1,
+		1, 1, 0, 0,
+		0, 1, 1, 0,
+		1, 0, 0, 0,
+	})
+
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	transformer.Fit(mat)
+	weighted, _ := transformer.Transform(mat)
+
+	var tests = []struct {
+		col      int
+		k        int
+		expected []string
+	}{
+		// the rare term dominates despite occuring less often than "the"
+		{col: 0, k: 1, expected: []string{"aardvark"}},
+		{col: 0, k: 10, expected: []string{"aardvark", "fox"}},
+		// "the" occurs in every document and so has a weight of 0
+		{col: 2, k: 10, expected: []string{"dog"}},
+		{col: 3, k: 10, expected: []string{}},
+		{col: 0, k: 0, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		terms := transformer.ExplainDocument(test.col, mat, vocabulary, test.k)
+		if len(terms) != len(test.expected) {
+			t.Errorf("Expected %d terms for document %d but found %v", len(test.expected), test.col, terms)
+			continue
+		}
+		for i, term := range terms {
+			if term.Term != test.expected[i] {
+				t.Errorf("Expected term '%s' at rank %d for document %d but found %v", test.expected[i], i, test.col, terms)
+			}
+			row := indexOf(vocabulary, term.Term)
+			if math.Abs(term.Score-weighted.At(row, test.col)) > 0.000001 {
+				t.Errorf("Expected score %f for term '%s' but found %f", weighted.At(row, test.col), term.Term, term.Score)
+			}
+		}
+	}
+
+	if terms := transformer.ExplainDocument(4, mat, vocabulary, 1); terms != nil {
+		t.Errorf("Expected nil for invalid column but found %v", terms)
+	}
+	if terms := NewTfidfTransformer().ExplainDocument(0, mat, vocabulary, 1); terms != nil {
+		t.Errorf("Expected nil for unfitted transformer but found %v", terms)
+	}
+}
+
+func indexOf(terms []string, term string) int {
+	for i, t := range terms {
+		if t == term {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestTfidfTransformerMeanContribution(t *testing.T) {
+	mat := mat64.NewDense(4, 3, []float64{
+		1, 0, 3,
+		2, 2, 0,
+		0, 4, 0,
+		0, 0, 0,
+	})
+
+	if means := NewTfidfTransformer().MeanContribution(mat); means != nil {
+		t.Errorf("Expected nil for unfitted transformer but found %v", means)
+	}
+
+	transformer, _ := NewTfidfTransformerWithWeights([]float64{1, 2, 0.5, 3})
+	expected := []float64{2, 4, 2, 0}
+	if means := transformer.MeanContribution(mat); !reflect.DeepEqual(expected, means) {
+		t.Errorf("Expected mean contributions %v but found %v", expected, means)
+	}
+
+	// with L1 normalisation the documents become [0.2 0.8 0 0], [0 2/3 1/3 0] and [1 0 0 0]
+	transformer.Norm = L1Norm
+	expected = []float64{0.6, (0.8 + 2/3.0) / 2, 1 / 3.0, 0}
+	means := transformer.MeanContribution(mat)
+	for i := range expected {
+		if len(means) != len(expected) || math.Abs(means[i]-expected[i]) > 0.000001 {
+			t.Errorf("Expected mean contributions %v but found %v", expected, means)
+			break
+		}
+	}
+
+	if means := transformer.MeanContribution(mat64.NewDense(2, 3, nil)); means != nil {
+		t.Errorf("Expected nil for mismatched matrix but found %v", means)
+	}
+}
+
+func TestTfidfTransformerTransformErrors(t *testing.T) {
+	input := mat64.NewDense(3, 2, []float64{
+		1, 0,
+		0, 2,
+		3, 1,
+	})
+
+	transformer := NewTfidfTransformer()
+
+	if _, err := transformer.Transform(input); err == nil {
+		t.Errorf("Expected error transforming with an unfitted transformer but found none")
+	}
+	if _, err := transformer.TransformSparse(input); err == nil {
+		t.Errorf("Expected error sparse transforming with an unfitted transformer but found none")
+	}
+
+	transformer.Fit(input)
+
+	for _, rows := range []int{2, 4} {
+		mat := mat64.NewDense(rows, 2, nil)
+		if _, err := transformer.Transform(mat); err == nil {
+			t.Errorf("Expected error transforming %d row matrix with transformer fitted on 3 terms", rows)
+		}
+		if _, err := transformer.TransformSparse(mat); err == nil {
+			t.Errorf("Expected error sparse transforming %d row matrix with transformer fitted on 3 terms", rows)
+		}
+	}
+
+	if _, err := transformer.Transform(input); err != nil {
+		t.Errorf("Failed tfidf transform caused by %v", err)
+	}
+}
+
+func TestTfidfTransformerAllowExtraTerms(t *testing.T) {
+	train := mat64.NewDense(3, 3, []float64{
+		1, 0, 2,
+		0, 2, 0,
+		3, 1, 1,
+	})
+	transformer := NewTfidfTransformer()
+	transformer.Fit(train)
+	weights := transformer.Weights()
+
+	// vocabulary has grown by 2 terms since fitting
+	grown := mat64.NewDense(5, 2, []float64{
+		1, 0,
+		0, 1,
+		2, 2,
+		1, 0,
+		0, 3,
+	})
+	if _, err := transformer.Transform(grown); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+
+	transformer.AllowExtraTerms = true
+	transformer.DefaultWeight = floats.Max(weights)
+	result, err := transformer.Transform(grown)
+	if err != nil {
+		t.Fatalf("Failed tfidf transform caused by %v", err)
+	}
+
+	max := transformer.DefaultWeight
+	expected := mat64.NewDense(5, 2, []float64{
+		weights[0], 0,
+		0, weights[1],
+		2 * weights[2], 2 * weights[2],
+		max, 0,
+		0, 3 * max,
+	})
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+	if sparse, err := transformer.TransformSparse(grown); err != nil || !mat64.EqualApprox(expected, sparse, 0.000001) {
+		t.Errorf("Expected sparse transform to match dense transform but found %v (error %v)", sparse, err)
+	}
+
+	// fewer rows than fitted are still rejected
+	if _, err := transformer.Transform(mat64.NewDense(2, 2, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+}
+
+func TestTfidfTransformerMarshalBinary(t *testing.T) {
+	input := mat64.NewDense(6, 4, []float64{
+		1, 3, 5, 2,
+		8, 1, 0, 0,
+		2, 1, 0, 1,
+		0, 0, 0, 0,
+		0, 0, 0, 1,
+		0, 1, 0, 0,
+	})
+	test := mat64.NewDense(6, 2, []float64{
+		1, 0,
+		2, 0,
+		0, 3,
+		1, 1,
+		0, 0,
+		4, 1,
+	})
+
+	transformer := NewTfidfTransformer()
+	transformer.Norm = L2Norm
+	transformer.SublinearTF = true
+	transformer.LogBase = 2
+	transformer.MaxIDF = 1.5
+	transformer.Fit(input)
+
+	expected, err := transformer.Transform(test)
+	if err != nil {
+		t.Errorf("Failed tfidf transform caused by %v", err)
+	}
+
+	data, err := transformer.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal transformer caused by %v", err)
+	}
+
+	var restored TfidfTransformer
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal transformer caused by %v", err)
+	}
+
+	if restored.LogBase != transformer.LogBase || restored.MaxIDF != transformer.MaxIDF {
+		t.Errorf("Expected LogBase %f and MaxIDF %f but found %f and %f",
+			transformer.LogBase, transformer.MaxIDF, restored.LogBase, restored.MaxIDF)
+	}
+	if restored.Documents() != transformer.Documents() {
+		t.Errorf("Expected %d documents but found %d", transformer.Documents(), restored.Documents())
+	}
+
+	result, err := restored.Transform(test)
+	if err != nil {
+		t.Errorf("Failed tfidf transform caused by %v", err)
+	}
+
+	if !mat64.Equal(expected, result) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	data[0] = 0
+	if err := restored.UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected error unmarshalling unsupported version but found none")
+	}
+}
+
+func TestBM25TransformerSaturation(t *testing.T) {
+	// the first term occurs an increasing number of times within each document while the
+	// document lengths are kept constant by the second term
+	input := mat64.NewDense(3, 5, []float64{
+		1, 2, 4, 8, 16,
+		15, 14, 12, 8, 0,
+		1, 0, 0, 0, 0,
+	})
+
+	transformer := NewBM25Transformer()
+	result, err := transformer.FitTransform(input)
+
+	if err != nil {
+		t.Errorf("Failed BM25 fit transform caused by %v", err)
+	}
+
+	ceiling := transformer.weights[0] * (transformer.K1 + 1)
+	prev, prevDelta := 0.0, math.Inf(1)
+	for j := 0; j < 5; j++ {
+		v := result.At(0, j)
+		delta := v - prev
+
+		if delta <= 0 {
+			t.Errorf("Expected weight to increase with term frequency but %f <= %f", v, prev)
+		}
+		if delta >= prevDelta {
+			t.Errorf("Expected weight increases to diminish (saturate) but found %f >= %f", delta, prevDelta)
+		}
+		if v >= ceiling {
+			t.Errorf("Expected weight %f to be less than saturation ceiling %f", v, ceiling)
+		}
+		prev, prevDelta = v, delta
+	}
+}
+
+func TestBM25TransformerEmptyDocuments(t *testing.T) {
+	var tests = []struct {
+		m     int
+		n     int
+		input []float64
+	}{
+		{
+			m: 3, n: 3,
+			input: []float64{
+				1, 0, 2,
+				0, 0, 3,
+				4, 0, 0,
+			},
+		},
+		{
+			m: 2, n: 2,
+			input: []float64{
+				0, 0,
+				0, 0,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		transformer := NewBM25Transformer()
+		input := mat64.NewDense(test.m, test.n, test.input)
+
+		result, err := transformer.FitTransform(input)
+		if err != nil {
+			t.Errorf("Failed BM25 fit transform caused by %v", err)
+		}
+
+		for i := 0; i < test.m; i++ {
+			for j := 0; j < test.n; j++ {
+				v := result.At(i, j)
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					t.Errorf("Expected finite value at (%d, %d) but found %f", i, j, v)
+				}
+				if test.input[i*test.n+j] == 0 && v != 0 {
+					t.Errorf("Expected zero at (%d, %d) but found %f", i, j, v)
+				}
+			}
+		}
+	}
+}
+
+func TestLogEntropyTransformer(t *testing.T) {
+	input := mat64.NewDense(4, 4, []float64{
+		1, 1, 1, 1,
+		2, 0, 0, 0,
+		1, 1, 0, 0,
+		0, 0, 0, 0,
+	})
+
+	// term 0 is evenly distributed: 1 + 4 * (0.25 * log(0.25)) / log(4) = 0
+	// term 1 occurs in a single document: 1 + (1 * log(1)) / log(4) = 1
+	// term 2 occurs in half the documents: 1 + 2 * (0.5 * log(0.5)) / log(4) = 0.5
+	// term 3 never occurs and so has a weight of 1
+	expectedWeights := []float64{0, 1, 0.5, 1}
+
+	transformer := NewLogEntropyTransformer()
+	if _, err := transformer.Transform(input); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+
+	result, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Fatalf("Failed log entropy fit transform caused by %v", err)
+	}
+
+	weights := transformer.Weights()
+	for i, w := range expectedWeights {
+		if math.Abs(weights[i]-w) > 0.000001 {
+			t.Errorf("Expected weight %f for term %d but found %f", w, i, weights[i])
+		}
+	}
+
+	expected := mat64.NewDense(4, 4, []float64{
+		0, 0, 0, 0,
+		math.Log(3), 0, 0, 0,
+		0.5 * math.Log(2), 0.5 * math.Log(2), 0, 0,
+		0, 0, 0, 0,
+	})
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+
+	if _, err := transformer.Transform(mat64.NewDense(3, 1, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+
+	// a single document carries no information about the distribution of terms
+	transformer.Fit(mat64.NewDense(2, 1, []float64{1, 3}))
+	if weights := transformer.Weights(); weights[0] != 1 || weights[1] != 1 {
+		t.Errorf("Expected weights of 1 for a single document but found %v", weights)
+	}
+}
+
+func TestTficfTransformer(t *testing.T) {
+	// term 0 is concentrated within class 0 whereas term 1 is spread across all 3 classes
+	// despite occuring in fewer documents than term 0
+	input := mat64.NewDense(4, 6, []float64{
+		1, 2, 1, 1, 0, 0,
+		1, 0, 0, 1, 0, 1,
+		0, 0, 0, 0, 1, 1,
+		0, 0, 0, 0, 0, 0,
+	})
+	labels := []int{0, 0, 0, 1, 2, 2}
+
+	transformer := NewTficfTransformer()
+	if _, err := transformer.Transform(input); !errors.Is(err, ErrNotFitted) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrNotFitted, err)
+	}
+	if err := transformer.FitWithLabels(input, labels[:5]); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' for mismatched labels but found '%v'", ErrDimensionMismatch, err)
+	}
+	if _, err := transformer.Transform(input); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' from Transform following failed fit but found '%v'", ErrDimensionMismatch, err)
+	}
+	if _, err := NewTficfTransformer().FitTransform(NewSparseMatrix(3, 0, nil, nil, nil)); !errors.Is(err, ErrEmptyCorpus) {
+		t.Errorf("Expected error wrapping '%v' for empty corpus but found '%v'", ErrEmptyCorpus, err)
+	}
+	if _, err := NewTficfTransformer().FitTransform(NewSparseMatrix(0, 2, nil, nil, nil)); !errors.Is(err, ErrEmptyVocabulary) {
+		t.Errorf("Expected error wrapping '%v' for empty vocabulary but found '%v'", ErrEmptyVocabulary, err)
+	}
+	if err := transformer.FitWithLabels(input, labels); err != nil {
+		t.Fatalf("Failed to fit transformer caused by %v", err)
+	}
+
+	// term 0 occurs in 2 classes, term 1 in 3, term 2 in 1 and term 3 in none
+	expectedWeights := []float64{
+		math.Log(4.0/3.0) + 1,
+		1,
+		math.Log(2) + 1,
+		math.Log(4) + 1,
+	}
+	weights := transformer.Weights()
+	for i, w := range expectedWeights {
+		if math.Abs(weights[i]-w) > 0.000001 {
+			t.Errorf("Expected weight %f for term %d but found %f", w, i, weights[i])
+		}
+	}
+	if weights[0] <= weights[1] {
+		t.Errorf("Expected term concentrated in fewer classes to be weighted more heavily but found %v", weights)
+	}
+
+	result, err := transformer.Transform(input)
+	if err != nil {
+		t.Fatalf("Failed to transform matrix caused by %v", err)
+	}
+	if v := result.At(0, 1); math.Abs(v-2*expectedWeights[0]) > 0.000001 {
+		t.Errorf("Expected weighted frequency %f but found %f", 2*expectedWeights[0], v)
+	}
+
+	if _, err := transformer.Transform(mat64.NewDense(3, 1, nil)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrDimensionMismatch, err)
+	}
+
+	// sparse matrices (including explicitly stored zeros) produce the same weights
+	sparse := NewSparseMatrix(2, 4, []int{0, 2, 3, 4, 5}, []int{0, 1, 0, 1, 0}, []float64{1, 0, 2, 1, 1})
+	if err := transformer.FitWithLabels(sparse, []int{0, 1, 1, 0}); err != nil {
+		t.Fatalf("Failed to fit transformer to sparse matrix caused by %v", err)
+	}
+	// term 0 occurs in both classes and term 1 only in class 1 (ignoring its stored zero)
+	expectedSparse := []float64{1, math.Log(1.5) + 1}
+	for i, w := range transformer.Weights() {
+		if math.Abs(w-expectedSparse[i]) > 0.000001 {
+			t.Errorf("Expected sparse weight %f for term %d but found %f", expectedSparse[i], i, w)
+		}
+	}
+
+	// without labels each document is its own class, matching scikit-learn compatible idf
+	transformer.Fit(input)
+	idf := NewScikitCompatibleTfidf().Fit(input).(*TfidfTransformer).Weights()
+	for i, w := range transformer.Weights() {
+		if math.Abs(w-idf[i]) > 0.000001 {
+			t.Errorf("Expected unlabelled weight %f for term %d to match idf but found %f", idf[i], i, w)
+		}
+	}
+}
+
+func TestTfTransformerTransform(t *testing.T) {
+	var tests = []struct {
+		m      int
+		n      int
+		input  []float64
 		output []float64
 	}{
 		{
-			m: 6, n: 4,
+			m: 3, n: 3,
 			input: []float64{
-				1, 3, 5, 2,
-				8, 1, 0, 0,
-				2, 1, 0, 1,
-				0, 0, 0, 0,
-				0, 0, 0, 1,
-				0, 1, 0, 0,
+				1, 0, 2,
+				3, 0, 2,
+				0, 0, 4,
 			},
-			tm: 6, tn: 4,
 			output: []float64{
-				0.000, 0.000, 0.000, 0.000,
-				4.087, 0.511, 0.000, 0.000,
-				0.446, 0.223, 0.000, 0.223,
-				0.000, 0.000, 0.000, 0.000,
-				0.000, 0.000, 0.000, 0.916,
-				0.000, 0.916, 0.000, 0.000,
+				0.25, 0, 0.25,
+				0.75, 0, 0.25,
+				0, 0, 0.5,
 			},
 		},
 	}
 
 	for _, test := range tests {
-		transformer := NewTfidfTransformer()
+		transformer := NewTfTransformer()
 		input := mat64.NewDense(test.m, test.n, test.input)
-		output := mat64.NewDense(test.tm, test.tn, test.output)
+		output := mat64.NewDense(test.m, test.n, test.output)
 
 		result, err := transformer.FitTransform(input)
-
 		if err != nil {
-			t.Errorf("Failed tfidf fit transform caused by %v", err)
+			t.Errorf("Failed tf fit transform caused by %v", err)
 		}
 
-		if !mat64.EqualApprox(output, result, 0.001) {
+		if !mat64.EqualApprox(output, result, 0.000001) {
 			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
 				mat64.Formatted(output),
 				mat64.Formatted(result))
 			t.Fail()
 		}
+	}
+}
 
-		// test that subsequent transforms produce same result as initial
-		result2, err := transformer.Transform(input)
+func TestTfTransformerAugmented(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 2,
+		4, 0, 1,
+		0, 0, 2,
+	})
+	expected := mat64.NewDense(3, 3, []float64{
+		0.625, 0, 1,
+		1, 0, 0.75,
+		0, 0, 1,
+	})
+
+	transformer := NewTfTransformer()
+	transformer.Augmented = true
+
+	result, err := transformer.FitTransform(input)
+	if err != nil {
+		t.Errorf("Failed augmented tf fit transform caused by %v", err)
+	}
+
+	if !mat64.EqualApprox(expected, result, 0.000001) {
+		t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
+			mat64.Formatted(expected),
+			mat64.Formatted(result))
+		t.Fail()
+	}
+}
+
+func TestNormaliser(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		3, 0, -1,
+		0, 0, 2,
+		4, 0, -2,
+	})
+
+	var tests = []struct {
+		norm   NormType
+		output []float64
+	}{
+		{
+			norm: NoNorm,
+			output: []float64{
+				3, 0, -1,
+				0, 0, 2,
+				4, 0, -2,
+			},
+		},
+		{
+			norm: L1Norm,
+			output: []float64{
+				3.0 / 7, 0, -0.2,
+				0, 0, 0.4,
+				4.0 / 7, 0, -0.4,
+			},
+		},
+		{
+			norm: L2Norm,
+			output: []float64{
+				0.6, 0, -1.0 / 3,
+				0, 0, 2.0 / 3,
+				0.8, 0, -2.0 / 3,
+			},
+		},
+		{
+			norm: MaxNorm,
+			output: []float64{
+				0.75, 0, -0.5,
+				0, 0, 1,
+				1, 0, -1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		normaliser := NewNormaliser(test.norm)
+		output := mat64.NewDense(3, 3, test.output)
 
+		result, err := normaliser.FitTransform(input)
 		if err != nil {
-			t.Errorf("Failed tfidf fit transform caused by %v", err)
+			t.Errorf("Failed normaliser fit transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(output, result, 0.000001) {
+			t.Logf("Expected matrix for norm %d: \n%v\n but found: \n%v\n",
+				test.norm,
+				mat64.Formatted(output),
+				mat64.Formatted(result))
+			t.Fail()
 		}
 
-		if !mat64.Equal(result, result2) {
-			t.Logf("Expected matrix: \n%v\n but found: \n%v\n",
-				mat64.Formatted(result),
-				mat64.Formatted(result2))
+		sparse, err := normaliser.TransformSparse(NewSparseMatrixFrom(input))
+		if err != nil {
+			t.Errorf("Failed normaliser sparse transform caused by %v", err)
+		}
+		if !mat64.EqualApprox(output, sparse, 0.000001) {
+			t.Logf("Expected matrix for norm %d: \n%v\n but found: \n%v\n",
+				test.norm,
+				mat64.Formatted(output),
+				mat64.Formatted(sparse))
 			t.Fail()
 		}
 	}
+
+	// the input should not be modified
+	if input.At(0, 0) != 3 {
+		t.Errorf("Expected input matrix to be unchanged but found %f", input.At(0, 0))
+	}
 }
 
 func benchmarkTFIDFFitTransform(t Transformer, m, n int, b *testing.B) {
@@ -137,3 +2282,272 @@ func BenchmarkTFIDFFitTransform2000x1000(b *testing.B) {
 func BenchmarkTFIDFFitTransform20000x10000(b *testing.B) {
 	benchmarkTFIDFFitTransform(NewTfidfTransformer(), 20000, 10000, b)
 }
+
+func benchmarkTFIDFFit(workers int, b *testing.B) {
+	mat := randomSparseTermDocMatrix(100000, 100, 200).ToDense()
+	m, _ := mat.Dims()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		countDocumentFrequencies(mat, make([]float64, m), 0, workers)
+	}
+}
+
+func BenchmarkTFIDFFitSequential100000x100(b *testing.B) {
+	benchmarkTFIDFFit(1, b)
+}
+func BenchmarkTFIDFFitParallel100000x100(b *testing.B) {
+	benchmarkTFIDFFit(runtime.NumCPU(), b)
+}
+
+func BenchmarkTFIDFTransform2000x500(b *testing.B) {
+	mat := randomSparseTermDocMatrix(2000, 500, 100).ToDense()
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.Transform(mat)
+	}
+}
+
+func BenchmarkTFIDFTransform32_2000x500(b *testing.B) {
+	mat := randomSparseTermDocMatrix(2000, 500, 100).ToDense()
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.Transform32(mat)
+	}
+}
+
+func BenchmarkTFIDFTransformInto2000x500(b *testing.B) {
+	mat := randomSparseTermDocMatrix(2000, 500, 100).ToDense()
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	dst := mat64.NewDense(2000, 500, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.TransformInto(dst, mat)
+	}
+}
+
+func BenchmarkTFIDFTransformInPlace2000x500(b *testing.B) {
+	mat := randomSparseTermDocMatrix(2000, 500, 100).ToDense()
+	transformer := NewTfidfTransformer()
+	// normalise so repeatedly weighting the same matrix does not overflow
+	transformer.Norm = L2Norm
+	transformer.Fit(mat)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.TransformInPlace(mat)
+	}
+}
+
+// randomSparseTermDocMatrix creates a m x n term document matrix with approximately nnz
+// non zero elements per document.
+func randomSparseTermDocMatrix(m, n, nnz int) *SparseMatrix {
+	rnd := rand.New(rand.NewSource(1))
+	indptr := make([]int, n+1)
+	var ind []int
+	var data []float64
+	for j := 0; j < n; j++ {
+		rows := rnd.Perm(m)[:nnz]
+		sort.Ints(rows)
+		for _, i := range rows {
+			ind = append(ind, i)
+			data = append(data, float64(rnd.Intn(5)+1))
+		}
+		indptr[j+1] = len(ind)
+	}
+	return NewSparseMatrix(m, n, indptr, ind, data)
+}
+
+func BenchmarkTFIDFTransformDense50000x200(b *testing.B) {
+	mat := randomSparseTermDocMatrix(50000, 200, 100)
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.Transform(mat)
+	}
+}
+
+func BenchmarkTFIDFTransformSparse50000x200(b *testing.B) {
+	mat := randomSparseTermDocMatrix(50000, 200, 100)
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.TransformSparse(mat)
+	}
+}
+
+func BenchmarkTFIDFTransformSparseQuery50000(b *testing.B) {
+	mat := randomSparseTermDocMatrix(50000, 200, 100)
+	query := randomSparseTermDocMatrix(50000, 1, 10)
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.TransformSparse(query)
+	}
+}
+
+func BenchmarkTFIDFTransformSparseIntoQuery50000(b *testing.B) {
+	mat := randomSparseTermDocMatrix(50000, 200, 100)
+	query := randomSparseTermDocMatrix(50000, 1, 10)
+	transformer := NewTfidfTransformer()
+	transformer.Fit(mat)
+	dst := &SparseMatrix{}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		transformer.TransformSparseInto(dst, query)
+	}
+}
+
+func TestClipTransformer(t *testing.T) {
+	input := mat64.NewDense(3, 3, []float64{
+		1, 0, 4000,
+		-3, 2, 0,
+		3, 10, 11,
+	})
+
+	var tests = []struct {
+		min, max float64
+		output   []float64
+	}{
+		{
+			min: 0, max: 10,
+			output: []float64{
+				1, 0, 10,
+				0, 2, 0,
+				3, 10, 10,
+			},
+		},
+		{
+			min: 1, max: 3,
+			output: []float64{
+				1, 1, 3,
+				1, 2, 1,
+				3, 3, 3,
+			},
+		},
+		{
+			min: math.Inf(-1), max: math.Inf(1),
+			output: []float64{
+				1, 0, 4000,
+				-3, 2, 0,
+				3, 10, 11,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		transformer := NewClipTransformer(test.min, test.max)
+		expected := mat64.NewDense(3, 3, test.output)
+
+		for _, in := range []mat64.Matrix{input, NewSparseMatrixFrom(input)} {
+			result, err := transformer.FitTransform(in)
+			if err != nil {
+				t.Errorf("Failed clip transform caused by %v", err)
+				continue
+			}
+			if !mat64.Equal(expected, result) {
+				t.Logf("Range (%f, %f): Expected matrix: \n%v\n but found: \n%v\n",
+					test.min, test.max,
+					mat64.Formatted(expected),
+					mat64.Formatted(result))
+				t.Fail()
+			}
+		}
+	}
+
+	if _, err := NewClipTransformer(2, 1).Transform(input); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected error wrapping '%v' but found '%v'", ErrInvalidArgument, err)
+	}
+}
+
+func TestTransformerClone(t *testing.T) {
+	train := mat64.NewDense(4, 5, []float64{
+		1, 0, 2, 0, 1,
+		0, 3, 1, 0, 0,
+		2, 1, 0, 1, 4,
+		0, 0, 1, 2, 1,
+	})
+	other := mat64.NewDense(4, 3, []float64{
+		5, 0, 0,
+		0, 0, 7,
+		1, 1, 1,
+		0, 2, 0,
+	})
+
+	var tests = []Transformer{
+		NewTfidfTransformer(),
+		NewBM25Transformer(),
+		NewLogEntropyTransformer(),
+		NewTficfTransformer(),
+		NewTfTransformer(),
+		NewNormaliser(L2Norm),
+		NewClipTransformer(0, 2),
+		NewTruncatedSVD(2),
+		NewRandomProjection(2, 1),
+		NewPCA(2),
+		NewStandardScaler(),
+		&LatentDirichletAllocation{K: 2, Alpha: 0.1, Beta: 0.01, MaxIter: 20, Seed: 1},
+		Freeze(NewTfidfTransformer().Fit(train)),
+	}
+
+	for _, transformer := range tests {
+		transformer.Fit(train)
+		expected, err := transformer.Transform(other)
+		if err != nil {
+			t.Errorf("%T: Failed to transform matrix caused by %v", transformer, err)
+			continue
+		}
+
+		cloner, ok := transformer.(Cloner)
+		if !ok {
+			t.Errorf("%T: Expected transformer to implement Cloner", transformer)
+			continue
+		}
+		clone := cloner.Clone()
+		if clone == transformer {
+			t.Errorf("%T: Expected clone to be a distinct transformer", transformer)
+		}
+
+		// mutating the clone, by further fitting, should leave the original unchanged
+		clone.Fit(other)
+		if p, ok := clone.(*TfidfTransformer); ok {
+			p.PartialFit(other)
+		}
+		result, err := transformer.Transform(other)
+		if err != nil {
+			t.Errorf("%T: Failed to transform matrix with original caused by %v", transformer, err)
+			continue
+		}
+		if !mat64.EqualApprox(expected, result, 0.000001) {
+			t.Errorf("%T: Expected original to be unchanged following fitting of clone", transformer)
+		}
+	}
+
+	// clones of an unfitted transformer remain unfitted
+	if weights := NewTfidfTransformer().Clone().(*TfidfTransformer).Weights(); weights != nil {
+		t.Errorf("Expected clone of unfitted transformer to be unfitted but found weights %v", weights)
+	}
+}
@@ -0,0 +1,41 @@
+package nlp
+
+import "strings"
+
+// Window splits each of the supplied documents into overlapping windows of size words,
+// with the start of each successive window advancing by stride words, so that long
+// documents can be vectorised as several shorter documents (one column per window).
+// Words are separated by whitespace and the words of each window are joined by a single
+// space.  Windows are generated until the end of the document is reached so the final
+// window of a document may contain fewer than size words.  Documents containing size or
+// fewer words (including empty documents) produce a single window.  Alongside the windows,
+// Window returns, for each window, the index of the document from which it was taken so
+// that scores for the windows may be aggregated back to the documents.  A size of less
+// than 1 produces a single window per document and a stride of less than 1 is treated as
+// size (i.e. non overlapping windows).
+func Window(docs []string, size, stride int) ([]string, []int) {
+	if stride < 1 {
+		stride = size
+	}
+
+	var windows []string
+	var index []int
+	for d, doc := range docs {
+		words := strings.Fields(doc)
+		if size < 1 || len(words) <= size {
+			windows = append(windows, strings.Join(words, " "))
+			index = append(index, d)
+			continue
+		}
+
+		for start := 0; ; start += stride {
+			end := min(start+size, len(words))
+			windows = append(windows, strings.Join(words[start:end], " "))
+			index = append(index, d)
+			if end == len(words) {
+				break
+			}
+		}
+	}
+	return windows, index
+}
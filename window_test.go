@@ -0,0 +1,102 @@
+package nlp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+	var tests = []struct {
+		docs    []string
+		size    int
+		stride  int
+		windows []string
+		index   []int
+	}{
+		{
+			docs:    []string{"a b c d e f g h i j"},
+			size:    4,
+			stride:  3,
+			windows: []string{"a b c d", "d e f g", "g h i j"},
+			index:   []int{0, 0, 0},
+		},
+		{
+			// final window is truncated at the end of the document
+			docs:    []string{"a b c d e f g h i j k"},
+			size:    4,
+			stride:  3,
+			windows: []string{"a b c d", "d e f g", "g h i j", "j k"},
+			index:   []int{0, 0, 0, 0},
+		},
+		{
+			// stride less than 1 gives non overlapping windows
+			docs:    []string{"a b c d e"},
+			size:    2,
+			stride:  0,
+			windows: []string{"a b", "c d", "e"},
+			index:   []int{0, 0, 0},
+		},
+		{
+			// documents shorter than the window (or empty) produce a single window
+			docs:    []string{"a  b", "", "c d e f"},
+			size:    3,
+			stride:  1,
+			windows: []string{"a b", "", "c d e", "d e f"},
+			index:   []int{0, 1, 2, 2},
+		},
+		{
+			docs:    []string{"a b c"},
+			size:    0,
+			stride:  1,
+			windows: []string{"a b c"},
+			index:   []int{0},
+		},
+	}
+
+	for ti, test := range tests {
+		windows, index := Window(test.docs, test.size, test.stride)
+		if !reflect.DeepEqual(test.windows, windows) || !reflect.DeepEqual(test.index, index) {
+			t.Errorf("Test %d: Expected windows %q (%v) but found %q (%v)", ti, test.windows, test.index, windows, index)
+		}
+	}
+}
+
+func TestWindowLongDocument(t *testing.T) {
+	words := make([]string, 1000)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	docs := []string{"short document", strings.Join(words, " ")}
+
+	windows, index := Window(docs, 100, 50)
+
+	// 1 window for the short document and (1000 - 100) / 50 + 1 for the long document
+	if len(windows) != 20 || len(index) != 20 {
+		t.Fatalf("Expected 20 windows but found %d", len(windows))
+	}
+	if index[0] != 0 || windows[0] != docs[0] {
+		t.Errorf("Expected the short document as a single window but found %q", windows[0])
+	}
+	for w := 1; w < len(windows); w++ {
+		if index[w] != 1 {
+			t.Errorf("Expected window %d to map to document 1 but found %d", w, index[w])
+		}
+		fields := strings.Fields(windows[w])
+		if len(fields) != 100 || fields[0] != words[(w-1)*50] {
+			t.Errorf("Expected window %d to contain 100 words starting at word %d but found %d starting at %s",
+				w, (w-1)*50, len(fields), fields[0])
+		}
+	}
+
+	// the windows may be vectorised with each window becoming a column
+	vectoriser := NewCountVectoriser(false)
+	mat, err := vectoriser.FitTransform(windows...)
+	if err != nil {
+		t.Fatalf("Error fitting and applying vectoriser caused by %v", err)
+	}
+	if _, c := mat.Dims(); c != len(windows) {
+		t.Errorf("Expected a column per window but found %d", c)
+	}
+}